@@ -6,13 +6,20 @@ Licensed under MIT License
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/commoddity/websocket-load-test/internal/backoff"
 	"github.com/commoddity/websocket-load-test/internal/client"
+	"github.com/commoddity/websocket-load-test/internal/report"
 	"github.com/commoddity/websocket-load-test/internal/stats"
 	"github.com/commoddity/websocket-load-test/internal/terminal"
 	"github.com/commoddity/websocket-load-test/internal/types"
@@ -26,9 +33,65 @@ var (
 	apiKey        string
 	subscriptions string
 	subCount      int
+	connections   int
 	enableLogging bool
+
+	// Subscription API flags
+	protocol           string
+	subscribeMethod    string
+	unsubscribeMethod  string
+	notificationMethod string
+	subParams          []string
+	unsubscribeParams  string
+
+	// Reconnection flags
+	backoffStrategy string
+	backoffBase     time.Duration
+	backoffMax      time.Duration
+	maxRetries      int
+
+	// Heartbeat flags
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+
+	// Stall detection flags
+	stallThresholds string
+	stallReconnect  bool
+
+	// Authentication flags
+	retryAuthFailures bool
+
+	// TLS flags
+	tlsCAFile             string
+	tlsCertFile           string
+	tlsKeyFile            string
+	tlsServerName         string
+	tlsMinVersion         string
+	tlsInsecureSkipVerify bool
+
+	// Proxy flags
+	proxy string
+
+	// Compression flags
+	compression bool
+
+	// Call workload flags
+	callRate   float64
+	callMethod string
+	callParams string
+
+	// Output flags
+	outputs         string
+	noTTY           bool
+	statusInterval  time.Duration
+	showHeaders     string
+	sortConnections string
 )
 
+// exitAuthFailure is the exit status when the server rejected the credentials,
+// so CI runs can tell a misconfigured API key from an unreachable endpoint
+const exitAuthFailure = 2
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "websocket-load-test",
@@ -76,10 +139,46 @@ Prerequisites:
     --count 50 \
     --log
 
-  # Only XRPL EVM service is supported
+  # Keep the dashboard and also write a JSON summary and a CSV time series
+  websocket-load-test \
+    --app-id "your_app_id_here" \
+    --api-key "your_api_key_here" \
+    --output "dashboard,json=summary.json,csv=series.csv"
+
+  # Solana PubSub with slot gap detection
+  websocket-load-test \
+    --service solana \
+    --app-id "your_app_id_here" \
+    --api-key "your_api_key_here" \
+    --subs "slot,logs"
+
+  # CometBFT events of a Cosmos chain, with block height gap detection
+  websocket-load-test \
+    --service osmosis \
+    --protocol cometbft \
+    --app-id "your_app_id_here" \
+    --api-key "your_api_key_here" \
+    --subs "NewBlock,Tx"
+
+  # Substrate best and finalized heads with finality lag
+  websocket-load-test \
+    --service polkadot \
+    --protocol substrate \
+    --app-id "your_app_id_here" \
+    --api-key "your_api_key_here"
+
+  # GraphQL subscriptions over graphql-transport-ws, one query per operation
+  websocket-load-test \
+    --service indexer \
+    --protocol graphql \
+    --app-id "your_app_id_here" \
+    --api-key "your_api_key_here" \
+    --subs "blocks" \
+    --sub-params 'blocks={"query":"subscription { blocks { number } }"}'
 
 URLs are automatically constructed as:
-  wss://xrplevm.rpc.grove.city/v1/[app-id]`,
+  wss://[service].rpc.grove.city/v1/[app-id]
+with /websocket appended for the cometbft protocol`,
 
 	Run: runWebSocketLoadTest,
 }
@@ -96,7 +195,7 @@ func Execute() {
 func init() {
 	// Grove Portal connection flags
 	rootCmd.Flags().StringVarP(&serviceID, "service", "s", "xrplevm",
-		"🎯 Grove Portal service (only xrplevm supported)")
+		fmt.Sprintf("🎯 Grove Portal service (%s, or any other with --protocol)", supportedServices()))
 
 	rootCmd.Flags().StringVarP(&appID, "app-id", "a", "",
 		"🆔 Grove Portal Application ID")
@@ -106,14 +205,120 @@ func init() {
 
 	// Subscription flags
 	rootCmd.Flags().StringVar(&subscriptions, "subs", "newHeads",
-		"📡 Comma-separated subscription types (newHeads,newPendingTransactions,logs)")
+		"📡 Comma-separated subscription types (newHeads,newPendingTransactions,logs); use newPendingTransactions:full for full transaction objects")
 
 	rootCmd.Flags().IntVarP(&subCount, "count", "c", 1,
 		"📊 Number of subscriptions to create for each type")
 
+	rootCmd.Flags().IntVar(&connections, "connections", 1,
+		"🔌 Number of concurrent connections, each with its own subscriptions")
+
 	rootCmd.Flags().BoolVarP(&enableLogging, "log", "l", false,
 		"📝 Display latest WebSocket message in formatted JSON")
 
+	// Subscription API flags
+	rootCmd.Flags().StringVar(&protocol, "protocol", "",
+		fmt.Sprintf("🧩 Subscription protocol (%s); defaults to the service's protocol, required for other services", protocolNames()))
+
+	rootCmd.Flags().StringVar(&subscribeMethod, "subscribe-method", "eth_subscribe",
+		"🧩 JSON-RPC method that subscribes; {type} is replaced by the subscription type (e.g. \"{type}Subscribe\")")
+
+	rootCmd.Flags().StringVar(&unsubscribeMethod, "unsubscribe-method", "eth_unsubscribe",
+		"🧩 JSON-RPC method that cancels a subscription on shutdown; {type} is replaced by the subscription type")
+
+	rootCmd.Flags().StringVar(&notificationMethod, "notification-method", "eth_subscription",
+		"🧩 JSON-RPC method of subscription notifications; {type} is replaced by the subscription type")
+
+	rootCmd.Flags().StringArrayVar(&subParams, "sub-params", nil,
+		"🧩 Subscribe params template for a type as type=JSON, repeatable; * applies to every other type and {type} is replaced (e.g. 'logs=[\"logs\",{\"address\":\"0x00\"}]')")
+
+	rootCmd.Flags().StringVar(&unsubscribeParams, "unsubscribe-params", `["{id}"]`,
+		"🧩 Unsubscribe params template; \"{id}\" is replaced by the subscription ID")
+
+	// Reconnection flags
+	rootCmd.Flags().StringVar(&backoffStrategy, "backoff", "exponential",
+		"⏳ Reconnect backoff strategy (constant,linear,exponential,decorrelated)")
+
+	rootCmd.Flags().DurationVar(&backoffBase, "backoff-base", 2*time.Second,
+		"⏱️  Base delay before reconnecting")
+
+	rootCmd.Flags().DurationVar(&backoffMax, "backoff-max", 30*time.Second,
+		"⏱️  Maximum delay between reconnection attempts")
+
+	rootCmd.Flags().IntVar(&maxRetries, "max-retries", 0,
+		"🛑 Give up after this many consecutive failed reconnection attempts (0 retries forever)")
+
+	// Heartbeat flags
+	rootCmd.Flags().DurationVar(&pingInterval, "ping-interval", 15*time.Second,
+		"💓 Interval between WebSocket pings (0 disables heartbeats)")
+
+	rootCmd.Flags().DurationVar(&pongTimeout, "pong-timeout", 10*time.Second,
+		"💔 Reconnect when no pong arrives within this long after a ping is due")
+
+	// Stall detection flags
+	rootCmd.Flags().StringVar(&stallThresholds, "stall-threshold", "",
+		"🚨 Flag a subscription as stalled when silent for longer than expected, as type=duration pairs (e.g. \"newHeads=10s\")")
+
+	rootCmd.Flags().BoolVar(&stallReconnect, "stall-reconnect", false,
+		"🔌 Reconnect when a subscription stalls")
+
+	// Authentication flags
+	rootCmd.Flags().BoolVar(&retryAuthFailures, "retry-auth-failures", false,
+		"🔐 Keep reconnecting when the server rejects the credentials instead of exiting")
+
+	// TLS flags
+	rootCmd.Flags().StringVar(&tlsCAFile, "tls-ca", "",
+		"🏛️  PEM bundle of CA certificates to trust instead of the system roots")
+
+	rootCmd.Flags().StringVar(&tlsCertFile, "tls-cert", "",
+		"🪪 PEM client certificate for mutual TLS (requires --tls-key)")
+
+	rootCmd.Flags().StringVar(&tlsKeyFile, "tls-key", "",
+		"🔑 PEM private key of the client certificate")
+
+	rootCmd.Flags().StringVar(&tlsServerName, "tls-server-name", "",
+		"🏷️  Server name sent as SNI and used to verify the certificate, instead of the URL host")
+
+	rootCmd.Flags().StringVar(&tlsMinVersion, "tls-min-version", "",
+		"🔒 Minimum TLS version (1.0, 1.1, 1.2 or 1.3)")
+
+	rootCmd.Flags().BoolVar(&tlsInsecureSkipVerify, "tls-insecure-skip-verify", false,
+		"⚠️  Skip server certificate verification (staging only)")
+
+	// Proxy flags
+	rootCmd.Flags().StringVar(&proxy, "proxy", "",
+		"🌐 HTTP CONNECT or SOCKS5 proxy URL, with optional user:password (defaults to HTTPS_PROXY/HTTP_PROXY; \"direct\" ignores them)")
+
+	// Compression flags
+	rootCmd.Flags().BoolVar(&compression, "compression", false,
+		"🗜️  Negotiate permessage-deflate compression and compare wire bytes with decompressed payloads")
+
+	// Call workload flags
+	rootCmd.Flags().Float64Var(&callRate, "call-rate", 0,
+		"📞 JSON-RPC calls per second each connection sends, open loop, with latency corrected for calls sent late (0 to disable)")
+
+	rootCmd.Flags().StringVar(&callMethod, "call-method", "eth_blockNumber",
+		"📞 JSON-RPC method of workload calls")
+
+	rootCmd.Flags().StringVar(&callParams, "call-params", "",
+		"📞 JSON array or object of params for workload calls, e.g. '[\"latest\",false]'")
+
+	// Output flags
+	rootCmd.Flags().StringVarP(&outputs, "output", "o", "dashboard",
+		"🖨️  Comma-separated outputs as format[=file] (dashboard,text,json,csv,metrics); outputs without a file write to stdout")
+
+	rootCmd.Flags().BoolVar(&noTTY, "no-tty", false,
+		"📜 Plain append-only output without colors, emojis or screen redraws (automatic when stdout is not a terminal)")
+
+	rootCmd.Flags().DurationVar(&statusInterval, "status-interval", 10*time.Second,
+		"⏲️  Interval between plain-text status lines")
+
+	rootCmd.Flags().StringVar(&showHeaders, "show-headers", strings.Join(types.DefaultCaptureHeaders, ","),
+		"📨 Comma-separated upgrade response headers to show in reports, e.g. rate limits and request IDs (empty to hide)")
+
+	rootCmd.Flags().StringVar(&sortConnections, "sort-connections", string(report.SortIndex),
+		"🧮 Column to sort the per-connection table by (index,state,uptime,reconnects,subs,events,rate,last-event,error); numbers sort largest first, states worst first")
+
 	// Mark required flags
 	_ = rootCmd.MarkFlagRequired("app-id")
 	_ = rootCmd.MarkFlagRequired("api-key")
@@ -121,14 +326,146 @@ func init() {
 
 // runWebSocketLoadTest is the main application logic
 func runWebSocketLoadTest(cmd *cobra.Command, args []string) {
-	// Validate service
-	if serviceID != "xrplevm" {
-		fmt.Printf("❌ Error: Only 'xrplevm' service is supported, got '%s'\n", serviceID)
+	// Validate the service and its protocol
+	wsProtocol, err := resolveProtocol(serviceID, protocol, cmd.Flags().Changed("protocol"))
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !cmd.Flags().Changed("subs") {
+		subscriptions = wsProtocol.DefaultSubscriptions()
+	}
+
+	// GraphQL operations have no default query to subscribe with
+	if wsProtocol == client.ProtocolGraphQL && strings.TrimSpace(subscriptions) == "" {
+		fmt.Println("❌ Error: --subs is required with the graphql protocol")
+		os.Exit(1)
+	}
+
+	// Validate subscriptions
+	subs, err := client.ParseSubscriptions(subscriptions)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A CometBFT connection can subscribe to each query only once
+	if wsProtocol == client.ProtocolCometBFT && subCount > 1 {
+		fmt.Println("❌ Error: CometBFT allows one subscription per query on a connection; use --connections instead of --count")
+		os.Exit(1)
+	}
+
+	if connections < 1 {
+		fmt.Println("❌ Error: --connections must be at least 1")
+		os.Exit(1)
+	}
+
+	// Validate stall thresholds
+	thresholds, err := client.ParseStallThresholds(stallThresholds, subs)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if stallReconnect && len(thresholds) == 0 {
+		fmt.Println("❌ Error: --stall-reconnect requires --stall-threshold")
+		os.Exit(1)
+	}
+
+	// Validate backoff settings
+	strategy, err := backoff.ParseStrategy(backoffStrategy)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if backoffBase <= 0 || backoffMax < backoffBase || maxRetries < 0 {
+		fmt.Println("❌ Error: --backoff-base must be positive, --backoff-max at least --backoff-base and --max-retries not negative")
+		os.Exit(1)
+	}
+
+	// Validate heartbeat settings
+	if pingInterval < 0 || (pingInterval > 0 && pongTimeout <= 0) {
+		fmt.Println("❌ Error: --ping-interval must not be negative and --pong-timeout must be positive when pings are enabled")
+		os.Exit(1)
+	}
+
+	// Validate subscription API settings
+	pubsub, err := parsePubSub(cmd, wsProtocol)
+	if err == nil {
+		err = wsProtocol.CheckSubscriptions(subs, pubsub)
+	}
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Validate call workload settings
+	if callRate < 0 || (callRate > 0 && strings.TrimSpace(callMethod) == "") {
+		fmt.Println("❌ Error: --call-rate must not be negative and --call-method must be set when calls are enabled")
+		os.Exit(1)
+	}
+	// Calls are JSON-RPC requests, which a graphql-transport-ws server rejects
+	if callRate > 0 && wsProtocol == client.ProtocolGraphQL {
+		fmt.Println("❌ Error: --call-rate is not supported with the graphql protocol")
+		os.Exit(1)
+	}
+	params, err := client.ParseCallParams(callParams)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Validate TLS settings
+	tlsVersion, err := client.ParseTLSVersion(tlsMinVersion)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	tlsConfig, err := client.NewTLSConfig(client.TLSOptions{
+		CAFile:             tlsCAFile,
+		CertFile:           tlsCertFile,
+		KeyFile:            tlsKeyFile,
+		ServerName:         tlsServerName,
+		MinVersion:         tlsVersion,
+		InsecureSkipVerify: tlsInsecureSkipVerify,
+	})
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Validate outputs
+	outputList, err := report.ParseOutputs(outputs)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	captureHeaders, err := client.ParseHeaderNames(showHeaders)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	connectionSort, err := report.ParseConnectionSort(sortConnections)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Without a terminal, screen redraws and emojis only garble logs
+	headless := noTTY || !terminal.IsTerminal(os.Stdout)
+	if headless {
+		terminal.DisableColor()
+		outputList = report.Headless(outputList)
+	}
+
 	// Construct Grove Portal WebSocket URL
-	wsURL := fmt.Sprintf("wss://%s.rpc.grove.city/v1/%s", serviceID, appID)
+	wsURL := fmt.Sprintf("wss://%s.rpc.grove.city/v1/%s", serviceID, appID) + wsProtocol.Path()
+
+	// Resolve the proxy from the flag or the environment
+	proxyURL, err := client.ResolveProxy(proxy, wsURL)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Create configuration from flags
 	config := &types.Config{
@@ -138,6 +475,35 @@ func runWebSocketLoadTest(cmd *cobra.Command, args []string) {
 		Subscriptions: subscriptions,
 		SubCount:      subCount,
 		EnableLogging: enableLogging,
+
+		Connections: connections,
+
+		BackoffStrategy: string(strategy),
+		BackoffBase:     backoffBase,
+		BackoffMax:      backoffMax,
+		MaxRetries:      maxRetries,
+
+		PingInterval: pingInterval,
+		PongTimeout:  pongTimeout,
+
+		StallThresholds: thresholds,
+		StallReconnect:  stallReconnect,
+
+		RetryAuthFailures: retryAuthFailures,
+
+		CaptureHeaders: captureHeaders,
+
+		TLS:   tlsConfig,
+		Proxy: proxyURL,
+
+		Compression: compression,
+
+		CallRate:   callRate,
+		CallMethod: strings.TrimSpace(callMethod),
+		CallParams: params,
+
+		Protocol: string(wsProtocol),
+		PubSub:   pubsub,
 	}
 
 	// Setup interrupt handler
@@ -149,18 +515,40 @@ func runWebSocketLoadTest(cmd *cobra.Command, args []string) {
 	statsManager := stats.NewManager()
 	if enableLogging {
 		statsManager.EnableLogging()
-		statsManager.SetConfig(config)
 	}
-	wsClient := client.NewWebSocketClient(config, statsManager, done)
+	reporter, err := report.Open(outputList, report.Options{
+		Config:         config,
+		ShowMessages:   enableLogging,
+		StatusInterval: statusInterval,
+		Headers:        config.CaptureHeaders,
+		ConnectionSort: connectionSort,
+	})
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer reporter.Close()
+	clients := make([]*client.WebSocketClient, config.Connections)
+	for i := range clients {
+		clients[i] = client.NewWebSocketClient(config, statsManager, done)
+	}
 
 	// Display startup information
-	displayStartupInfo(config)
+	if headless {
+		displayPlainStartupInfo(config)
+	} else {
+		displayStartupInfo(config)
+	}
 
-	// Start the WebSocket client
-	wsClient.Start()
+	// Start the WebSocket clients
+	for _, wsClient := range clients {
+		wsClient.Start()
+	}
 
-	// Start automatic display updates
+	// Start automatic report updates
+	updatesDone := make(chan struct{})
 	go func() {
+		defer close(updatesDone)
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
 		for {
@@ -168,20 +556,174 @@ func runWebSocketLoadTest(cmd *cobra.Command, args []string) {
 			case <-done:
 				return
 			case <-ticker.C:
-				if statsManager.GetStats().TotalConnections > 0 {
-					statsManager.DisplayRunningStats(wsClient.GetTotalSubscriptions())
+				if err := reporter.Update(statsManager.Snapshot()); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Report error: %v\n", err)
 				}
 			}
 		}
 	}()
 
-	// Wait for interrupt
-	<-interrupt
-	terminal.Cyan.Println("\n🛑 Received interrupt signal, shutting down...")
+	// Wait for interrupt or for every client to give up reconnecting
+	var clientErr error
+	select {
+	case <-interrupt:
+		if headless {
+			fmt.Println("Received interrupt signal, shutting down...")
+		} else {
+			terminal.Cyan.Println("\n🛑 Received interrupt signal, shutting down...")
+		}
+	case <-allFinished(clients):
+		clientErr = clientError(clients)
+		if headless {
+			fmt.Printf("%v, shutting down...\n", clientErr)
+		} else {
+			terminal.Red.Printf("\n🛑 %v, shutting down...\n", clientErr)
+		}
+	}
 	close(done)
+	<-updatesDone
+
+	// Let the clients unsubscribe and record how their connections ended
+	select {
+	case <-allFinished(clients):
+	case <-time.After(shutdownTimeout):
+		fmt.Fprintf(os.Stderr, "⚠️  Clients still running after %v, reporting anyway\n", shutdownTimeout)
+	}
 
 	// Print final statistics
-	statsManager.PrintFinalStats(wsClient.GetTotalSubscriptions())
+	if err := reporter.Final(statsManager.Snapshot()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Report error: %v\n", err)
+	}
+
+	if clientErr != nil {
+		exitCode := 1
+		var authErr *client.AuthError
+		if errors.As(clientErr, &authErr) {
+			printAuthFailure(os.Stderr, authErr, headless)
+			exitCode = exitAuthFailure
+		}
+		reporter.Close()
+		os.Exit(exitCode)
+	}
+}
+
+// shutdownTimeout bounds how long the clients get to stop on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// serviceProtocols maps every supported service to its subscription protocol
+var serviceProtocols = map[string]client.Protocol{
+	"xrplevm": client.ProtocolEthereum,
+	"solana":  client.ProtocolSolana,
+}
+
+// supportedServices lists the services with a protocol of their own
+func supportedServices() string {
+	return strings.Join(slices.Sorted(maps.Keys(serviceProtocols)), ", ")
+}
+
+// protocolNames lists every --protocol value
+func protocolNames() string {
+	names := make([]string, len(client.Protocols))
+	for i, protocol := range client.Protocols {
+		names[i] = string(protocol)
+	}
+	return strings.Join(names, ", ")
+}
+
+// resolveProtocol returns the subscription protocol of the service, or the
+// protocol flag when it is set. A service the tool has no protocol for can
+// be tested by setting the flag.
+func resolveProtocol(service, protocolFlag string, protocolSet bool) (client.Protocol, error) {
+	serviceProtocol, known := serviceProtocols[service]
+	switch {
+	case service == "":
+		return "", fmt.Errorf("--service must not be empty")
+	case protocolSet:
+		return client.ParseProtocol(protocolFlag)
+	case !known:
+		return "", fmt.Errorf("unsupported service '%s' (supported: %s; set --protocol for other services)", service, supportedServices())
+	}
+	return serviceProtocol, nil
+}
+
+// parsePubSub builds the subscription API configuration from the flags.
+// Flags left unset keep the methods and params of the protocol.
+func parsePubSub(cmd *cobra.Command, wsProtocol client.Protocol) (types.PubSub, error) {
+	pubsub := wsProtocol.PubSub()
+	for flagName, method := range map[string]*string{
+		"subscribe-method":    &pubsub.SubscribeMethod,
+		"unsubscribe-method":  &pubsub.UnsubscribeMethod,
+		"notification-method": &pubsub.NotificationMethod,
+	} {
+		value := cmd.Flags().Lookup(flagName).Value.String()
+		if cmd.Flags().Changed(flagName) || *method == "" {
+			*method = strings.TrimSpace(value)
+		}
+		if *method == "" {
+			return types.PubSub{}, fmt.Errorf("--%s must not be empty", flagName)
+		}
+	}
+
+	params, err := client.ParsePubSubParams(subParams)
+	if err != nil {
+		return types.PubSub{}, err
+	}
+	if pubsub.SubscribeParams == nil {
+		pubsub.SubscribeParams = make(map[string]json.RawMessage, len(params))
+	}
+	for subType, template := range params {
+		pubsub.SubscribeParams[subType] = template
+	}
+
+	if cmd.Flags().Changed("unsubscribe-params") || pubsub.UnsubscribeParams == nil {
+		if pubsub.UnsubscribeParams, err = client.ParseUnsubscribeParams(unsubscribeParams); err != nil {
+			return types.PubSub{}, err
+		}
+	}
+	return pubsub, nil
+}
+
+// allFinished is closed once every client has stopped
+func allFinished(clients []*client.WebSocketClient) <-chan struct{} {
+	finished := make(chan struct{})
+	go func() {
+		for _, wsClient := range clients {
+			<-wsClient.Finished()
+		}
+		close(finished)
+	}()
+	return finished
+}
+
+// clientError returns why the clients stopped on their own. Rejected
+// credentials take precedence so they decide the exit status.
+func clientError(clients []*client.WebSocketClient) error {
+	var first error
+	for _, wsClient := range clients {
+		err := wsClient.Err()
+		var authErr *client.AuthError
+		if errors.As(err, &authErr) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// printAuthFailure explains rejected credentials. It is printed after the
+// final summary so the dashboard does not clear it.
+func printAuthFailure(w io.Writer, authErr *client.AuthError, headless bool) {
+	if headless {
+		fmt.Fprintf(w, "Error: %v\n", authErr)
+	} else {
+		terminal.Red.Fprintf(w, "\n🔐 %v\n", authErr)
+	}
+	if authErr.Status != "" && authErr.Body != "" {
+		fmt.Fprintf(w, "Server response: %s\n", authErr.Body)
+	}
+	fmt.Fprintln(w, "Check --api-key and --app-id, or pass --retry-auth-failures to keep retrying.")
 }
 
 // displayStartupInfo shows the initial startup information
@@ -191,21 +733,78 @@ func displayStartupInfo(config *types.Config) {
 	terminal.Green.Printf("🎯 Service: %s\n", config.ServiceID)
 
 	// Parse subscriptions
-	subTypes := strings.Split(config.Subscriptions, ",")
-	totalSubsToCreate := len(subTypes) * config.SubCount
-	terminal.Green.Printf("📡 Subscriptions (%d types × %d instances = %d total):\n", len(subTypes), config.SubCount, totalSubsToCreate)
-	for _, sub := range subTypes {
-		sub = strings.TrimSpace(sub)
-		emoji := terminal.GetSubscriptionEmoji(sub)
-		terminal.Green.Printf("  %s %s (×%d)\n", emoji, sub, config.SubCount)
+	subs, _ := client.ParseSubscriptions(config.Subscriptions)
+	totalSubsToCreate := len(subs) * config.SubCount
+	terminal.Green.Printf("📡 Subscriptions (%d types × %d instances = %d total):\n", len(subs), config.SubCount, totalSubsToCreate)
+	for _, sub := range subs {
+		emoji := terminal.GetSubscriptionEmoji(sub.Type)
+		terminal.Green.Printf("  %s %s (×%d)\n", emoji, sub.Key(), config.SubCount)
+	}
+	if config.Connections > 1 {
+		terminal.Green.Printf("🔌 Connections: %d, each with every subscription\n", config.Connections)
+	}
+	if config.PubSub.SubscribeMethod != "eth_subscribe" {
+		terminal.Green.Printf("🧩 Protocol: %s (%s / %s, notified by %s)\n", config.Protocol,
+			config.PubSub.SubscribeMethod, config.PubSub.UnsubscribeMethod, config.PubSub.NotificationMethod)
 	}
 
 	if config.AuthHeader != "" {
-		authDisplay := config.AuthHeader
-		if len(authDisplay) > 20 {
-			authDisplay = authDisplay[:20]
-		}
-		terminal.Green.Printf("🔐 Auth: %s...\n", authDisplay)
+		terminal.Green.Printf("🔐 Auth: %s...\n", authPreview(config.AuthHeader))
+	}
+	if config.Proxy != nil {
+		terminal.Green.Printf("🌐 Proxy: %s\n", config.Proxy.Redacted())
+	}
+	if config.CallRate > 0 {
+		terminal.Green.Printf("📞 Calls: %s at %g/s per connection, open loop\n", config.CallMethod, config.CallRate)
+	}
+	if config.Compression {
+		terminal.Green.Println("🗜️  Compression: permessage-deflate requested")
+	}
+	if config.TLS != nil && config.TLS.InsecureSkipVerify {
+		terminal.Yellow.Println("⚠️  TLS certificate verification is disabled")
 	}
 	fmt.Println()
 }
+
+// displayPlainStartupInfo shows the startup information without colors or emojis
+func displayPlainStartupInfo(config *types.Config) {
+	fmt.Println("Starting WebSocket Load Test")
+	fmt.Printf("Target: %s\n", config.URL)
+	fmt.Printf("Service: %s\n", config.ServiceID)
+
+	subs, _ := client.ParseSubscriptions(config.Subscriptions)
+	for _, sub := range subs {
+		fmt.Printf("Subscription: %s (x%d)\n", sub.Key(), config.SubCount)
+	}
+	if config.Connections > 1 {
+		fmt.Printf("Connections: %d\n", config.Connections)
+	}
+	if config.PubSub.SubscribeMethod != "eth_subscribe" {
+		fmt.Printf("Protocol: %s (%s / %s, notified by %s)\n", config.Protocol,
+			config.PubSub.SubscribeMethod, config.PubSub.UnsubscribeMethod, config.PubSub.NotificationMethod)
+	}
+
+	if config.AuthHeader != "" {
+		fmt.Printf("Auth: %s...\n", authPreview(config.AuthHeader))
+	}
+	if config.Proxy != nil {
+		fmt.Printf("Proxy: %s\n", config.Proxy.Redacted())
+	}
+	if config.CallRate > 0 {
+		fmt.Printf("Calls: %s at %g/s per connection, open loop\n", config.CallMethod, config.CallRate)
+	}
+	if config.Compression {
+		fmt.Println("Compression: permessage-deflate requested")
+	}
+	if config.TLS != nil && config.TLS.InsecureSkipVerify {
+		fmt.Println("Warning: TLS certificate verification is disabled")
+	}
+}
+
+// authPreview returns the first characters of the auth header for display
+func authPreview(authHeader string) string {
+	if len(authHeader) > 20 {
+		return authHeader[:20]
+	}
+	return authHeader
+}
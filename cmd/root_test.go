@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/client"
 )
 
 func TestURL_Construction(t *testing.T) {
@@ -85,12 +89,138 @@ func TestRootCommand_Flags(t *testing.T) {
 			expectedType: "int",
 			required:     false,
 		},
+		{
+			name:         "connections flag",
+			flagName:     "connections",
+			expectedType: "int",
+			required:     false,
+		},
 		{
 			name:         "log flag",
 			flagName:     "log",
 			expectedType: "bool",
 			required:     false,
 		},
+		{
+			name:         "backoff flag",
+			flagName:     "backoff",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "backoff-base flag",
+			flagName:     "backoff-base",
+			expectedType: "duration",
+			required:     false,
+		},
+		{
+			name:         "backoff-max flag",
+			flagName:     "backoff-max",
+			expectedType: "duration",
+			required:     false,
+		},
+		{
+			name:         "max-retries flag",
+			flagName:     "max-retries",
+			expectedType: "int",
+			required:     false,
+		},
+		{
+			name:         "ping-interval flag",
+			flagName:     "ping-interval",
+			expectedType: "duration",
+			required:     false,
+		},
+		{
+			name:         "pong-timeout flag",
+			flagName:     "pong-timeout",
+			expectedType: "duration",
+			required:     false,
+		},
+		{
+			name:         "stall-threshold flag",
+			flagName:     "stall-threshold",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "stall-reconnect flag",
+			flagName:     "stall-reconnect",
+			expectedType: "bool",
+			required:     false,
+		},
+		{
+			name:         "retry-auth-failures flag",
+			flagName:     "retry-auth-failures",
+			expectedType: "bool",
+			required:     false,
+		},
+		{
+			name:         "output flag",
+			flagName:     "output",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "no-tty flag",
+			flagName:     "no-tty",
+			expectedType: "bool",
+			required:     false,
+		},
+		{
+			name:         "status-interval flag",
+			flagName:     "status-interval",
+			expectedType: "duration",
+			required:     false,
+		},
+		{
+			name:         "protocol flag",
+			flagName:     "protocol",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "subscribe-method flag",
+			flagName:     "subscribe-method",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "notification-method flag",
+			flagName:     "notification-method",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "sub-params flag",
+			flagName:     "sub-params",
+			expectedType: "stringArray",
+			required:     false,
+		},
+		{
+			name:         "call-rate flag",
+			flagName:     "call-rate",
+			expectedType: "float64",
+			required:     false,
+		},
+		{
+			name:         "call-method flag",
+			flagName:     "call-method",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "call-params flag",
+			flagName:     "call-params",
+			expectedType: "string",
+			required:     false,
+		},
+		{
+			name:         "sort-connections flag",
+			flagName:     "sort-connections",
+			expectedType: "string",
+			required:     false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -136,6 +266,111 @@ func TestRootCommand_Defaults(t *testing.T) {
 			flagName:        "count",
 			expectedDefault: "1",
 		},
+		{
+			name:            "connections default",
+			flagName:        "connections",
+			expectedDefault: "1",
+		},
+		{
+			name:            "backoff default",
+			flagName:        "backoff",
+			expectedDefault: "exponential",
+		},
+		{
+			name:            "backoff-base default",
+			flagName:        "backoff-base",
+			expectedDefault: "2s",
+		},
+		{
+			name:            "backoff-max default",
+			flagName:        "backoff-max",
+			expectedDefault: "30s",
+		},
+		{
+			name:            "max-retries default",
+			flagName:        "max-retries",
+			expectedDefault: "0",
+		},
+		{
+			name:            "ping-interval default",
+			flagName:        "ping-interval",
+			expectedDefault: "15s",
+		},
+		{
+			name:            "pong-timeout default",
+			flagName:        "pong-timeout",
+			expectedDefault: "10s",
+		},
+		{
+			name:            "stall-threshold default",
+			flagName:        "stall-threshold",
+			expectedDefault: "",
+		},
+		{
+			name:            "stall-reconnect default",
+			flagName:        "stall-reconnect",
+			expectedDefault: "false",
+		},
+		{
+			name:            "retry-auth-failures default",
+			flagName:        "retry-auth-failures",
+			expectedDefault: "false",
+		},
+		{
+			name:            "output default",
+			flagName:        "output",
+			expectedDefault: "dashboard",
+		},
+		{
+			name:            "no-tty default",
+			flagName:        "no-tty",
+			expectedDefault: "false",
+		},
+		{
+			name:            "status-interval default",
+			flagName:        "status-interval",
+			expectedDefault: "10s",
+		},
+		{
+			name:            "protocol default",
+			flagName:        "protocol",
+			expectedDefault: "",
+		},
+		{
+			name:            "subscribe-method default",
+			flagName:        "subscribe-method",
+			expectedDefault: "eth_subscribe",
+		},
+		{
+			name:            "unsubscribe-method default",
+			flagName:        "unsubscribe-method",
+			expectedDefault: "eth_unsubscribe",
+		},
+		{
+			name:            "notification-method default",
+			flagName:        "notification-method",
+			expectedDefault: "eth_subscription",
+		},
+		{
+			name:            "unsubscribe-params default",
+			flagName:        "unsubscribe-params",
+			expectedDefault: `["{id}"]`,
+		},
+		{
+			name:            "call-rate default",
+			flagName:        "call-rate",
+			expectedDefault: "0",
+		},
+		{
+			name:            "call-method default",
+			flagName:        "call-method",
+			expectedDefault: "eth_blockNumber",
+		},
+		{
+			name:            "sort-connections default",
+			flagName:        "sort-connections",
+			expectedDefault: "index",
+		},
 	}
 
 	for _, tt := range tests {
@@ -183,6 +418,11 @@ func TestRootCommand_ShortFlags(t *testing.T) {
 			flagName:  "log",
 			shorthand: "l",
 		},
+		{
+			name:      "output short flag",
+			flagName:  "output",
+			shorthand: "o",
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,53 +474,113 @@ func TestRootCommand_Usage(t *testing.T) {
 	}
 }
 
-func TestValidateService(t *testing.T) {
+func TestFlagHelp_ListsServicesAndProtocols(t *testing.T) {
+	service := rootCmd.Flags().Lookup("service").Usage
+	for name := range serviceProtocols {
+		if !strings.Contains(service, name) {
+			t.Errorf("--service help %q does not list %s", service, name)
+		}
+	}
+	if !strings.Contains(service, "solana, xrplevm, or any other") {
+		t.Errorf("--service help = %q, want the services listed in order", service)
+	}
+
+	protocol := rootCmd.Flags().Lookup("protocol").Usage
+	for _, name := range client.Protocols {
+		if !strings.Contains(protocol, string(name)) {
+			t.Errorf("--protocol help %q does not list %s", protocol, name)
+		}
+	}
+}
+
+func TestResolveProtocol(t *testing.T) {
+	tests := []struct {
+		name        string
+		service     string
+		protocol    string
+		protocolSet bool
+		want        client.Protocol
+		wantErr     bool
+	}{
+		{name: "xrplevm service", service: "xrplevm", want: client.ProtocolEthereum},
+		{name: "solana service", service: "solana", want: client.ProtocolSolana},
+		{name: "ethereum service (not supported)", service: "ethereum", wantErr: true},
+		{name: "polygon service (not supported)", service: "polygon", wantErr: true},
+		{name: "invalid service", service: "invalid", wantErr: true},
+		{name: "empty service", service: "", wantErr: true},
+		{name: "case sensitive - XRPLEVM", service: "XRPLEVM", wantErr: true},
+		{name: "other service with protocol", service: "osmosis", protocol: "cometbft", protocolSet: true, want: client.ProtocolCometBFT},
+		{name: "substrate protocol", service: "polkadot", protocol: "Substrate", protocolSet: true, want: client.ProtocolSubstrate},
+		{name: "graphql protocol", service: "indexer", protocol: "graphql", protocolSet: true, want: client.ProtocolGraphQL},
+		{name: "protocol overrides service", service: "xrplevm", protocol: "solana", protocolSet: true, want: client.ProtocolSolana},
+		{name: "empty service with protocol", service: "", protocol: "cometbft", protocolSet: true, wantErr: true},
+		{name: "unknown protocol", service: "osmosis", protocol: "cosmos", protocolSet: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveProtocol(tt.service, tt.protocol, tt.protocolSet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveProtocol(%q, %q) error = %v, wantErr %v", tt.service, tt.protocol, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveProtocol(%q, %q) = %q, want %q", tt.service, tt.protocol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthPreview(t *testing.T) {
+	tests := []struct {
+		name       string
+		authHeader string
+		want       string
+	}{
+		{name: "short header", authHeader: "key", want: "key"},
+		{name: "long header truncated", authHeader: "abcdefghijklmnopqrstuvwxyz", want: "abcdefghijklmnopqrst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authPreview(tt.authHeader); got != tt.want {
+				t.Errorf("authPreview(%q) = %q, want %q", tt.authHeader, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintAuthFailure(t *testing.T) {
 	tests := []struct {
 		name    string
-		service string
-		valid   bool
+		err     *client.AuthError
+		want    []string
+		notWant string
 	}{
 		{
-			name:    "xrplevm service",
-			service: "xrplevm",
-			valid:   true,
-		},
-		{
-			name:    "ethereum service (not supported)",
-			service: "ethereum",
-			valid:   false,
-		},
-		{
-			name:    "polygon service (not supported)",
-			service: "polygon",
-			valid:   false,
-		},
-		{
-			name:    "invalid service",
-			service: "invalid",
-			valid:   false,
+			name: "rejected upgrade shows the response body",
+			err:  &client.AuthError{Status: "401 Unauthorized", Body: `{"error":"invalid api key"}`},
+			want: []string{"Error: authentication failed: upgrade rejected with HTTP 401 Unauthorized", `Server response: {"error":"invalid api key"}`, "--retry-auth-failures"},
 		},
 		{
-			name:    "empty service",
-			service: "",
-			valid:   false,
-		},
-		{
-			name:    "case sensitive - XRPLEVM",
-			service: "XRPLEVM",
-			valid:   false,
+			name:    "JSON-RPC error is part of the message",
+			err:     &client.AuthError{Body: `{"message":"unauthorized"}`},
+			want:    []string{`JSON-RPC error {"message":"unauthorized"}`},
+			notWant: "Server response:",
 		},
 	}
 
-	validServices := map[string]bool{
-		"xrplevm": true,
-	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			valid := validServices[tt.service]
-			if valid != tt.valid {
-				t.Errorf("Service %q validation = %v, want %v", tt.service, valid, tt.valid)
+			var buf bytes.Buffer
+			printAuthFailure(&buf, tt.err, true)
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q missing %q", out, want)
+				}
+			}
+			if tt.notWant != "" && strings.Contains(out, tt.notWant) {
+				t.Errorf("output %q should not contain %q", out, tt.notWant)
 			}
 		})
 	}
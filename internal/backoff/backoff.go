@@ -0,0 +1,117 @@
+package backoff
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Strategy selects how the delay between reconnection attempts grows
+type Strategy string
+
+const (
+	// Constant waits the base delay before every retry
+	Constant Strategy = "constant"
+	// Linear waits base × attempt, capped at the maximum delay
+	Linear Strategy = "linear"
+	// Exponential waits base × 2^(attempt-1), capped at the maximum delay
+	Exponential Strategy = "exponential"
+	// Decorrelated waits a random delay between base and 3 × the previous delay, capped at the maximum delay
+	Decorrelated Strategy = "decorrelated"
+)
+
+// Strategies lists all supported strategies in display order
+var Strategies = []Strategy{Constant, Linear, Exponential, Decorrelated}
+
+// ParseStrategy converts a command line value into a Strategy
+func ParseStrategy(value string) (Strategy, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, strategy := range Strategies {
+		if value == string(strategy) {
+			return strategy, nil
+		}
+	}
+
+	names := make([]string, len(Strategies))
+	for i, strategy := range Strategies {
+		names[i] = string(strategy)
+	}
+	return "", fmt.Errorf("unknown backoff strategy %q (supported: %s)", value, strings.Join(names, ", "))
+}
+
+// Policy configures a Backoff
+type Policy struct {
+	Strategy   Strategy
+	Base       time.Duration
+	Max        time.Duration
+	MaxRetries int // 0 retries forever
+}
+
+// Backoff computes successive retry delays for a Policy.
+// It is not safe for concurrent use.
+type Backoff struct {
+	policy   Policy
+	attempts int
+	previous time.Duration
+	int64N   func(n int64) int64
+}
+
+// New creates a Backoff for the given policy
+func New(policy Policy) *Backoff {
+	if policy.Base <= 0 {
+		policy.Base = time.Second
+	}
+	if policy.Max < policy.Base {
+		policy.Max = policy.Base
+	}
+	return &Backoff{
+		policy: policy,
+		int64N: rand.Int64N,
+	}
+}
+
+// Next returns the delay before the next retry.
+// It returns false once MaxRetries consecutive retries have been used.
+func (b *Backoff) Next() (time.Duration, bool) {
+	if b.policy.MaxRetries > 0 && b.attempts >= b.policy.MaxRetries {
+		return 0, false
+	}
+	b.attempts++
+
+	var delay time.Duration
+	switch b.policy.Strategy {
+	case Linear:
+		delay = b.policy.Base * time.Duration(b.attempts)
+	case Exponential:
+		delay = b.policy.Base
+		for i := 1; i < b.attempts && delay < b.policy.Max; i++ {
+			delay *= 2
+		}
+	case Decorrelated:
+		upper := b.previous * 3
+		if upper <= b.policy.Base {
+			upper = b.policy.Base * 3
+		}
+		delay = b.policy.Base + time.Duration(b.int64N(int64(upper-b.policy.Base)))
+	default:
+		delay = b.policy.Base
+	}
+
+	if delay > b.policy.Max {
+		delay = b.policy.Max
+	}
+	b.previous = delay
+	return delay, true
+}
+
+// Attempts returns the number of consecutive retries since the last Reset
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
+
+// Reset starts the retry sequence over, typically after a successful connection
+func (b *Backoff) Reset() {
+	b.attempts = 0
+	b.previous = 0
+}
@@ -0,0 +1,157 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Strategy
+		wantErr bool
+	}{
+		{name: "constant", value: "constant", want: Constant},
+		{name: "linear", value: "linear", want: Linear},
+		{name: "exponential", value: "exponential", want: Exponential},
+		{name: "decorrelated", value: "decorrelated", want: Decorrelated},
+		{name: "case and whitespace insensitive", value: " Exponential ", want: Exponential},
+		{name: "unknown strategy", value: "fibonacci", wantErr: true},
+		{name: "empty strategy", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStrategy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStrategy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStrategy(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff_Next(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []time.Duration
+	}{
+		{
+			name:   "constant",
+			policy: Policy{Strategy: Constant, Base: 5 * time.Second, Max: time.Minute},
+			want:   []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:   "linear with cap",
+			policy: Policy{Strategy: Linear, Base: time.Second, Max: 3 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:   "exponential with cap",
+			policy: Policy{Strategy: Exponential, Base: time.Second, Max: 10 * time.Second},
+			want: []time.Duration{
+				time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+			},
+		},
+		{
+			name:   "max below base is raised to base",
+			policy: Policy{Strategy: Exponential, Base: 2 * time.Second},
+			want:   []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.policy)
+			for i, want := range tt.want {
+				got, ok := b.Next()
+				if !ok {
+					t.Fatalf("Next() #%d gave up unexpectedly", i+1)
+				}
+				if got != want {
+					t.Errorf("Next() #%d = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoff_Decorrelated(t *testing.T) {
+	policy := Policy{Strategy: Decorrelated, Base: time.Second, Max: 20 * time.Second}
+
+	t.Run("bounded by base, previous delay and cap", func(t *testing.T) {
+		b := New(policy)
+		previous := policy.Base
+		for i := 0; i < 1000; i++ {
+			got, _ := b.Next()
+			upper := previous * 3
+			if upper > policy.Max {
+				upper = policy.Max
+			}
+			if got < policy.Base || got > upper {
+				t.Fatalf("Next() #%d = %v, want between %v and %v", i+1, got, policy.Base, upper)
+			}
+			previous = got
+		}
+	})
+
+	t.Run("deterministic with injected randomness", func(t *testing.T) {
+		b := New(policy)
+		b.int64N = func(n int64) int64 { return n / 2 }
+
+		want := []time.Duration{
+			2 * time.Second, 3500 * time.Millisecond, 5750 * time.Millisecond, 9125 * time.Millisecond,
+		}
+		for i, w := range want {
+			if got, _ := b.Next(); got != w {
+				t.Errorf("Next() #%d = %v, want %v", i+1, got, w)
+			}
+		}
+	})
+}
+
+func TestBackoff_MaxRetries(t *testing.T) {
+	b := New(Policy{Strategy: Constant, Base: time.Millisecond, MaxRetries: 3})
+
+	for i := 0; i < 3; i++ {
+		if _, ok := b.Next(); !ok {
+			t.Fatalf("Next() #%d gave up before MaxRetries", i+1)
+		}
+	}
+	if _, ok := b.Next(); ok {
+		t.Error("Next() should give up after MaxRetries")
+	}
+	if b.Attempts() != 3 {
+		t.Errorf("Attempts() = %d, want 3", b.Attempts())
+	}
+
+	b.Reset()
+	if b.Attempts() != 0 {
+		t.Errorf("Attempts() after Reset = %d, want 0", b.Attempts())
+	}
+	if _, ok := b.Next(); !ok {
+		t.Error("Next() should retry again after Reset")
+	}
+}
+
+func TestBackoff_UnlimitedRetries(t *testing.T) {
+	b := New(Policy{Strategy: Constant, Base: time.Millisecond})
+	for i := 0; i < 10000; i++ {
+		if _, ok := b.Next(); !ok {
+			t.Fatalf("Next() #%d gave up with unlimited retries", i+1)
+		}
+	}
+}
+
+func BenchmarkBackoff_Next(b *testing.B) {
+	backoff := New(Policy{Strategy: Decorrelated, Base: time.Second, Max: time.Minute})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		backoff.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// ErrAuthFailed is wrapped by the error returned from Err when the server
+// rejected the credentials and retrying authentication failures is disabled
+var ErrAuthFailed = errors.New("authentication failed")
+
+// AuthError describes credentials rejected by the server
+type AuthError struct {
+	Status string // HTTP status of the rejected upgrade; empty for JSON-RPC errors
+	Body   string // the response body or JSON-RPC error exactly as sent by the server
+}
+
+// Error returns a one-line description of the rejection
+func (e *AuthError) Error() string {
+	if e.Status != "" {
+		return fmt.Sprintf("%v: upgrade rejected with HTTP %s", ErrAuthFailed, e.Status)
+	}
+	return fmt.Sprintf("%v: server returned JSON-RPC error %s", ErrAuthFailed, e.Body)
+}
+
+// Unwrap makes errors.Is(err, ErrAuthFailed) hold
+func (e *AuthError) Unwrap() error {
+	return ErrAuthFailed
+}
+
+// maxHandshakeBody bounds how much of a rejected upgrade response is kept.
+// gorilla/websocket itself keeps at most 1024 bytes.
+const maxHandshakeBody = 1024
+
+// handshakeBody reads the body of a rejected upgrade response
+func handshakeBody(resp *http.Response) string {
+	if resp == nil || resp.Body == nil {
+		return ""
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHandshakeBody))
+	return strings.TrimSpace(string(body))
+}
+
+// isAuthFailure reports whether a dial failure means the credentials were rejected
+func isAuthFailure(class types.FailureClass) bool {
+	return class == types.FailureHTTP401 || class == types.FailureHTTP403
+}
+
+// authErrorMarkers are lower-case fragments of JSON-RPC error messages that
+// signal rejected credentials
+var authErrorMarkers = []string{
+	"unauthorized",
+	"unauthenticated",
+	"not authorized",
+	"forbidden",
+	"authentication",
+	"api key",
+	"invalid token",
+}
+
+// rpcAuthError returns an AuthError when a JSON-RPC error response rejects
+// the credentials with an HTTP-style 401/403 code. Auth messages are only
+// trusted in the answer to a subscribe request, as other requests may be
+// rejected for reasons of their own, e.g. a method a gateway forbids.
+func rpcAuthError(response types.JSONRPCResponse, subscribe bool) *AuthError {
+	rpcErr, ok := response.Error.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	isAuth := false
+	if code, ok := rpcErr["code"].(float64); ok && (code == http.StatusUnauthorized || code == http.StatusForbidden) {
+		isAuth = true
+	}
+	if message, ok := rpcErr["message"].(string); ok && subscribe {
+		message = strings.ToLower(message)
+		for _, marker := range authErrorMarkers {
+			if strings.Contains(message, marker) {
+				isAuth = true
+				break
+			}
+		}
+	}
+	if !isAuth {
+		return nil
+	}
+
+	body, _ := json.Marshal(response.Error)
+	return &AuthError{Body: string(body)}
+}
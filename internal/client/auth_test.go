@@ -0,0 +1,194 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// rejectingServer answers every upgrade with status and body
+func rejectingServer(t *testing.T, status int, body string) (url string, requests *atomic.Int32) {
+	t.Helper()
+	requests = &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, body, status)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), requests
+}
+
+func TestWebSocketClient_AuthFailureStopsRetrying(t *testing.T) {
+	url, requests := rejectingServer(t, http.StatusUnauthorized, `{"error":"invalid api key"}`)
+
+	// Without a retry limit the client would otherwise retry forever
+	statsManager := stats.NewManager()
+	config := heartbeatConfig(url)
+	config.MaxRetries = 0
+	client := NewWebSocketClient(config, statsManager, make(chan struct{}))
+	client.Start()
+	<-client.Finished()
+
+	var authErr *AuthError
+	if !errors.As(client.Err(), &authErr) || !errors.Is(client.Err(), ErrAuthFailed) {
+		t.Fatalf("Err() = %v, want an AuthError", client.Err())
+	}
+	if authErr.Status != "401 Unauthorized" || authErr.Body != `{"error":"invalid api key"}` {
+		t.Errorf("AuthError = %+v, want the status and body sent by the server", authErr)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server saw %d upgrade requests, want 1", got)
+	}
+
+	snap := statsManager.Snapshot()
+	if snap.Failures[types.FailureHTTP401] != 1 || snap.Stats.TotalReconnections != 0 {
+		t.Errorf("Failures = %v, reconnections = %d, want one 401 and no reconnection", snap.Failures, snap.Stats.TotalReconnections)
+	}
+}
+
+func TestWebSocketClient_RetryAuthFailures(t *testing.T) {
+	url, requests := rejectingServer(t, http.StatusForbidden, "forbidden")
+
+	config := heartbeatConfig(url)
+	config.MaxRetries = 2
+	config.RetryAuthFailures = true
+	snap := runUntilFinished(t, config)
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("server saw %d upgrade requests, want 3", got)
+	}
+	if snap.Failures[types.FailureHTTP403] != 3 {
+		t.Errorf("Failures = %v, want three 403s", snap.Failures)
+	}
+}
+
+func TestWebSocketClient_RPCAuthErrorStopsRetrying(t *testing.T) {
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_, _, _ = conn.ReadMessage()
+	})
+	node.subscribeError = map[string]interface{}{"code": -32000, "message": "Unauthorized: API key disabled"}
+
+	statsManager := stats.NewManager()
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.MaxRetries = 0
+	client := NewWebSocketClient(config, statsManager, make(chan struct{}))
+	client.Start()
+	<-client.Finished()
+
+	var authErr *AuthError
+	if !errors.As(client.Err(), &authErr) || authErr.Status != "" || !strings.Contains(authErr.Body, "API key disabled") {
+		t.Fatalf("Err() = %v, want a JSON-RPC AuthError", client.Err())
+	}
+
+	snap := statsManager.Snapshot()
+	if len(snap.ConnectionHistory) != 1 || snap.ConnectionHistory[0].Class != types.FailureRPCAuth {
+		t.Errorf("ConnectionHistory = %+v, want one connection ended by the auth error", snap.ConnectionHistory)
+	}
+	if snap.Stats.TotalReconnections != 0 {
+		t.Errorf("TotalReconnections = %d, want 0", snap.Stats.TotalReconnections)
+	}
+}
+
+func TestWebSocketClient_HandleMessage_AuthErrorScope(t *testing.T) {
+	forbidden := `"error":{"code":-32000,"message":"method forbidden"}`
+	tests := []struct {
+		name    string
+		msg     string
+		wantErr bool
+	}{
+		{name: "subscribe request", msg: `{"jsonrpc":"2.0","id":1,` + forbidden + `}`, wantErr: true},
+		{name: "workload call", msg: `{"jsonrpc":"2.0","id":"call-1",` + forbidden + `}`},
+		{name: "unsubscribe request", msg: `{"jsonrpc":"2.0","id":9,` + forbidden + `}`},
+		{name: "workload call with an HTTP-style code", msg: `{"jsonrpc":"2.0","id":"call-1","error":{"code":401,"message":"denied"}}`},
+		{name: "no ID with an HTTP-style code", msg: `{"jsonrpc":"2.0","id":null,"error":{"code":401,"message":"denied"}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statsManager := stats.NewManager()
+			done := make(chan struct{})
+			defer close(done)
+
+			client := NewWebSocketClient(heartbeatConfig("wss://xrplevm.rpc.grove.city/v1/app123"), statsManager, done)
+			client.startSession()
+			client.session.pending[1] = types.Subscription{Type: "newHeads"}
+			client.calls = &callWorkload{collector: client.collector, pending: map[string]pendingCall{"call-1": {}}}
+
+			err := client.handleMessage(message{data: []byte(tt.msg)})
+			var authErr *AuthError
+			if errors.As(err, &authErr) != tt.wantErr {
+				t.Errorf("handleMessage() error = %v, want auth error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRPCAuthError(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  types.JSONRPCResponse
+		subscribe bool
+		want      bool
+	}{
+		{
+			name:      "auth message answering a subscribe request",
+			response:  types.JSONRPCResponse{Error: map[string]interface{}{"code": float64(-32000), "message": "Invalid API key"}},
+			subscribe: true,
+			want:      true,
+		},
+		{
+			name:     "auth message answering another request",
+			response: types.JSONRPCResponse{Error: map[string]interface{}{"code": float64(-32000), "message": "method forbidden"}},
+		},
+		{
+			name:     "HTTP-style code",
+			response: types.JSONRPCResponse{Error: map[string]interface{}{"code": float64(403), "message": "denied"}},
+			want:     true,
+		},
+		{
+			name:     "unrelated error",
+			response: types.JSONRPCResponse{Error: map[string]interface{}{"code": float64(-32601), "message": "method not found"}},
+		},
+		{
+			name:     "non-object error",
+			response: types.JSONRPCResponse{Error: "unauthorized"},
+		},
+		{
+			name:     "no error",
+			response: types.JSONRPCResponse{Result: "0x1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rpcAuthError(tt.response, tt.subscribe)
+			if (got != nil) != tt.want {
+				t.Errorf("rpcAuthError() = %v, want auth error %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthError_Error(t *testing.T) {
+	tests := []struct {
+		err  *AuthError
+		want string
+	}{
+		{&AuthError{Status: "401 Unauthorized", Body: "nope"}, "authentication failed: upgrade rejected with HTTP 401 Unauthorized"},
+		{&AuthError{Body: `{"message":"forbidden"}`}, `authentication failed: server returned JSON-RPC error {"message":"forbidden"}`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
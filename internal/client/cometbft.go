@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// CometBFT event types, subscribed to with the query tm.event='<type>'.
+// The /websocket endpoint confirms a subscribe request with an empty
+// result and then answers it again for every event, with the query in the
+// result, so subscriptions are known by their query instead of an ID.
+const (
+	cometBFTNewBlock            = "NewBlock"
+	cometBFTNewBlockHeader      = "NewBlockHeader"
+	cometBFTNewBlockEvents      = "NewBlockEvents"
+	cometBFTTx                  = "Tx"
+	cometBFTValidatorSetUpdates = "ValidatorSetUpdates"
+	cometBFTNewEvidence         = "NewEvidence"
+	cometBFTNewRound            = "NewRound"
+	cometBFTNewRoundStep        = "NewRoundStep"
+	cometBFTVote                = "Vote"
+	cometBFTCompleteProposal    = "CompleteProposal"
+)
+
+// cometBFTEvents lists the event types that need no query of their own
+var cometBFTEvents = []string{
+	cometBFTCompleteProposal,
+	cometBFTNewBlock,
+	cometBFTNewBlockEvents,
+	cometBFTNewBlockHeader,
+	cometBFTNewEvidence,
+	cometBFTNewRound,
+	cometBFTNewRoundStep,
+	cometBFTTx,
+	cometBFTValidatorSetUpdates,
+	cometBFTVote,
+}
+
+// cometBFTEventMethod is the notification method events are given once
+// they are rewritten into notifications
+const cometBFTEventMethod = "event"
+
+// cometBFTPath is the path of the CometBFT WebSocket endpoint
+const cometBFTPath = "/websocket"
+
+// cometBFTPubSub returns the CometBFT event API. Unsubscribing names the
+// query, which the subscription ID of a CometBFT subscription is.
+func cometBFTPubSub() types.PubSub {
+	return types.PubSub{
+		SubscribeMethod:    "subscribe",
+		UnsubscribeMethod:  "unsubscribe",
+		NotificationMethod: cometBFTEventMethod,
+		SubscribeParams:    map[string]json.RawMessage{anyTypeParams: json.RawMessage(`{"query":"tm.event='{type}'"}`)},
+		UnsubscribeParams:  json.RawMessage(`{"query":"{id}"}`),
+	}
+}
+
+// checkCometBFTSubscriptions rejects unknown event types without a query of
+// their own, and types subscribed twice: a connection may subscribe to a
+// query only once, so the second request would fail.
+func checkCometBFTSubscriptions(subs []types.Subscription, pubsub types.PubSub) error {
+	seen := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		if !slices.Contains(cometBFTEvents, sub.Type) && pubsub.SubscribeParams[sub.Type] == nil {
+			return fmt.Errorf("unsupported CometBFT event type %q (want one of %v, or give its query with --sub-params)", sub.Type, cometBFTEvents)
+		}
+		if seen[sub.Type] {
+			return fmt.Errorf("CometBFT event type %q is subscribed twice", sub.Type)
+		}
+		seen[sub.Type] = true
+	}
+	return nil
+}
+
+// cometBFTQuery returns the query of subscribe request params, which the
+// server identifies the subscription by
+func cometBFTQuery(params any) any {
+	if params, ok := params.(map[string]any); ok {
+		if query, ok := params["query"].(string); ok {
+			return query
+		}
+	}
+	return nil
+}
+
+// cometBFTNotification rewrites an event, a response to the subscribe
+// request carrying the query and the event data, into a notification of the
+// subscription named by the query. Other responses are returned unchanged.
+func cometBFTNotification(response types.JSONRPCResponse) types.JSONRPCResponse {
+	result, ok := response.Result.(map[string]any)
+	if !ok || response.Method != "" {
+		return response
+	}
+	query, ok := result["query"].(string)
+	if _, hasData := result["data"]; !ok || !hasData {
+		return response
+	}
+	return types.JSONRPCResponse{
+		JSONRPC: response.JSONRPC,
+		Method:  cometBFTEventMethod,
+		Params:  map[string]any{"subscription": query, "result": result},
+	}
+}
+
+// cometBFTSequenceNumber returns the block height of a block event. Every
+// block produces one event of each of these types, so a jump shows blocks
+// that were not delivered.
+func cometBFTSequenceNumber(sub types.Subscription, data []byte) (uint64, bool) {
+	var event struct {
+		Result struct {
+			Data struct {
+				Value struct {
+					Block struct {
+						Header struct {
+							Height json.RawMessage `json:"height"`
+						} `json:"header"`
+					} `json:"block"`
+					Header struct {
+						Height json.RawMessage `json:"height"`
+					} `json:"header"`
+					Height json.RawMessage `json:"height"`
+				} `json:"value"`
+			} `json:"data"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return 0, false
+	}
+
+	value := event.Result.Data.Value
+	switch sub.Type {
+	case cometBFTNewBlock:
+		return parseHeight(value.Block.Header.Height)
+	case cometBFTNewBlockHeader:
+		return parseHeight(value.Header.Height)
+	case cometBFTNewBlockEvents:
+		return parseHeight(value.Height)
+	}
+	return 0, false
+}
+
+// parseHeight parses a block height, which CometBFT encodes as a string
+// like every 64-bit integer
+func parseHeight(raw json.RawMessage) (uint64, bool) {
+	height, err := strconv.ParseUint(string(bytes.Trim(raw, `"`)), 10, 64)
+	return height, err == nil
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckCometBFTSubscriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		subs    string
+		params  map[string]json.RawMessage
+		wantErr bool
+	}{
+		{name: "block and transaction events", subs: "NewBlock,NewBlockHeader,Tx"},
+		{name: "custom type with a query", subs: "transfers", params: map[string]json.RawMessage{"transfers": json.RawMessage(`{"query":"tm.event='Tx' AND transfer.amount EXISTS"}`)}},
+		{name: "custom type without a query", subs: "transfers", wantErr: true},
+		{name: "Ethereum type", subs: "newHeads", wantErr: true},
+		{name: "type subscribed twice", subs: "NewBlock,NewBlock", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := ParseSubscriptions(tt.subs)
+			if err != nil {
+				t.Fatalf("ParseSubscriptions(%q) error = %v", tt.subs, err)
+			}
+			pubsub := ProtocolCometBFT.PubSub()
+			for subType, template := range tt.params {
+				pubsub.SubscribeParams[subType] = template
+			}
+			if err := ProtocolCometBFT.CheckSubscriptions(subs, pubsub); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSubscriptions(%q) error = %v, wantErr %v", tt.subs, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCometBFTPubSub(t *testing.T) {
+	pubsub := newPubSub(ProtocolCometBFT.PubSub())
+	sub := types.Subscription{Type: "NewBlock"}
+
+	request, err := pubsub.subscribeRequest(sub, 1)
+	if err != nil {
+		t.Fatalf("subscribeRequest() error = %v", err)
+	}
+	if params, _ := json.Marshal(request.Params); request.Method != "subscribe" || string(params) != `{"query":"tm.event='NewBlock'"}` {
+		t.Errorf("subscribeRequest() = %s %s", request.Method, params)
+	}
+
+	// The subscription is known by its query, which unsubscribing names
+	query := ProtocolCometBFT.subscriptionID(request.Params, map[string]any{})
+	if query != "tm.event='NewBlock'" {
+		t.Fatalf("subscriptionID() = %v, want the query", query)
+	}
+	request, err = pubsub.unsubscribeRequest(sub, query, 2)
+	if err != nil {
+		t.Fatalf("unsubscribeRequest() error = %v", err)
+	}
+	if params, _ := json.Marshal(request.Params); request.Method != "unsubscribe" || string(params) != `{"query":"tm.event='NewBlock'"}` {
+		t.Errorf("unsubscribeRequest() = %s %s", request.Method, params)
+	}
+}
+
+func TestCometBFTDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantQuery string
+	}{
+		{
+			name:      "event",
+			data:      `{"jsonrpc":"2.0","id":"1#event","result":{"query":"tm.event='NewBlock'","data":{"type":"tendermint/event/NewBlock","value":{}},"events":{}}}`,
+			wantQuery: "tm.event='NewBlock'",
+		},
+		{
+			name: "subscribe confirmation",
+			data: `{"jsonrpc":"2.0","id":1,"result":{}}`,
+		},
+		{
+			name: "cancelled subscription",
+			data: `{"jsonrpc":"2.0","id":"1#event","error":{"code":-32000,"message":"Server error","data":"subscription was cancelled"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response types.JSONRPCResponse
+			if err := json.Unmarshal([]byte(tt.data), &response); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ProtocolCometBFT.decode([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if tt.wantQuery == "" {
+				if !reflect.DeepEqual(got, response) {
+					t.Errorf("decode() = %+v, want the response unchanged", got)
+				}
+				return
+			}
+			params, _ := got.Params.(map[string]any)
+			if got.Method != cometBFTEventMethod || got.ID != nil || got.Result != nil || params["subscription"] != tt.wantQuery {
+				t.Errorf("decode() = %+v, want an event notification for %s", got, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestCometBFTSequenceNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		sub    string
+		data   string
+		want   uint64
+		wantOK bool
+	}{
+		{
+			name:   "NewBlock",
+			sub:    "NewBlock",
+			data:   `{"result":{"data":{"value":{"block":{"header":{"height":"1205"}}}}}}`,
+			want:   1205,
+			wantOK: true,
+		},
+		{
+			name:   "NewBlockHeader",
+			sub:    "NewBlockHeader",
+			data:   `{"result":{"data":{"value":{"header":{"height":"1205"}}}}}`,
+			want:   1205,
+			wantOK: true,
+		},
+		{
+			name:   "NewBlockEvents with a numeric height",
+			sub:    "NewBlockEvents",
+			data:   `{"result":{"data":{"value":{"height":1205}}}}`,
+			want:   1205,
+			wantOK: true,
+		},
+		{
+			name: "transactions share their block's height",
+			sub:  "Tx",
+			data: `{"result":{"data":{"value":{"TxResult":{"height":"1205"}}}}}`,
+		},
+		{
+			name: "invalid height",
+			sub:  "NewBlock",
+			data: `{"result":{"data":{"value":{"block":{"header":{"height":"-1"}}}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ProtocolCometBFT.sequenceNumber(types.Subscription{Type: tt.sub}, []byte(tt.data))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("sequenceNumber() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_CometBFTBlockGaps(t *testing.T) {
+	node := newFakeNode(t, 2, func(conn *websocket.Conn, connection int, requestIDs []string) {
+		// Blocks 12 and 13 never arrive as NewBlock events
+		events := []struct {
+			requestID string
+			query     string
+			heights   []int
+		}{
+			{requestID: requestIDs[0], query: "tm.event='NewBlock'", heights: []int{10, 11, 14}},
+			{requestID: requestIDs[1], query: "tm.event='NewBlockHeader'", heights: []int{10, 11, 12}},
+		}
+		for _, event := range events {
+			for _, height := range event.heights {
+				header := map[string]interface{}{"height": strconv.Itoa(height)}
+				value := map[string]interface{}{"header": header}
+				if event.query == "tm.event='NewBlock'" {
+					value = map[string]interface{}{"block": map[string]interface{}{"header": header}}
+				}
+				_ = conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"id":      event.requestID + "#event",
+					"result": map[string]interface{}{
+						"query":  event.query,
+						"data":   map[string]interface{}{"type": "tendermint/event/NewBlock", "value": value},
+						"events": map[string]interface{}{"tm.event": []string{"NewBlock"}},
+					},
+				})
+			}
+		}
+	})
+	node.emptyResults = true
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.Subscriptions = "NewBlock,NewBlockHeader"
+	config.Protocol = string(ProtocolCometBFT)
+	config.PubSub = ProtocolCometBFT.PubSub()
+	snap := runUntilFinished(t, config)
+
+	if got := node.Methods(); !reflect.DeepEqual(got, []string{"subscribe", "subscribe"}) {
+		t.Errorf("subscribe methods = %v, want subscribe twice", got)
+	}
+	if snap.Stats.SubscriptionsCreated != 2 || snap.Stats.SubscriptionEvents != 6 {
+		t.Errorf("SubscriptionsCreated = %d, SubscriptionEvents = %d, want 2 and 6",
+			snap.Stats.SubscriptionsCreated, snap.Stats.SubscriptionEvents)
+	}
+	if snap.MessagesByType["NewBlock"] != 3 || snap.MessagesByType["NewBlockHeader"] != 3 {
+		t.Errorf("MessagesByType = %v, want 3 of each type", snap.MessagesByType)
+	}
+	if got, want := snap.Sequences["NewBlock"], (types.SequenceStats{Received: 3, Highest: 14, Gaps: 1, Missed: 2}); got != want {
+		t.Errorf("Sequences[NewBlock] = %+v, want %+v", got, want)
+	}
+	if got, want := snap.Sequences["NewBlockHeader"], (types.SequenceStats{Received: 3, Highest: 12}); got != want {
+		t.Errorf("Sequences[NewBlockHeader] = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// dialFailure classifies a failed dial. resp is the HTTP response to the
+// upgrade request, which gorilla only returns when the handshake was rejected,
+// and body is the start of its body.
+func dialFailure(err error, resp *http.Response, body string) types.Failure {
+	if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+		reason := fmt.Sprintf("upgrade rejected: HTTP %s", resp.Status)
+		if body != "" {
+			reason += ": " + strings.Join(strings.Fields(body), " ")
+		}
+		return types.Failure{Class: httpStatusClass(resp.StatusCode), Reason: reason}
+	}
+
+	var (
+		proxyErr *ProxyError
+		dnsErr   *net.DNSError
+	)
+	switch {
+	case errors.As(err, &proxyErr):
+		return types.Failure{Class: proxyErr.Class, Reason: proxyErr.Error()}
+	case errors.As(err, &dnsErr):
+		return types.Failure{Class: types.FailureDNS, Reason: fmt.Sprintf("dns lookup failed: %v", dnsErr)}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return types.Failure{Class: types.FailureTCPRefused, Reason: fmt.Sprintf("connection refused: %v", err)}
+	case isTLSError(err):
+		return types.Failure{Class: types.FailureTLS, Reason: fmt.Sprintf("tls handshake failed: %v", err)}
+	case isTimeout(err):
+		return types.Failure{Class: types.FailureDialTimeout, Reason: fmt.Sprintf("dial timeout: %v", err)}
+	}
+	return types.Failure{Class: networkClass(err), Reason: fmt.Sprintf("dial failed: %v", err)}
+}
+
+// readFailure classifies a failed read on an open connection
+func (c *WebSocketClient) readFailure(err error) types.Failure {
+	failure := types.Failure{Reason: c.readErrorReason(err)}
+
+	var closeErr *websocket.CloseError
+	switch {
+	case isTimeout(err):
+		failure.Class = types.FailureReadTimeout
+	case errors.As(err, &closeErr):
+		failure.Class = closeCodeClass(closeErr.Code)
+	default:
+		failure.Class = networkClass(err)
+	}
+	return failure
+}
+
+// networkClass classifies a transport error that is not specific to dialing or reading
+func networkClass(err error) types.FailureClass {
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return types.FailureTCPReset
+	case errors.As(err, &opErr):
+		return types.FailureNetwork
+	}
+	return types.FailureOther
+}
+
+// httpStatusClass classifies the status of a rejected upgrade
+func httpStatusClass(status int) types.FailureClass {
+	switch {
+	case status == http.StatusUnauthorized:
+		return types.FailureHTTP401
+	case status == http.StatusForbidden:
+		return types.FailureHTTP403
+	case status == http.StatusTooManyRequests:
+		return types.FailureHTTP429
+	case status >= 500 && status <= 599:
+		return types.FailureHTTP5xx
+	}
+	return types.FailureHTTPOther
+}
+
+// closeCodeClass classifies the close code of a connection closed by the server
+func closeCodeClass(code int) types.FailureClass {
+	switch {
+	case code == websocket.CloseNormalClosure:
+		return types.FailureCloseNormal
+	case code == websocket.CloseGoingAway:
+		return types.FailureCloseGoingAway
+	case code == websocket.CloseAbnormalClosure:
+		return types.FailureCloseAbnormal
+	case code == websocket.CloseInternalServerErr:
+		return types.FailureCloseInternalError
+	case code >= 4000 && code <= 4999:
+		return types.FailureCloseApplication
+	}
+	return types.FailureCloseOther
+}
+
+// isTLSError reports whether err came from the TLS handshake or certificate verification
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &verifyErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return true
+	}
+	// Handshake failures without a dedicated type, e.g. protocol version mismatches
+	return strings.HasPrefix(err.Error(), "tls: ")
+}
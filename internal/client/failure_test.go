@@ -0,0 +1,216 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestDialFailure(t *testing.T) {
+	rejected := func(status int) *http.Response {
+		return &http.Response{StatusCode: status, Status: fmt.Sprintf("%d %s", status, http.StatusText(status))}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		resp       *http.Response
+		body       string
+		want       types.FailureClass
+		wantReason string
+	}{
+		{
+			name:       "dns",
+			err:        &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}},
+			want:       types.FailureDNS,
+			wantReason: "dns lookup failed: lookup nowhere.invalid: no such host",
+		},
+		{
+			name: "connection refused",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: types.FailureTCPRefused,
+		},
+		{
+			// A refused proxy is not a refused target
+			name: "proxy connection refused",
+			err: &ProxyError{Proxy: "10.0.0.5:3128", Class: types.FailureProxyConnect,
+				Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+			want:       types.FailureProxyConnect,
+			wantReason: "proxy 10.0.0.5:3128: dial tcp: connect: connection refused",
+		},
+		{
+			name: "dial timeout",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			want: types.FailureDialTimeout,
+		},
+		{
+			name: "unknown certificate authority",
+			err:  &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}},
+			want: types.FailureTLS,
+		},
+		{
+			name: "not a TLS server",
+			err:  tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"},
+			want: types.FailureTLS,
+		},
+		{
+			name: "handshake failure alert",
+			err:  errors.New("tls: handshake failure"),
+			want: types.FailureTLS,
+		},
+		{
+			name:       "unauthorized",
+			err:        websocket.ErrBadHandshake,
+			resp:       rejected(http.StatusUnauthorized),
+			want:       types.FailureHTTP401,
+			wantReason: "upgrade rejected: HTTP 401 Unauthorized",
+		},
+		{
+			name:       "forbidden with body",
+			err:        websocket.ErrBadHandshake,
+			resp:       rejected(http.StatusForbidden),
+			body:       "{\"error\":\n  \"invalid api key\"}",
+			want:       types.FailureHTTP403,
+			wantReason: "upgrade rejected: HTTP 403 Forbidden: {\"error\": \"invalid api key\"}",
+		},
+		{
+			name: "rate limited",
+			err:  websocket.ErrBadHandshake,
+			resp: rejected(http.StatusTooManyRequests),
+			want: types.FailureHTTP429,
+		},
+		{
+			name: "bad gateway",
+			err:  websocket.ErrBadHandshake,
+			resp: rejected(http.StatusBadGateway),
+			want: types.FailureHTTP5xx,
+		},
+		{
+			name: "not found",
+			err:  websocket.ErrBadHandshake,
+			resp: rejected(http.StatusNotFound),
+			want: types.FailureHTTPOther,
+		},
+		{
+			name: "reset",
+			err:  &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			want: types.FailureTCPReset,
+		},
+		{
+			name: "unclassified",
+			err:  errors.New("malformed ws or wss URL"),
+			want: types.FailureOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dialFailure(tt.err, tt.resp, tt.body)
+			if got.Class != tt.want {
+				t.Errorf("dialFailure() class = %q, want %q", got.Class, tt.want)
+			}
+			if tt.wantReason != "" && got.Reason != tt.wantReason {
+				t.Errorf("dialFailure() reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReadFailure(t *testing.T) {
+	client := &WebSocketClient{config: &types.Config{PongTimeout: time.Second}}
+
+	tests := []struct {
+		name string
+		err  error
+		want types.FailureClass
+	}{
+		{"normal closure", &websocket.CloseError{Code: websocket.CloseNormalClosure}, types.FailureCloseNormal},
+		{"going away", &websocket.CloseError{Code: websocket.CloseGoingAway}, types.FailureCloseGoingAway},
+		{"abnormal closure", &websocket.CloseError{Code: websocket.CloseAbnormalClosure}, types.FailureCloseAbnormal},
+		{"internal error", &websocket.CloseError{Code: websocket.CloseInternalServerErr}, types.FailureCloseInternalError},
+		{"application code", &websocket.CloseError{Code: 4008}, types.FailureCloseApplication},
+		{"other close code", &websocket.CloseError{Code: websocket.CloseMessageTooBig}, types.FailureCloseOther},
+		{"read deadline", &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, types.FailureReadTimeout},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, types.FailureTCPReset},
+		{"closed connection", &net.OpError{Op: "read", Err: net.ErrClosed}, types.FailureNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := client.readFailure(tt.err)
+			if got.Class != tt.want {
+				t.Errorf("readFailure() class = %q, want %q", got.Class, tt.want)
+			}
+			if got.Reason != client.readErrorReason(tt.err) {
+				t.Errorf("readFailure() reason = %q, want %q", got.Reason, client.readErrorReason(tt.err))
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_ClassifiesFailures(t *testing.T) {
+	// The first connection is closed with an application close code and
+	// every later upgrade is rejected with 503
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4001, "kicked"))
+		_, _, _ = conn.ReadMessage()
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	snap := runUntilFinished(t, config)
+
+	want := map[types.FailureClass]int{
+		types.FailureCloseApplication: 1,
+		types.FailureHTTP5xx:          1,
+	}
+	if len(snap.Failures) != len(want) || snap.FailureCount() != 2 {
+		t.Errorf("Failures = %v, want %v", snap.Failures, want)
+	}
+	for class, count := range want {
+		if snap.Failures[class] != count {
+			t.Errorf("Failures[%s] = %d, want %d", class, snap.Failures[class], count)
+		}
+	}
+
+	if len(snap.ConnectionHistory) != 1 {
+		t.Fatalf("ConnectionHistory has %d entries, want 1", len(snap.ConnectionHistory))
+	}
+	if conn := snap.ConnectionHistory[0]; conn.Class != types.FailureCloseApplication || conn.Reason != "closed by server (4001 kicked)" {
+		t.Errorf("ConnectionHistory[0] = %+v, want an application close", conn)
+	}
+	if last := snap.LastFailure; last.Class != types.FailureHTTP5xx || !strings.Contains(last.Reason, "503") || last.Time.IsZero() {
+		t.Errorf("LastFailure = %+v, want the rejected upgrade", last)
+	}
+}
+
+func TestWebSocketClient_InvalidMessageIsJSONDecodeFailure(t *testing.T) {
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+		_, _, _ = conn.ReadMessage()
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	snap := runUntilFinished(t, config)
+
+	if snap.Failures[types.FailureJSONDecode] != 1 {
+		t.Errorf("Failures = %v, want one JSON decode failure", snap.Failures)
+	}
+	if reason := snap.ConnectionHistory[0].Reason; !strings.HasPrefix(reason, reasonInvalidMessage) {
+		t.Errorf("Reason = %q, want an invalid message", reason)
+	}
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// graphQLSubprotocol is the WebSocket subprotocol of GraphQL over WebSocket
+const graphQLSubprotocol = "graphql-transport-ws"
+
+// graphql-transport-ws message types
+const (
+	graphQLConnectionInit = "connection_init"
+	graphQLConnectionAck  = "connection_ack"
+	graphQLSubscribe      = "subscribe"
+	graphQLNext           = "next"
+	graphQLError          = "error"
+	graphQLComplete       = "complete"
+	graphQLPing           = "ping"
+	graphQLPong           = "pong"
+)
+
+// graphQLAckTimeout bounds the wait for connection_ack
+const graphQLAckTimeout = 10 * time.Second
+
+// graphQLMessage is a graphql-transport-ws message. Operations are not
+// JSON-RPC requests: their ID is chosen by the client, the server does not
+// confirm them, and every result is a next message carrying that ID.
+type graphQLMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// graphQLPubSub returns the graphql-transport-ws API. Each subscription type
+// is an operation whose query must be given with --sub-params, as there is
+// no query that fits every schema.
+func graphQLPubSub() types.PubSub {
+	return types.PubSub{
+		SubscribeMethod:    graphQLSubscribe,
+		UnsubscribeMethod:  graphQLComplete,
+		NotificationMethod: graphQLNext,
+		SubscribeParams:    map[string]json.RawMessage{},
+		UnsubscribeParams:  json.RawMessage(`{"id":"{id}"}`),
+	}
+}
+
+// checkGraphQLSubscriptions rejects operations without a query of their own
+// or from a "*" template
+func checkGraphQLSubscriptions(subs []types.Subscription, pubsub types.PubSub) error {
+	for _, sub := range subs {
+		template, ok := pubsub.SubscribeParams[sub.Type]
+		if !ok {
+			template, ok = pubsub.SubscribeParams[anyTypeParams]
+		}
+		var payload struct {
+			Query string `json:"query"`
+		}
+		if !ok || json.Unmarshal(template, &payload) != nil || payload.Query == "" {
+			return fmt.Errorf(`GraphQL operation %s needs a query, e.g. --sub-params '%s={"query":"subscription { %s { id } }"}'`, sub.Type, sub.Type, sub.Type)
+		}
+	}
+	return nil
+}
+
+// graphQLEncode turns a subscribe or unsubscribe request into a subscribe
+// or complete message. The request ID of a subscribe request becomes the
+// operation ID, which complete messages take from their params.
+func graphQLEncode(request types.JSONRPCRequest) ([]byte, error) {
+	msg := graphQLMessage{Type: request.Method}
+	if request.Method == graphQLComplete {
+		params, _ := request.Params.(map[string]any)
+		id, ok := types.SubscriptionID(params["id"])
+		if !ok {
+			return nil, fmt.Errorf("complete message without an operation ID")
+		}
+		msg.ID = id
+		return json.Marshal(msg)
+	}
+
+	payload, err := json.Marshal(request.Params)
+	if err != nil {
+		return nil, err
+	}
+	msg.ID = fmt.Sprint(request.ID) // see Protocol.startedID
+	msg.Payload = payload
+	return json.Marshal(msg)
+}
+
+// graphQLDecode turns a next message into a notification of its operation
+// and an error message into an error response, so both are counted like
+// their JSON-RPC counterparts. Other messages decode to empty responses.
+func graphQLDecode(data []byte) (types.JSONRPCResponse, error) {
+	var msg graphQLMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return types.JSONRPCResponse{}, err
+	}
+	if msg.Type == "" {
+		return types.JSONRPCResponse{}, errors.New("graphql-transport-ws message without a type")
+	}
+
+	var payload any
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return types.JSONRPCResponse{}, err
+		}
+	}
+	switch msg.Type {
+	case graphQLNext:
+		return types.JSONRPCResponse{
+			Method: graphQLNext,
+			Params: map[string]any{"subscription": msg.ID, "result": payload},
+		}, nil
+	case graphQLError:
+		return types.JSONRPCResponse{Error: payload}, nil
+	}
+	return types.JSONRPCResponse{}, nil
+}
+
+// initGraphQL sends connection_init and waits for connection_ack, which the
+// server requires before any operation. Pings are answered while waiting.
+func (c *WebSocketClient) initGraphQL(conn *websocket.Conn) error {
+	if err := c.writeGraphQL(conn, graphQLMessage{Type: graphQLConnectionInit}); err != nil {
+		return err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(graphQLAckTimeout)); err != nil {
+		return err
+	}
+	for {
+		msg, err := readMessage(conn)
+		if err != nil {
+			if isTimeout(err) {
+				return fmt.Errorf("no %s within %v: %w", graphQLConnectionAck, graphQLAckTimeout, err)
+			}
+			return err
+		}
+		c.collector.RecordWireMessage(types.RPCMessageType, msg.wireBytes, len(msg.data), msg.readTime)
+
+		var reply graphQLMessage
+		if err := json.Unmarshal(msg.data, &reply); err != nil {
+			return err
+		}
+		switch reply.Type {
+		case graphQLConnectionAck:
+			return conn.SetReadDeadline(time.Time{})
+		case graphQLPing:
+			if err := c.writeGraphQL(conn, graphQLMessage{Type: graphQLPong}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected %q message before %s", reply.Type, graphQLConnectionAck)
+		}
+	}
+}
+
+// writeGraphQL sends a message that belongs to no operation
+func (c *WebSocketClient) writeGraphQL(conn *websocket.Conn, msg graphQLMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		return err
+	}
+	c.collector.RecordSentMessage(types.RPCMessageType, len(payload))
+	return nil
+}
+
+// handleGraphQLMessage records the outcome of the operation a message is
+// about, ending operations the server finished, and answers pings
+func (c *WebSocketClient) handleGraphQLMessage(data []byte) {
+	var msg graphQLMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	if msg.Type == graphQLPing {
+		c.pongDue = true
+		return
+	}
+
+	sub, ok := types.Subscription{}, false
+	if c.session != nil {
+		sub, ok = c.session.active[msg.ID]
+	}
+	if !ok {
+		return
+	}
+	switch msg.Type {
+	case graphQLNext:
+		c.collector.RecordOperation(sub.Key(), types.OperationResult)
+		var result struct {
+			Errors []json.RawMessage `json:"errors"`
+		}
+		if json.Unmarshal(msg.Payload, &result) == nil && len(result.Errors) > 0 {
+			c.collector.RecordOperation(sub.Key(), types.OperationError)
+		}
+	case graphQLError:
+		c.collector.RecordOperation(sub.Key(), types.OperationError)
+		c.endOperation(msg.ID)
+	case graphQLComplete:
+		c.collector.RecordOperation(sub.Key(), types.OperationComplete)
+		c.endOperation(msg.ID)
+	}
+}
+
+// endOperation forgets an operation the server ended, so it is no longer
+// counted as active nor completed on shutdown
+func (c *WebSocketClient) endOperation(id string) {
+	delete(c.session.active, id)
+	delete(c.session.ids, id)
+	c.collector.RetireSubscription(id)
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckGraphQLSubscriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]json.RawMessage
+		wantErr bool
+	}{
+		{name: "no query", wantErr: true},
+		{name: "own query", params: map[string]json.RawMessage{"blocks": json.RawMessage(`{"query":"subscription { blocks { number } }"}`)}},
+		{name: "query for every type", params: map[string]json.RawMessage{"*": json.RawMessage(`{"query":"subscription { {type} { id } }"}`)}},
+		{name: "query of another type", params: map[string]json.RawMessage{"transfers": json.RawMessage(`{"query":"subscription { transfers { id } }"}`)}, wantErr: true},
+		{name: "params without a query", params: map[string]json.RawMessage{"blocks": json.RawMessage(`{"variables":{}}`)}, wantErr: true},
+		{name: "params array", params: map[string]json.RawMessage{"*": json.RawMessage(`["blocks"]`)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubsub := ProtocolGraphQL.PubSub()
+			for subType, template := range tt.params {
+				pubsub.SubscribeParams[subType] = template
+			}
+			err := ProtocolGraphQL.CheckSubscriptions([]types.Subscription{{Type: "blocks"}}, pubsub)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckSubscriptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphQLEncode(t *testing.T) {
+	config := ProtocolGraphQL.PubSub()
+	config.SubscribeParams["blocks"] = json.RawMessage(`{"query":"subscription { blocks { number } }"}`)
+	pubsub := newPubSub(config)
+	sub := types.Subscription{Type: "blocks"}
+
+	request, err := pubsub.subscribeRequest(sub, 3)
+	if err != nil {
+		t.Fatalf("subscribeRequest() error = %v", err)
+	}
+	data, err := ProtocolGraphQL.encode(request)
+	if want := `{"id":"3","type":"subscribe","payload":{"query":"subscription { blocks { number } }"}}`; err != nil || string(data) != want {
+		t.Errorf("encode(subscribe) = %s, %v, want %s", data, err, want)
+	}
+
+	// Operations are completed by their own ID, not the request's
+	id, _ := ProtocolGraphQL.startedID(3)
+	request, err = pubsub.unsubscribeRequest(sub, id, 8)
+	if err != nil {
+		t.Fatalf("unsubscribeRequest() error = %v", err)
+	}
+	data, err = ProtocolGraphQL.encode(request)
+	if want := `{"id":"3","type":"complete"}`; err != nil || string(data) != want {
+		t.Errorf("encode(complete) = %s, %v, want %s", data, err, want)
+	}
+}
+
+func TestGraphQLDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    types.JSONRPCResponse
+		wantErr bool
+	}{
+		{
+			name: "next",
+			data: `{"id":"1","type":"next","payload":{"data":{"blocks":{"number":7}}}}`,
+			want: types.JSONRPCResponse{Method: "next", Params: map[string]any{
+				"subscription": "1",
+				"result":       map[string]any{"data": map[string]any{"blocks": map[string]any{"number": float64(7)}}},
+			}},
+		},
+		{
+			name: "error",
+			data: `{"id":"1","type":"error","payload":[{"message":"unknown field"}]}`,
+			want: types.JSONRPCResponse{Error: []any{map[string]any{"message": "unknown field"}}},
+		},
+		{name: "complete", data: `{"id":"1","type":"complete"}`},
+		{name: "ping", data: `{"type":"ping"}`},
+		{name: "JSON-RPC response", data: `{"jsonrpc":"2.0","id":1,"result":"0x1"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProtocolGraphQL.decode([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeGraphQLServer is a minimal graphql-transport-ws server. It pings the
+// client before acknowledging connection_init, then sends the messages
+// returned by onSubscribed for the operation IDs it received, and closes
+// the connection. Only the first connection is accepted.
+type fakeGraphQLServer struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	connections int
+	subprotocol string
+	ponged      bool
+	subscribes  []graphQLMessage
+}
+
+func newFakeGraphQLServer(t *testing.T, operations int, onSubscribed func(ids []string) []graphQLMessage) *fakeGraphQLServer {
+	t.Helper()
+	s := &fakeGraphQLServer{}
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphQLSubprotocol}}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.connections++
+		first := s.connections == 1
+		s.mu.Unlock()
+		if !first {
+			http.Error(w, "no more connections", http.StatusServiceUnavailable)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msg graphQLMessage
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != graphQLConnectionInit {
+			return
+		}
+		_ = conn.WriteJSON(graphQLMessage{Type: graphQLPing})
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.subprotocol = conn.Subprotocol()
+		s.ponged = msg.Type == graphQLPong
+		s.mu.Unlock()
+		_ = conn.WriteJSON(graphQLMessage{Type: graphQLConnectionAck})
+
+		var ids []string
+		for len(ids) < operations {
+			var subscribe graphQLMessage
+			if err := conn.ReadJSON(&subscribe); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.subscribes = append(s.subscribes, subscribe)
+			s.mu.Unlock()
+			ids = append(ids, subscribe.ID)
+		}
+		for _, reply := range onSubscribed(ids) {
+			_ = conn.WriteJSON(reply)
+		}
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// URL returns the ws:// URL of the fake server
+func (s *fakeGraphQLServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func TestWebSocketClient_GraphQLOperations(t *testing.T) {
+	server := newFakeGraphQLServer(t, 2, func(ids []string) []graphQLMessage {
+		return []graphQLMessage{
+			{ID: ids[0], Type: graphQLNext, Payload: json.RawMessage(`{"data":{"blocks":{"number":1}}}`)},
+			{ID: ids[1], Type: graphQLNext, Payload: json.RawMessage(`{"data":{"transfers":{"amount":5}}}`)},
+			{ID: ids[0], Type: graphQLNext, Payload: json.RawMessage(`{"data":{"blocks":{"number":2}}}`)},
+			{ID: ids[0], Type: graphQLNext, Payload: json.RawMessage(`{"data":null,"errors":[{"message":"timeout"}]}`)},
+			{ID: ids[1], Type: graphQLComplete},
+			// Messages about a completed operation are ignored
+			{ID: ids[1], Type: graphQLNext, Payload: json.RawMessage(`{"data":{"transfers":{"amount":6}}}`)},
+		}
+	})
+
+	config := heartbeatConfig(server.URL())
+	config.PingInterval = 0
+	config.Subscriptions = "blocks,transfers"
+	config.Protocol = string(ProtocolGraphQL)
+	config.PubSub = ProtocolGraphQL.PubSub()
+	config.PubSub.SubscribeParams["blocks"] = json.RawMessage(`{"query":"subscription { blocks { number } }"}`)
+	config.PubSub.SubscribeParams["transfers"] = json.RawMessage(`{"query":"subscription { transfers { amount } }"}`)
+	snap := runUntilFinished(t, config)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.subprotocol != graphQLSubprotocol || !server.ponged {
+		t.Errorf("subprotocol = %q, ponged = %v, want %s and a pong", server.subprotocol, server.ponged, graphQLSubprotocol)
+	}
+	if len(server.subscribes) != 2 || string(server.subscribes[0].Payload) != `{"query":"subscription { blocks { number } }"}` {
+		t.Errorf("subscribe messages = %+v", server.subscribes)
+	}
+
+	if snap.Stats.SubscriptionsCreated != 2 || snap.Stats.ErrorEvents != 0 {
+		t.Errorf("SubscriptionsCreated = %d, ErrorEvents = %d, want 2 and 0", snap.Stats.SubscriptionsCreated, snap.Stats.ErrorEvents)
+	}
+	if snap.MessagesByType["blocks"] != 3 || snap.MessagesByType["transfers"] != 1 {
+		t.Errorf("MessagesByType = %v, want 3 blocks and 1 transfers result", snap.MessagesByType)
+	}
+	want := map[string]types.OperationStats{
+		"blocks":    {Results: 3, Errors: 1},
+		"transfers": {Results: 1, Completed: 1},
+	}
+	if !reflect.DeepEqual(snap.Operations, want) {
+		t.Errorf("Operations = %+v, want %+v", snap.Operations, want)
+	}
+}
+
+func TestWebSocketClient_GraphQLErrorEndsOperation(t *testing.T) {
+	config := &types.Config{
+		URL:           "wss://indexer.example/graphql",
+		Subscriptions: "blocks",
+		SubCount:      1,
+		Protocol:      string(ProtocolGraphQL),
+		PubSub:        ProtocolGraphQL.PubSub(),
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.startSession()
+	client.session.pending[1] = types.Subscription{Type: "blocks"}
+	client.confirmSubscription(1, "1")
+
+	for _, msg := range []string{
+		`{"id":"1","type":"error","payload":[{"message":"unknown field"}]}`,
+		`{"type":"ping"}`,
+	} {
+		if err := client.handleMessage(message{data: []byte(msg)}); err != nil {
+			t.Fatalf("handleMessage(%s) error = %v", msg, err)
+		}
+	}
+
+	snap := statsManager.Snapshot()
+	if snap.Stats.ErrorEvents != 1 || snap.Stats.ActiveSubscriptions != 0 || len(client.session.active) != 0 {
+		t.Errorf("ErrorEvents = %d, ActiveSubscriptions = %d, active = %v, want the operation ended by its error",
+			snap.Stats.ErrorEvents, snap.Stats.ActiveSubscriptions, client.session.active)
+	}
+	if got := snap.Operations["blocks"]; got != (types.OperationStats{Errors: 1}) {
+		t.Errorf("Operations[blocks] = %+v, want one error", got)
+	}
+	if !client.pongDue {
+		t.Error("ping did not schedule a pong")
+	}
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// handshakeTrace records when each phase of a dial starts and ends.
+// DNS and connect hooks may fire from several goroutines when the dialer
+// races IPv4 and IPv6 addresses, so every field is guarded by mu.
+type handshakeTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	gotConn      time.Time // the transport is ready, after any proxy tunnel
+	tlsStart     time.Time
+	tlsDone      time.Time
+	tlsState     *tls.ConnectionState // set once a TLS handshake succeeded
+}
+
+// newHandshakeTrace starts timing a dial
+func newHandshakeTrace() *handshakeTrace {
+	return &handshakeTrace{start: time.Now()}
+}
+
+// context returns ctx instrumented with the trace hooks
+func (h *handshakeTrace) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { h.mark(&h.dnsStart, false) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { h.mark(&h.dnsDone, true) },
+		// The first connect to start and the last to finish bound the phase
+		ConnectStart:      func(string, string) { h.mark(&h.connectStart, false) },
+		ConnectDone:       func(string, string, error) { h.mark(&h.connectDone, true) },
+		GotConn:           func(httptrace.GotConnInfo) { h.mark(&h.gotConn, true) },
+		TLSHandshakeStart: func() { h.mark(&h.tlsStart, false) },
+		TLSHandshakeDone:  h.tlsHandshakeDone,
+	})
+}
+
+// tlsHandshakeDone records the end of the TLS handshake and the negotiated session
+func (h *handshakeTrace) tlsHandshakeDone(state tls.ConnectionState, err error) {
+	h.mark(&h.tlsDone, true)
+	if err == nil {
+		h.mu.Lock()
+		h.tlsState = &state
+		h.mu.Unlock()
+	}
+}
+
+// mark records the current time in field, keeping the earliest time unless latest is set
+func (h *handshakeTrace) mark(field *time.Time, latest bool) {
+	now := time.Now()
+	h.mu.Lock()
+	if field.IsZero() || latest {
+		*field = now
+	}
+	h.mu.Unlock()
+}
+
+// timing computes the phase durations once the dial has returned.
+// responded reports whether the server answered the upgrade request.
+func (h *handshakeTrace) timing(end time.Time, responded, failed bool) types.HandshakeTiming {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	timing := types.HandshakeTiming{
+		Time:    h.start,
+		DNS:     between(h.dnsStart, h.dnsDone),
+		Connect: between(h.connectStart, h.connectDone),
+		TLS:     between(h.tlsStart, h.tlsDone),
+		Total:   end.Sub(h.start),
+		Failed:  failed,
+	}
+
+	// The upgrade request is sent as soon as the transport is ready
+	if responded {
+		upgradeStart := h.connectDone
+		switch {
+		case !h.tlsDone.IsZero():
+			upgradeStart = h.tlsDone
+		case !h.gotConn.IsZero():
+			upgradeStart = h.gotConn
+		}
+		timing.Upgrade = between(upgradeStart, end)
+	}
+	return timing
+}
+
+// between returns the time from start to end, or zero if either is missing
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// upgradeResponse converts the response to an upgrade request for the collector
+func (h *handshakeTrace) upgradeResponse(resp *http.Response, now time.Time) types.UpgradeResponse {
+	headers := make(map[string]string, len(resp.Header))
+	for name, values := range resp.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	response := types.UpgradeResponse{Time: now, Status: resp.StatusCode, Headers: headers}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tlsState != nil {
+		response.TLSVersion = tls.VersionName(h.tlsState.Version)
+		response.TLSCipher = tls.CipherSuiteName(h.tlsState.CipherSuite)
+	}
+	return response
+}
+
+// newDialer returns a dialer configured from config. Proxies are resolved
+// up front, so the dialer never consults the environment itself. Every
+// connection counts its traffic into collector; see wireConn.
+func newDialer(config *types.Config, collector *stats.Collector) *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	dialer.Proxy = nil
+	dialer.EnableCompression = config.Compression
+	dialer.Subprotocols = Protocol(config.Protocol).subprotocols()
+
+	dial := (&net.Dialer{}).DialContext
+	if config.Proxy != nil {
+		dial = (&proxyDialer{proxy: config.Proxy}).DialContext
+	}
+	dialer.NetDialContext = countingDialer(dial, collector)
+	dialer.NetDialTLSContext = countingDialer(tlsDialer(dial, config.TLS), collector)
+	return &dialer
+}
+
+// ParseHeaderNames parses a comma-separated list of header names into their
+// canonical form, dropping duplicates
+func ParseHeaderNames(value string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.ContainsAny(name, " \t:") {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
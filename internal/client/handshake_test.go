@@ -0,0 +1,126 @@
+package client
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocketClient_RecordsHandshakeTiming(t *testing.T) {
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = notify(conn, subIDs[0], map[string]interface{}{"number": "0x1"})
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	snap := runUntilFinished(t, config)
+
+	if len(snap.Handshakes) == 0 {
+		t.Fatal("no handshakes recorded")
+	}
+	// The fake node listens on 127.0.0.1 over plain ws://, so there is no
+	// lookup and no TLS handshake
+	h := snap.Handshakes[0]
+	if h.Failed || h.DNS != 0 || h.TLS != 0 {
+		t.Errorf("handshake = %+v, want a successful dial without DNS or TLS", h)
+	}
+	if h.Connect <= 0 || h.Upgrade <= 0 || h.Total < h.Connect+h.Upgrade {
+		t.Errorf("handshake = %+v, want connect and upgrade phases within the total", h)
+	}
+}
+
+func TestWebSocketClient_RecordsFailedHandshakes(t *testing.T) {
+	url, _ := rejectingServer(t, http.StatusServiceUnavailable, "try later")
+
+	config := heartbeatConfig(url)
+	config.MaxRetries = 1
+	snap := runUntilFinished(t, config)
+
+	if len(snap.Handshakes) != 2 {
+		t.Fatalf("recorded %d handshakes, want one per attempt", len(snap.Handshakes))
+	}
+	for _, h := range snap.Handshakes {
+		// The server answered, so the upgrade phase is still measured
+		if !h.Failed || h.Upgrade <= 0 {
+			t.Errorf("handshake = %+v, want a failed attempt with an upgrade phase", h)
+		}
+	}
+}
+
+func TestHandshakeTrace_Timing(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	trace := &handshakeTrace{
+		start:        start,
+		dnsStart:     at(0),
+		dnsDone:      at(5),
+		connectStart: at(5),
+		connectDone:  at(15),
+		tlsStart:     at(15),
+		tlsDone:      at(45),
+	}
+	got := trace.timing(at(60), true, false)
+	if got.DNS != 5*time.Millisecond || got.Connect != 10*time.Millisecond || got.TLS != 30*time.Millisecond ||
+		got.Upgrade != 15*time.Millisecond || got.Total != 60*time.Millisecond {
+		t.Errorf("timing() = %+v", got)
+	}
+
+	// Without a response there is no upgrade phase to measure
+	if got := trace.timing(at(60), false, true); got.Upgrade != 0 || !got.Failed {
+		t.Errorf("timing() without a response = %+v, want no upgrade phase", got)
+	}
+}
+
+func TestWebSocketClient_RecordsUpgradeResponses(t *testing.T) {
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {})
+	node.maxConnections = 1
+	node.responseHeader = http.Header{"X-Request-Id": {"req-1"}, "X-Ratelimit-Remaining": {"99"}}
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.MaxRetries = 1
+	snap := runUntilFinished(t, config)
+
+	if len(snap.UpgradeResponses) != 2 {
+		t.Fatalf("UpgradeResponses = %+v, want the accepted and the rejected upgrade", snap.UpgradeResponses)
+	}
+	accepted, rejected := snap.UpgradeResponses[0], snap.UpgradeResponses[1]
+	if accepted.ConnectionNum != 1 || accepted.Status != http.StatusSwitchingProtocols || accepted.Headers["X-Request-Id"] != "req-1" {
+		t.Errorf("accepted = %+v, want connection #1 with its request ID", accepted)
+	}
+	if rejected.ConnectionNum != 0 || rejected.Status != http.StatusServiceUnavailable || rejected.Headers["X-Ratelimit-Remaining"] != "99" {
+		t.Errorf("rejected = %+v, want a 503 with its rate-limit header", rejected)
+	}
+}
+
+func TestParseHeaderNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", value: ""},
+		{name: "canonicalised", value: "x-request-id, retry-after", want: []string{"X-Request-Id", "Retry-After"}},
+		{name: "duplicates dropped", value: "Server,server,,SERVER", want: []string{"Server"}},
+		{name: "colon", value: "X-Request-Id:", wantErr: true},
+		{name: "space", value: "X Request", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHeaderNames(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHeaderNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseHeaderNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
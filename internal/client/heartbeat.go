@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/gorilla/websocket"
+)
+
+// pingPayloadSize is the size of the send timestamp carried by every ping
+const pingPayloadSize = 8
+
+// heartbeat sends periodic pings on a connection and measures the pong round
+// trip. Every pong pushes the read deadline forward, so a connection whose
+// pongs stop arriving fails its next read instead of hanging forever.
+type heartbeat struct {
+	conn      *websocket.Conn
+	collector *stats.Collector
+	interval  time.Duration
+	timeout   time.Duration
+	stop      chan struct{}
+	stopped   chan struct{}
+
+	mu      sync.Mutex
+	expired bool // the read deadline was set to fail the pending read
+}
+
+// startHeartbeat starts pinging conn every interval. It returns nil when
+// interval is not positive, which disables heartbeats and read deadlines.
+func startHeartbeat(conn *websocket.Conn, collector *stats.Collector, interval, timeout time.Duration) *heartbeat {
+	if interval <= 0 {
+		return nil
+	}
+
+	h := &heartbeat{
+		conn:      conn,
+		collector: collector,
+		interval:  interval,
+		timeout:   timeout,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(interval + timeout))
+	conn.SetPongHandler(h.handlePong)
+	go h.run()
+	return h
+}
+
+// run sends a ping every interval until stopped
+func (h *heartbeat) run() {
+	defer close(h.stopped)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	payload := make([]byte, pingPayloadSize)
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			binary.BigEndian.PutUint64(payload, uint64(now.UnixNano()))
+
+			// A failed write means the connection is gone; the reader will notice
+			if err := h.conn.WriteControl(websocket.PingMessage, payload, now.Add(h.timeout)); err != nil {
+				return
+			}
+			h.collector.RecordPingSent()
+		}
+	}
+}
+
+// handlePong records the round trip of a pong and extends the read deadline.
+// It runs on the reading goroutine, inside ReadMessage.
+func (h *heartbeat) handlePong(appData string) error {
+	now := time.Now()
+	if len(appData) == pingPayloadSize {
+		sent := time.Unix(0, int64(binary.BigEndian.Uint64([]byte(appData))))
+		h.collector.RecordPong(now.Sub(sent))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.expired {
+		return nil
+	}
+	return h.conn.SetReadDeadline(now.Add(h.interval + h.timeout))
+}
+
+// expireRead fails the pending read on conn at once. Later pongs no longer
+// push the deadline forward, so the reader cannot be kept waiting.
+func (h *heartbeat) expireRead(conn *websocket.Conn) {
+	if h == nil {
+		_ = conn.SetReadDeadline(time.Now())
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expired = true
+	_ = conn.SetReadDeadline(time.Now())
+}
+
+// Stop stops sending pings and waits for the ping goroutine to exit
+func (h *heartbeat) Stop() {
+	if h == nil {
+		return
+	}
+	close(h.stop)
+	<-h.stopped
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// heartbeatConfig returns a config for a single-connection heartbeat test
+func heartbeatConfig(url string) *types.Config {
+	return &types.Config{
+		URL:             url,
+		ServiceID:       "ethereum",
+		Subscriptions:   "newHeads",
+		SubCount:        1,
+		BackoffStrategy: "constant",
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      time.Millisecond,
+		MaxRetries:      1,
+		PingInterval:    20 * time.Millisecond,
+		PongTimeout:     100 * time.Millisecond,
+	}
+}
+
+// runUntilFinished starts a client and waits for it to give up reconnecting
+func runUntilFinished(t *testing.T, config *types.Config) stats.Snapshot {
+	t.Helper()
+
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.Start()
+
+	select {
+	case <-client.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not finish")
+	}
+	return statsManager.Snapshot()
+}
+
+func TestWebSocketClient_HeartbeatMeasuresRTT(t *testing.T) {
+	// The server keeps reading, which answers pings, then closes the connection
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	node.maxConnections = 1
+
+	snap := runUntilFinished(t, heartbeatConfig(node.URL()))
+
+	if snap.Ping.Sent == 0 || snap.Ping.Received == 0 {
+		t.Fatalf("Ping = %+v, want pings sent and pongs received", snap.Ping)
+	}
+	if snap.Ping.Timeouts != 0 {
+		t.Errorf("Ping.Timeouts = %d, want 0", snap.Ping.Timeouts)
+	}
+	if snap.Ping.MinRTT <= 0 || snap.Ping.MaxRTT < snap.Ping.MinRTT || snap.Ping.AverageRTT() > snap.Ping.MaxRTT {
+		t.Errorf("inconsistent RTTs: %+v", snap.Ping)
+	}
+	if len(snap.PingRTTs) != snap.Ping.Received {
+		t.Errorf("PingRTTs has %d samples, want %d", len(snap.PingRTTs), snap.Ping.Received)
+	}
+	for _, sample := range snap.PingRTTs {
+		if sample.ConnectionNum != 1 {
+			t.Errorf("sample recorded for connection %d, want 1", sample.ConnectionNum)
+		}
+	}
+	if len(snap.ConnectionHistory) != 1 || strings.Contains(snap.ConnectionHistory[0].Reason, reasonPongTimeout) {
+		t.Errorf("ConnectionHistory = %+v, want one connection not ended by a pong timeout", snap.ConnectionHistory)
+	}
+}
+
+func TestWebSocketClient_MissedPongDisconnects(t *testing.T) {
+	// The server stops reading after subscribing, so pings are never answered
+	release := make(chan struct{})
+	defer close(release)
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		<-release
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PongTimeout = 50 * time.Millisecond
+	snap := runUntilFinished(t, config)
+
+	if snap.Ping.Timeouts != 1 {
+		t.Errorf("Ping.Timeouts = %d, want 1", snap.Ping.Timeouts)
+	}
+	if snap.Ping.Received != 0 {
+		t.Errorf("Ping.Received = %d, want 0", snap.Ping.Received)
+	}
+	if len(snap.ConnectionHistory) != 1 {
+		t.Fatalf("ConnectionHistory has %d entries, want 1", len(snap.ConnectionHistory))
+	}
+	if reason := snap.ConnectionHistory[0].Reason; !strings.HasPrefix(reason, reasonPongTimeout) {
+		t.Errorf("Reason = %q, want a pong timeout", reason)
+	}
+}
+
+func TestStartHeartbeat_Disabled(t *testing.T) {
+	heartbeat := startHeartbeat(nil, nil, 0, time.Second)
+	if heartbeat != nil {
+		t.Fatal("startHeartbeat() with a zero interval should return nil")
+	}
+
+	// Stopping a disabled heartbeat is a no-op
+	heartbeat.Stop()
+}
+
+func TestWebSocketClient_ReadErrorReason(t *testing.T) {
+	client := &WebSocketClient{config: &types.Config{PongTimeout: 5 * time.Second}}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "close frame",
+			err:  &websocket.CloseError{Code: websocket.CloseGoingAway, Text: "restarting"},
+			want: "closed by server (1001 restarting)",
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection reset by peer"),
+			want: "read error: connection reset by peer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := client.readErrorReason(tt.err); got != tt.want {
+				t.Errorf("readErrorReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Protocol is a subscription API the client speaks
+type Protocol string
+
+// Supported protocols
+const (
+	ProtocolEthereum  Protocol = "ethereum"  // eth_subscribe, or any API configured through types.PubSub
+	ProtocolSolana    Protocol = "solana"    // Solana PubSub
+	ProtocolCometBFT  Protocol = "cometbft"  // CometBFT (Tendermint) events of Cosmos chains
+	ProtocolSubstrate Protocol = "substrate" // Substrate chains such as Polkadot
+	ProtocolGraphQL   Protocol = "graphql"   // GraphQL over WebSocket (graphql-transport-ws)
+)
+
+// Protocols lists every supported protocol
+var Protocols = []Protocol{ProtocolEthereum, ProtocolSolana, ProtocolCometBFT, ProtocolSubstrate, ProtocolGraphQL}
+
+// ParseProtocol validates a protocol name. An empty name is Ethereum.
+func ParseProtocol(value string) (Protocol, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ProtocolEthereum, nil
+	}
+	for _, protocol := range Protocols {
+		if Protocol(value) == protocol {
+			return protocol, nil
+		}
+	}
+	return "", fmt.Errorf("unknown protocol %q (want one of %s)", value, joinProtocols())
+}
+
+// joinProtocols lists the supported protocols for messages
+func joinProtocols() string {
+	names := make([]string, len(Protocols))
+	for i, protocol := range Protocols {
+		names[i] = string(protocol)
+	}
+	return strings.Join(names, ", ")
+}
+
+// PubSub returns the methods and params of the protocol's subscription API
+func (p Protocol) PubSub() types.PubSub {
+	switch p {
+	case ProtocolSolana:
+		return solanaPubSub()
+	case ProtocolCometBFT:
+		return cometBFTPubSub()
+	case ProtocolSubstrate:
+		return substratePubSub()
+	case ProtocolGraphQL:
+		return graphQLPubSub()
+	}
+	return types.PubSub{}
+}
+
+// Path returns the path of the protocol's WebSocket endpoint below the
+// service URL, if it has one
+func (p Protocol) Path() string {
+	switch p {
+	case ProtocolCometBFT:
+		return cometBFTPath
+	}
+	return ""
+}
+
+// DefaultSubscriptions returns the --subs value used when none is given.
+// It is empty for GraphQL, whose operations depend on the schema.
+func (p Protocol) DefaultSubscriptions() string {
+	switch p {
+	case ProtocolGraphQL:
+		return ""
+	case ProtocolSolana:
+		return solanaSlot
+	case ProtocolCometBFT:
+		return cometBFTNewBlock
+	case ProtocolSubstrate:
+		return substrateNewHeads + "," + substrateFinalizedHeads
+	}
+	return "newHeads"
+}
+
+// CheckSubscriptions reports subscription types the protocol does not
+// support, or that need params pubsub does not provide
+func (p Protocol) CheckSubscriptions(subs []types.Subscription, pubsub types.PubSub) error {
+	switch p {
+	case ProtocolSolana:
+		return checkSolanaSubscriptions(subs, pubsub)
+	case ProtocolCometBFT:
+		return checkCometBFTSubscriptions(subs, pubsub)
+	case ProtocolSubstrate:
+		return checkSubstrateSubscriptions(subs)
+	case ProtocolGraphQL:
+		return checkGraphQLSubscriptions(subs, pubsub)
+	}
+	return nil
+}
+
+// sequenceNumber extracts the number of a notification of sub that is
+// expected to increase by one with every notification, such as a slot
+func (p Protocol) sequenceNumber(sub types.Subscription, data []byte) (uint64, bool) {
+	switch p {
+	case ProtocolSolana:
+		return solanaSequenceNumber(sub, data)
+	case ProtocolCometBFT:
+		return cometBFTSequenceNumber(sub, data)
+	case ProtocolSubstrate:
+		return substrateSequenceNumber(sub, data)
+	}
+	return 0, false
+}
+
+// head extracts the block number of a notification of sub announcing a new
+// best or finalized head, and whether the head is finalized
+func (p Protocol) head(sub types.Subscription, data []byte) (number uint64, finalized bool, ok bool) {
+	switch p {
+	case ProtocolSubstrate:
+		return substrateHead(sub, data)
+	}
+	return 0, false, false
+}
+
+// subprotocols returns the WebSocket subprotocols the protocol requests
+func (p Protocol) subprotocols() []string {
+	switch p {
+	case ProtocolGraphQL:
+		return []string{graphQLSubprotocol}
+	}
+	return nil
+}
+
+// encode marshals a subscribe or unsubscribe request
+func (p Protocol) encode(request types.JSONRPCRequest) ([]byte, error) {
+	switch p {
+	case ProtocolGraphQL:
+		return graphQLEncode(request)
+	}
+	return json.Marshal(request)
+}
+
+// decode unmarshals a message into a JSON-RPC response. Events a protocol
+// does not deliver as JSON-RPC notifications are rewritten into them, so
+// they are handled like any other.
+func (p Protocol) decode(data []byte) (types.JSONRPCResponse, error) {
+	if p == ProtocolGraphQL {
+		return graphQLDecode(data)
+	}
+
+	var response types.JSONRPCResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return types.JSONRPCResponse{}, err
+	}
+	switch p {
+	case ProtocolCometBFT:
+		return cometBFTNotification(response), nil
+	}
+	return response, nil
+}
+
+// startedID returns the subscription ID of the subscribe request requestID
+// for protocols that start subscriptions without confirming them
+func (p Protocol) startedID(requestID int) (string, bool) {
+	switch p {
+	case ProtocolGraphQL:
+		return strconv.Itoa(requestID), true
+	}
+	return "", false
+}
+
+// subscriptionID returns the ID the server refers to a subscription by,
+// given its subscribe params and the result confirming it
+func (p Protocol) subscriptionID(params, result any) any {
+	switch p {
+	case ProtocolCometBFT:
+		return cometBFTQuery(params)
+	}
+	return result
+}
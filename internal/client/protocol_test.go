@@ -0,0 +1,46 @@
+package client
+
+import "testing"
+
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Protocol
+		wantErr bool
+	}{
+		{value: "", want: ProtocolEthereum},
+		{value: "ethereum", want: ProtocolEthereum},
+		{value: " Solana ", want: ProtocolSolana},
+		{value: "graphql", want: ProtocolGraphQL},
+		{value: "bitcoin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseProtocol(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParseProtocol(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseProtocol(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestProtocol_DefaultSubscriptions(t *testing.T) {
+	for _, protocol := range Protocols {
+		// GraphQL operations depend on the schema, so there is no default
+		if protocol == ProtocolGraphQL {
+			if got := protocol.DefaultSubscriptions(); got != "" {
+				t.Errorf("%s default subscriptions = %q, want none", protocol, got)
+			}
+			continue
+		}
+		subs, err := ParseSubscriptions(protocol.DefaultSubscriptions())
+		if err != nil {
+			t.Fatalf("%s default subscriptions: %v", protocol, err)
+		}
+		if err := protocol.CheckSubscriptions(subs, protocol.PubSub()); err != nil {
+			t.Errorf("%s default subscriptions are not supported: %v", protocol, err)
+		}
+	}
+}
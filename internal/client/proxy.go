@@ -0,0 +1,327 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// ProxyDirect disables proxies, including those set in the environment
+const ProxyDirect = "direct"
+
+// ResolveProxy returns the proxy to connect to target through. An empty value
+// falls back to the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+// variables; ProxyDirect connects directly. A nil URL means no proxy.
+func ResolveProxy(value, target string) (*url.URL, error) {
+	if value == ProxyDirect {
+		return nil, nil
+	}
+	if value == "" {
+		targetURL, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q: %w", target, err)
+		}
+		// Environment proxies are chosen by the scheme of the upgrade request
+		request := &http.Request{URL: &url.URL{Scheme: "https", Host: targetURL.Host}}
+		if targetURL.Scheme == "ws" || targetURL.Scheme == "http" {
+			request.URL.Scheme = "http"
+		}
+		proxyURL, err := http.ProxyFromEnvironment(request)
+		if err != nil || proxyURL == nil {
+			return nil, err
+		}
+		value = proxyURL.String()
+	}
+
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (valid: http, socks5, socks5h)", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
+
+// ProxyError is a failure to open a tunnel through the proxy, as opposed to a
+// failure of the target itself
+type ProxyError struct {
+	Proxy string             // proxy host and port
+	Class types.FailureClass // proxy_connect, proxy_auth or proxy_tunnel
+	Err   error
+}
+
+// Error describes the proxy failure
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("proxy %s: %v", e.Proxy, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// proxyDialer opens connections through an HTTP CONNECT or SOCKS5 proxy.
+// Credentials in the proxy URL are sent as basic auth or SOCKS5
+// username/password authentication.
+type proxyDialer struct {
+	proxy   *url.URL
+	forward net.Dialer
+}
+
+// proxyAddr returns the host and port of the proxy, adding the scheme's default port
+func (p *proxyDialer) proxyAddr() string {
+	if port := p.proxy.Port(); port != "" {
+		return p.proxy.Host
+	}
+	if p.proxy.Scheme == "http" {
+		return net.JoinHostPort(p.proxy.Hostname(), "80")
+	}
+	return net.JoinHostPort(p.proxy.Hostname(), "1080")
+}
+
+// DialContext connects to addr through the proxy
+func (p *proxyDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	addr, err := p.resolve(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyAddr := p.proxyAddr()
+	conn, err := p.forward.DialContext(ctx, network, proxyAddr)
+	if err != nil {
+		return nil, &ProxyError{Proxy: proxyAddr, Class: types.FailureProxyConnect, Err: err}
+	}
+
+	// The tunnel handshake must not outlive the dial
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if p.proxy.Scheme == "http" {
+		err = p.connectHTTP(conn, addr)
+	} else {
+		err = p.connectSOCKS5(conn, addr)
+	}
+	if err != nil {
+		conn.Close()
+		var proxyErr *ProxyError
+		if errors.As(err, &proxyErr) {
+			proxyErr.Proxy = proxyAddr
+			return nil, proxyErr
+		}
+		return nil, &ProxyError{Proxy: proxyAddr, Class: types.FailureProxyTunnel, Err: err}
+	}
+	return conn, nil
+}
+
+// resolve returns addr with its host name resolved on the client when the
+// scheme is socks5. HTTP and socks5h proxies resolve host names themselves.
+func (p *proxyDialer) resolve(ctx context.Context, addr string) (string, error) {
+	if p.proxy.Scheme != "socks5" {
+		return addr, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return addr, err
+	}
+
+	resolver := p.forward.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ips[0].String(), port), nil
+}
+
+// connectHTTP opens a tunnel with an HTTP CONNECT request
+func (p *proxyDialer) connectHTTP(conn net.Conn, addr string) error {
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := p.proxy.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		return err
+	}
+
+	// The target does not speak before the client, so nothing past the
+	// response can be buffered and lost
+	resp, err := http.ReadResponse(bufio.NewReader(conn), request)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusProxyAuthRequired:
+		return &ProxyError{Class: types.FailureProxyAuth, Err: fmt.Errorf("CONNECT rejected: HTTP %s", resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		return &ProxyError{Class: types.FailureProxyTunnel, Err: fmt.Errorf("CONNECT rejected: HTTP %s", resp.Status)}
+	}
+	return nil
+}
+
+// SOCKS5 protocol constants from RFC 1928 and RFC 1929
+const (
+	socks5Version         = 0x05
+	socks5AuthNone        = 0x00
+	socks5AuthPassword    = 0x02
+	socks5AuthUnavailable = 0xff
+	socks5PasswordVersion = 0x01
+	socks5CmdConnect      = 0x01
+	socks5AddrIPv4        = 0x01
+	socks5AddrDomain      = 0x03
+	socks5AddrIPv6        = 0x04
+)
+
+// socks5Replies describes the SOCKS5 reply codes that signal a failure
+var socks5Replies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// connectSOCKS5 opens a tunnel with a SOCKS5 CONNECT request. Host names
+// still in addr are resolved by the proxy; see resolve.
+func (p *proxyDialer) connectSOCKS5(conn net.Conn, addr string) error {
+	host, portValue, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portValue, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portValue)
+	}
+
+	// Offer username/password authentication only when credentials are set
+	methods := []byte{socks5AuthNone}
+	if p.proxy.User != nil {
+		methods = append(methods, socks5AuthPassword)
+	}
+	if _, err := conn.Write(append([]byte{socks5Version, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	switch {
+	case reply[0] != socks5Version:
+		return fmt.Errorf("unexpected SOCKS version %d", reply[0])
+	case reply[1] == socks5AuthUnavailable:
+		return &ProxyError{Class: types.FailureProxyAuth, Err: errors.New("no acceptable SOCKS5 authentication method")}
+	case reply[1] == socks5AuthPassword:
+		if err := p.authenticateSOCKS5(conn); err != nil {
+			return err
+		}
+	case reply[1] != socks5AuthNone:
+		return fmt.Errorf("unsupported SOCKS5 authentication method %d", reply[1])
+	}
+
+	request := []byte{socks5Version, socks5CmdConnect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("host name %q is too long for SOCKS5", host)
+		}
+		request = append(request, socks5AddrDomain, byte(len(host)))
+		request = append(request, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(request, socks5AddrIPv4)
+		request = append(request, ip4...)
+	} else {
+		request = append(request, socks5AddrIPv6)
+		request = append(request, ip.To16()...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	// The reply ends with the address the proxy bound, which is discarded
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0 {
+		message, ok := socks5Replies[header[1]]
+		if !ok {
+			message = fmt.Sprintf("reply code %d", header[1])
+		}
+		return &ProxyError{Class: types.FailureProxyTunnel, Err: fmt.Errorf("SOCKS5 connect to %s failed: %s", addr, message)}
+	}
+	var boundLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		boundLen = net.IPv4len
+	case socks5AddrIPv6:
+		boundLen = net.IPv6len
+	case socks5AddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		boundLen = int(length[0])
+	default:
+		return fmt.Errorf("unexpected SOCKS5 address type %d", header[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, boundLen+2))
+	return err
+}
+
+// authenticateSOCKS5 sends the username and password from the proxy URL
+func (p *proxyDialer) authenticateSOCKS5(conn net.Conn) error {
+	username := p.proxy.User.Username()
+	password, _ := p.proxy.User.Password()
+	if len(username) > 255 || len(password) > 255 {
+		return errors.New("SOCKS5 username and password must be at most 255 bytes")
+	}
+
+	request := []byte{socks5PasswordVersion, byte(len(username))}
+	request = append(request, username...)
+	request = append(request, byte(len(password)))
+	request = append(request, password...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		return &ProxyError{Class: types.FailureProxyAuth, Err: errors.New("SOCKS5 username/password rejected")}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Default methods of the Ethereum eth_subscribe API
+const (
+	defaultSubscribeMethod    = "eth_subscribe"
+	defaultUnsubscribeMethod  = "eth_unsubscribe"
+	defaultNotificationMethod = "eth_subscription"
+)
+
+// Placeholders replaced in pubsub method names and params templates
+const (
+	typePlaceholder = "{type}"
+	idPlaceholder   = "{id}"
+)
+
+// anyTypeParams keys the params template of every type without its own
+const anyTypeParams = "*"
+
+// pubSub builds the requests of a JSON-RPC subscription API and recognises
+// its notifications
+type pubSub struct {
+	types.PubSub
+}
+
+// newPubSub fills the unset fields of config with the eth_subscribe API
+func newPubSub(config types.PubSub) pubSub {
+	if config.SubscribeMethod == "" {
+		config.SubscribeMethod = defaultSubscribeMethod
+	}
+	if config.UnsubscribeMethod == "" {
+		config.UnsubscribeMethod = defaultUnsubscribeMethod
+	}
+	if config.NotificationMethod == "" {
+		config.NotificationMethod = defaultNotificationMethod
+	}
+	if len(config.UnsubscribeParams) == 0 {
+		config.UnsubscribeParams = json.RawMessage(`["{id}"]`)
+	}
+	return pubSub{PubSub: config}
+}
+
+// subscribeRequest builds the request subscribing to sub
+func (p pubSub) subscribeRequest(sub types.Subscription, requestID int) (types.JSONRPCRequest, error) {
+	request := types.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      requestID,
+		Method:  expandMethod(p.methods(sub).Subscribe, sub),
+	}
+
+	template, ok := p.SubscribeParams[sub.Type]
+	if !ok {
+		template, ok = p.SubscribeParams[anyTypeParams]
+	}
+	if !ok {
+		request.Params = subscriptionParams(sub)
+		return request, nil
+	}
+
+	params, err := expandParams(template, sub, nil)
+	if err != nil {
+		return types.JSONRPCRequest{}, fmt.Errorf("subscribe params for %s: %w", sub.Type, err)
+	}
+	request.Params = params
+	return request, nil
+}
+
+// unsubscribeRequest builds the request cancelling the subscription the
+// server identified as subscriptionID
+func (p pubSub) unsubscribeRequest(sub types.Subscription, subscriptionID any, requestID int) (types.JSONRPCRequest, error) {
+	params, err := expandParams(p.UnsubscribeParams, sub, subscriptionID)
+	if err != nil {
+		return types.JSONRPCRequest{}, fmt.Errorf("unsubscribe params for %s: %w", sub.Type, err)
+	}
+	return types.JSONRPCRequest{
+		JSONRPC: "2.0",
+		ID:      requestID,
+		Method:  expandMethod(p.methods(sub).Unsubscribe, sub),
+		Params:  params,
+	}, nil
+}
+
+// notificationMethods returns the methods of the notifications of subs
+func (p pubSub) notificationMethods(subs []types.Subscription) []string {
+	methods := make([]string, 0, len(subs))
+	for _, sub := range subs {
+		methods = append(methods, expandMethod(p.methods(sub).Notification, sub))
+	}
+	return methods
+}
+
+// isNotification reports whether method is the notification method of sub
+func (p pubSub) isNotification(method string, sub types.Subscription) bool {
+	return method == expandMethod(p.methods(sub).Notification, sub)
+}
+
+// methods returns the methods of sub, its own where it has them
+func (p pubSub) methods(sub types.Subscription) types.PubSubMethods {
+	methods := p.TypeMethods[sub.Type]
+	if methods.Subscribe == "" {
+		methods.Subscribe = p.SubscribeMethod
+	}
+	if methods.Unsubscribe == "" {
+		methods.Unsubscribe = p.UnsubscribeMethod
+	}
+	if methods.Notification == "" {
+		methods.Notification = p.NotificationMethod
+	}
+	return methods
+}
+
+// expandMethod replaces the type placeholder in a method name
+func expandMethod(method string, sub types.Subscription) string {
+	return strings.ReplaceAll(method, typePlaceholder, sub.Type)
+}
+
+// expandParams decodes a params template, replacing the type placeholder in
+// every string and turning "{id}" strings into subscriptionID
+func expandParams(template json.RawMessage, sub types.Subscription, subscriptionID any) (any, error) {
+	var params any
+	if err := json.Unmarshal(template, &params); err != nil {
+		return nil, err
+	}
+	return expandValue(params, sub, subscriptionID), nil
+}
+
+// expandValue implements expandParams for a decoded JSON value
+func expandValue(value any, sub types.Subscription, subscriptionID any) any {
+	switch value := value.(type) {
+	case string:
+		if value == idPlaceholder && subscriptionID != nil {
+			return subscriptionID
+		}
+		return strings.ReplaceAll(value, typePlaceholder, sub.Type)
+	case []any:
+		for i, element := range value {
+			value[i] = expandValue(element, sub, subscriptionID)
+		}
+	case map[string]any:
+		for key, element := range value {
+			value[key] = expandValue(element, sub, subscriptionID)
+		}
+	}
+	return value
+}
+
+// ParsePubSubParams parses the --sub-params values, each a subscription type
+// (or "*" for every type) and a JSON array or object of params, e.g.
+// `logs=["logs",{"address":"0x00"}]`
+func ParsePubSubParams(values []string) (map[string]json.RawMessage, error) {
+	params := make(map[string]json.RawMessage, len(values))
+	for _, value := range values {
+		subType, template, ok := strings.Cut(value, "=")
+		subType = strings.TrimSpace(subType)
+		if !ok || subType == "" {
+			return nil, fmt.Errorf("subscription params %q must be in the form type=JSON", value)
+		}
+		parsed, err := parseParamsTemplate(template)
+		if err != nil {
+			return nil, fmt.Errorf("subscription params for %s: %w", subType, err)
+		}
+		params[subType] = parsed
+	}
+	return params, nil
+}
+
+// ParseUnsubscribeParams parses the --unsubscribe-params template
+func ParseUnsubscribeParams(value string) (json.RawMessage, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	template, err := parseParamsTemplate(value)
+	if err != nil {
+		return nil, fmt.Errorf("unsubscribe params: %w", err)
+	}
+	return template, nil
+}
+
+// parseParamsTemplate checks that a params template is a JSON array or
+// object, as JSON-RPC requires
+func parseParamsTemplate(value string) (json.RawMessage, error) {
+	value = strings.TrimSpace(value)
+	if !json.Valid([]byte(value)) || (value[0] != '[' && value[0] != '{') {
+		return nil, fmt.Errorf("params must be a JSON array or object, got %q", value)
+	}
+	return json.RawMessage(value), nil
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestPubSub_SubscribeRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     types.PubSub
+		sub        types.Subscription
+		wantMethod string
+		wantParams string
+	}{
+		{
+			name:       "eth_subscribe by default",
+			sub:        types.Subscription{Type: "newPendingTransactions", FullTransactions: true},
+			wantMethod: "eth_subscribe",
+			wantParams: `["newPendingTransactions",true]`,
+		},
+		{
+			name: "method and params per type",
+			config: types.PubSub{
+				SubscribeMethod: "{type}Subscribe",
+				SubscribeParams: map[string]json.RawMessage{
+					"*":       json.RawMessage(`[]`),
+					"account": json.RawMessage(`["Vote111", {"encoding": "{type}-base64"}]`),
+				},
+			},
+			sub:        types.Subscription{Type: "account"},
+			wantMethod: "accountSubscribe",
+			wantParams: `["Vote111",{"encoding":"account-base64"}]`,
+		},
+		{
+			name: "methods of the type",
+			config: types.PubSub{
+				SubscribeMethod: "chain_subscribeNewHeads",
+				SubscribeParams: map[string]json.RawMessage{"*": json.RawMessage(`[]`)},
+				TypeMethods:     map[string]types.PubSubMethods{"finalizedHeads": {Subscribe: "chain_subscribeFinalizedHeads"}},
+			},
+			sub:        types.Subscription{Type: "finalizedHeads"},
+			wantMethod: "chain_subscribeFinalizedHeads",
+			wantParams: `[]`,
+		},
+		{
+			name: "template for every type",
+			config: types.PubSub{
+				SubscribeMethod: "gateway_subscribe",
+				SubscribeParams: map[string]json.RawMessage{"*": json.RawMessage(`{"topic": "{type}"}`)},
+			},
+			sub:        types.Subscription{Type: "blocks"},
+			wantMethod: "gateway_subscribe",
+			wantParams: `{"topic":"blocks"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := newPubSub(tt.config).subscribeRequest(tt.sub, 7)
+			if err != nil {
+				t.Fatalf("subscribeRequest() error = %v", err)
+			}
+			params, _ := json.Marshal(request.Params)
+			if request.Method != tt.wantMethod || string(params) != tt.wantParams || request.ID != 7 {
+				t.Errorf("subscribeRequest() = %s %s (id %v), want %s %s", request.Method, params, request.ID, tt.wantMethod, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestPubSub_UnsubscribeRequest(t *testing.T) {
+	pubsub := newPubSub(types.PubSub{UnsubscribeMethod: "{type}Unsubscribe"})
+	sub := types.Subscription{Type: "slot"}
+
+	// Numeric subscription IDs must be sent back as numbers
+	for _, tt := range []struct {
+		id   any
+		want string
+	}{
+		{id: float64(23784), want: `[23784]`},
+		{id: "0xabc", want: `["0xabc"]`},
+	} {
+		request, err := pubsub.unsubscribeRequest(sub, tt.id, 3)
+		if err != nil {
+			t.Fatalf("unsubscribeRequest() error = %v", err)
+		}
+		params, _ := json.Marshal(request.Params)
+		if request.Method != "slotUnsubscribe" || string(params) != tt.want {
+			t.Errorf("unsubscribeRequest(%v) = %s %s, want slotUnsubscribe %s", tt.id, request.Method, params, tt.want)
+		}
+	}
+}
+
+func TestParsePubSubParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]json.RawMessage
+		wantErr bool
+	}{
+		{
+			name:   "types and default",
+			values: []string{` logs = ["logs",{"address":"0x00"}]`, `*=[]`},
+			want: map[string]json.RawMessage{
+				"logs": json.RawMessage(`["logs",{"address":"0x00"}]`),
+				"*":    json.RawMessage(`[]`),
+			},
+		},
+		{name: "missing type", values: []string{`=[]`}, wantErr: true},
+		{name: "missing params", values: []string{`logs`}, wantErr: true},
+		{name: "scalar params", values: []string{`logs="logs"`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePubSubParams(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePubSubParams(%q) error = %v, wantErr %v", tt.values, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePubSubParams(%q) = %s, want %s", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_HandleMessage_CustomPubSub(t *testing.T) {
+	config := &types.Config{
+		URL:           "wss://solana.rpc.grove.city/v1/app123",
+		Subscriptions: "slot",
+		SubCount:      1,
+		PubSub: types.PubSub{
+			SubscribeMethod:    "{type}Subscribe",
+			NotificationMethod: "{type}Notification",
+		},
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.startSession()
+	client.session.pending[1] = types.Subscription{Type: "slot"}
+
+	messages := []string{
+		`{"jsonrpc":"2.0","id":1,"result":1234567}`,
+		`{"jsonrpc":"2.0","method":"slotNotification","params":{"subscription":1234567,"result":{"slot":5}}}`,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":1234567,"result":{"slot":6}}}`,
+	}
+	for _, msg := range messages {
+		if err := client.handleMessage(message{data: []byte(msg)}); err != nil {
+			t.Fatalf("handleMessage(%s) error = %v", msg, err)
+		}
+	}
+
+	snap := statsManager.Snapshot()
+	if snap.Stats.SubscriptionsCreated != 1 || snap.Stats.SubscriptionEvents != 1 {
+		t.Errorf("SubscriptionsCreated = %d, SubscriptionEvents = %d, want 1 and 1",
+			snap.Stats.SubscriptionsCreated, snap.Stats.SubscriptionEvents)
+	}
+	if got := snap.MessagesByType["slot"]; got != 1 {
+		t.Errorf("MessagesByType[slot] = %d, want 1", got)
+	}
+	if got := snap.WireStats["slot"].Payload.Count; got != 1 {
+		t.Errorf("slot payloads = %d, want only the slotNotification", got)
+	}
+}
+
+func TestWebSocketClient_UnsubscribesOnShutdown(t *testing.T) {
+	tests := []struct {
+		name         string
+		pingInterval time.Duration
+	}{
+		{name: "without heartbeats"},
+		// Pongs keep pushing the read deadline far beyond the test timeout
+		{name: "with heartbeats", pingInterval: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsubscribed := make(chan types.JSONRPCRequest, 1)
+			node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+				// The connection stays quiet, so the client must notice the
+				// shutdown without a message to read
+				var request types.JSONRPCRequest
+				if err := conn.ReadJSON(&request); err == nil {
+					unsubscribed <- request
+				}
+			})
+
+			statsManager := stats.NewManager()
+			done := make(chan struct{})
+			config := &types.Config{
+				URL:           node.URL(),
+				Subscriptions: "newHeads",
+				SubCount:      1,
+				PingInterval:  tt.pingInterval,
+				PongTimeout:   time.Minute,
+			}
+			client := NewWebSocketClient(config, statsManager, done)
+			client.Start()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for statsManager.Snapshot().Stats.SubscriptionsCreated == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(done)
+
+			select {
+			case request := <-unsubscribed:
+				params, _ := json.Marshal(request.Params)
+				if request.Method != "eth_unsubscribe" || string(params) != `["0x101"]` {
+					t.Errorf("unsubscribe request = %s %s, want eth_unsubscribe [\"0x101\"]", request.Method, params)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("client did not unsubscribe on shutdown")
+			}
+			select {
+			case <-client.Finished():
+			case <-time.After(5 * time.Second):
+				t.Fatal("client did not finish after unsubscribing")
+			}
+		})
+	}
+}
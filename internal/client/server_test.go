@@ -0,0 +1,166 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeNode is a minimal JSON-RPC pubsub WebSocket server for client tests.
+// It confirms every subscribe request whatever its method.
+type fakeNode struct {
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu          sync.Mutex
+	connections int
+	requestIDs  []int
+	methods     []string
+
+	// onSubscribed is called once all subscriptions of a connection are
+	// confirmed; returning closes the connection
+	onSubscribed func(conn *websocket.Conn, connection int, subIDs []string)
+	// subscriptionsPerConn is the number of subscribe requests to expect per connection
+	subscriptionsPerConn int
+	// maxConnections rejects upgrades beyond this many connections when set
+	maxConnections int
+	// responseHeader is sent with every upgrade response, accepted or rejected
+	responseHeader http.Header
+	// numericIDs confirms subscriptions with numbers, as Solana does
+	numericIDs bool
+	// emptyResults confirms subscriptions with an empty object, as CometBFT
+	// does, and passes the request IDs to onSubscribed instead
+	emptyResults bool
+	// subscribeError answers every subscribe request with this JSON-RPC
+	// error instead of confirming it
+	subscribeError map[string]interface{}
+}
+
+// newFakeNode starts a fake node; it is closed when the test ends
+func newFakeNode(t *testing.T, subscriptionsPerConn int, onSubscribed func(conn *websocket.Conn, connection int, subIDs []string)) *fakeNode {
+	t.Helper()
+	node := &fakeNode{
+		onSubscribed:         onSubscribed,
+		subscriptionsPerConn: subscriptionsPerConn,
+	}
+	node.server = httptest.NewServer(http.HandlerFunc(node.handle))
+	t.Cleanup(node.server.Close)
+	return node
+}
+
+// newFakeTLSNode starts a fake node serving wss:// with tlsConfig applied on
+// top of the httptest certificate
+func newFakeTLSNode(t *testing.T, tlsConfig *tls.Config, onSubscribed func(conn *websocket.Conn, connection int, subIDs []string)) *fakeNode {
+	t.Helper()
+	node := &fakeNode{
+		onSubscribed:         onSubscribed,
+		subscriptionsPerConn: 1,
+	}
+	node.server = httptest.NewUnstartedServer(http.HandlerFunc(node.handle))
+	node.server.TLS = tlsConfig
+	// Rejected handshakes are expected, so keep them out of the test output
+	node.server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	node.server.StartTLS()
+	t.Cleanup(node.server.Close)
+	return node
+}
+
+// URL returns the ws:// or wss:// URL of the fake node
+func (n *fakeNode) URL() string {
+	return "ws" + strings.TrimPrefix(n.server.URL, "http")
+}
+
+// Methods returns the method of every subscribe request received so far
+func (n *fakeNode) Methods() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.methods...)
+}
+
+// RequestIDs returns every subscribe request ID received so far
+func (n *fakeNode) RequestIDs() []int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]int(nil), n.requestIDs...)
+}
+
+func (n *fakeNode) handle(w http.ResponseWriter, r *http.Request) {
+	for name, values := range n.responseHeader {
+		w.Header()[name] = values
+	}
+
+	n.mu.Lock()
+	if n.maxConnections > 0 && n.connections >= n.maxConnections {
+		n.mu.Unlock()
+		http.Error(w, "no more connections", http.StatusServiceUnavailable)
+		return
+	}
+	n.connections++
+	connection := n.connections
+	n.mu.Unlock()
+
+	conn, err := n.upgrader.Upgrade(w, r, n.responseHeader)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var subIDs []string
+	for len(subIDs) < n.subscriptionsPerConn {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		n.mu.Lock()
+		n.requestIDs = append(n.requestIDs, req.ID)
+		n.methods = append(n.methods, req.Method)
+		n.mu.Unlock()
+
+		subID := fmt.Sprintf("0x%d%02d", connection, req.ID)
+		var result interface{} = subID
+		if n.numericIDs {
+			subID = fmt.Sprintf("%d%02d", connection, req.ID)
+			result = json.Number(subID)
+		}
+		if n.emptyResults {
+			subID = fmt.Sprint(req.ID)
+			result = map[string]interface{}{}
+		}
+		subIDs = append(subIDs, subID)
+		if n.subscribeError != nil {
+			_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": n.subscribeError})
+			continue
+		}
+		_ = conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+
+	if n.onSubscribed != nil {
+		n.onSubscribed(conn, connection, subIDs)
+	}
+}
+
+// notify sends an eth_subscription notification for subID
+func notify(conn *websocket.Conn, subID string, result interface{}) error {
+	msg, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_subscription",
+		"params":  map[string]interface{}{"subscription": subID, "result": result},
+	})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msg)
+}
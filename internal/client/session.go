@@ -0,0 +1,61 @@
+package client
+
+import (
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// session holds the subscription state owned by a single connection.
+// A fresh session is created for every connection so that request IDs and
+// server subscription IDs from a previous connection are never reused.
+type session struct {
+	resubscribing bool                          // true for every connection after the first
+	pending       map[int]types.Subscription    // request ID → subscription awaiting confirmation
+	requestedAt   map[int]time.Time             // request ID → when the subscribe request was sent
+	active        map[string]types.Subscription // server subscription ID → confirmed subscription
+	ids           map[string]any                // server subscription ID → the ID as the server sent it
+}
+
+// newSession creates an empty session
+func newSession(resubscribing bool) *session {
+	return &session{
+		resubscribing: resubscribing,
+		pending:       make(map[int]types.Subscription),
+		requestedAt:   make(map[int]time.Time),
+		active:        make(map[string]types.Subscription),
+		ids:           make(map[string]any),
+	}
+}
+
+// request tracks a subscribe request sent at the given time until the server confirms it
+func (s *session) request(requestID int, sub types.Subscription, sentAt time.Time) {
+	s.pending[requestID] = sub
+	s.requestedAt[requestID] = sentAt
+}
+
+// confirm moves a pending subscription to the active set once the server
+// has returned its subscription ID, a string or a number. It also returns
+// when the request was sent, which is zero if that was not tracked.
+func (s *session) confirm(requestID int, result any) (types.Subscription, time.Time, bool) {
+	sub, exists := s.pending[requestID]
+	subscriptionID, ok := types.SubscriptionID(result)
+	if !exists || !ok {
+		return types.Subscription{}, time.Time{}, false
+	}
+	sentAt := s.requestedAt[requestID]
+	delete(s.pending, requestID)
+	delete(s.requestedAt, requestID)
+	s.active[subscriptionID] = sub
+	s.ids[subscriptionID] = result
+	return sub, sentAt, true
+}
+
+// subscriptionIDs returns the server subscription IDs active in this session
+func (s *session) subscriptionIDs() []string {
+	ids := make([]string, 0, len(s.active))
+	for id := range s.active {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Solana PubSub subscription types. Each type has its own subscribe,
+// unsubscribe and notification method, e.g. slotSubscribe,
+// slotUnsubscribe and slotNotification, and subscription IDs are numbers.
+const (
+	solanaSlot      = "slot"
+	solanaAccount   = "account"
+	solanaLogs      = "logs"
+	solanaSignature = "signature"
+	solanaProgram   = "program"
+	solanaRoot      = "root"
+)
+
+// solanaParams are the default params of each Solana subscription type.
+// The clock sysvar changes every slot and the sysvar program owns it, so
+// both stream steadily without naming an account of interest. A signature
+// has no sensible default and must be given with --sub-params.
+var solanaParams = map[string]json.RawMessage{
+	solanaSlot:    json.RawMessage(`[]`),
+	solanaRoot:    json.RawMessage(`[]`),
+	solanaAccount: json.RawMessage(`["SysvarC1ock11111111111111111111111111111111",{"encoding":"base64","commitment":"confirmed"}]`),
+	solanaLogs:    json.RawMessage(`["all",{"commitment":"confirmed"}]`),
+	solanaProgram: json.RawMessage(`["Sysvar1111111111111111111111111111111111111",{"encoding":"base64","commitment":"confirmed"}]`),
+}
+
+// solanaPubSub returns the Solana PubSub API
+func solanaPubSub() types.PubSub {
+	params := make(map[string]json.RawMessage, len(solanaParams))
+	for subType, template := range solanaParams {
+		params[subType] = template
+	}
+	return types.PubSub{
+		SubscribeMethod:    "{type}Subscribe",
+		UnsubscribeMethod:  "{type}Unsubscribe",
+		NotificationMethod: "{type}Notification",
+		SubscribeParams:    params,
+		UnsubscribeParams:  json.RawMessage(`["{id}"]`),
+	}
+}
+
+// checkSolanaSubscriptions rejects types Solana does not stream and types
+// whose params have no default
+func checkSolanaSubscriptions(subs []types.Subscription, pubsub types.PubSub) error {
+	supported := []string{solanaSignature}
+	for subType := range solanaParams {
+		supported = append(supported, subType)
+	}
+	sort.Strings(supported)
+
+	for _, sub := range subs {
+		if !slices.Contains(supported, sub.Type) {
+			return fmt.Errorf("unsupported Solana subscription type %q (want one of %v)", sub.Type, supported)
+		}
+		if sub.Type == solanaSignature && pubsub.SubscribeParams[solanaSignature] == nil {
+			return fmt.Errorf(`signature subscriptions need a signature, e.g. --sub-params 'signature=["<signature>",{"commitment":"confirmed"}]'`)
+		}
+	}
+	return nil
+}
+
+// solanaSequenceNumber returns the slot of a slotNotification. Every slot
+// the node processes is notified, so a jump shows slots that were skipped by
+// their leader or not delivered.
+func solanaSequenceNumber(sub types.Subscription, data []byte) (uint64, bool) {
+	if sub.Type != solanaSlot {
+		return 0, false
+	}
+	var notification struct {
+		Params struct {
+			Result struct {
+				Slot *uint64 `json:"slot"`
+			} `json:"result"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil || notification.Params.Result.Slot == nil {
+		return 0, false
+	}
+	return *notification.Params.Result.Slot, true
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckSolanaSubscriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		subs    string
+		params  map[string]json.RawMessage
+		wantErr bool
+	}{
+		{name: "every type with defaults", subs: "slot,root,account,logs,program"},
+		{name: "signature with params", subs: "signature", params: map[string]json.RawMessage{"signature": json.RawMessage(`["5h6x"]`)}},
+		{name: "signature without params", subs: "signature", wantErr: true},
+		{name: "Ethereum type", subs: "slot,newHeads", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := ParseSubscriptions(tt.subs)
+			if err != nil {
+				t.Fatalf("ParseSubscriptions(%q) error = %v", tt.subs, err)
+			}
+			pubsub := ProtocolSolana.PubSub()
+			for subType, template := range tt.params {
+				pubsub.SubscribeParams[subType] = template
+			}
+			if err := ProtocolSolana.CheckSubscriptions(subs, pubsub); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSubscriptions(%q) error = %v, wantErr %v", tt.subs, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolanaPubSub(t *testing.T) {
+	pubsub := newPubSub(ProtocolSolana.PubSub())
+
+	request, err := pubsub.subscribeRequest(types.Subscription{Type: "slot"}, 1)
+	if err != nil {
+		t.Fatalf("subscribeRequest() error = %v", err)
+	}
+	if request.Method != "slotSubscribe" || !reflect.DeepEqual(request.Params, []any{}) {
+		t.Errorf("subscribeRequest(slot) = %s %v, want slotSubscribe []", request.Method, request.Params)
+	}
+
+	request, err = pubsub.subscribeRequest(types.Subscription{Type: "logs"}, 2)
+	if err != nil {
+		t.Fatalf("subscribeRequest() error = %v", err)
+	}
+	if params, _ := json.Marshal(request.Params); request.Method != "logsSubscribe" || string(params) != `["all",{"commitment":"confirmed"}]` {
+		t.Errorf("subscribeRequest(logs) = %s %s", request.Method, params)
+	}
+
+	if got := pubsub.notificationMethods([]types.Subscription{{Type: "account"}}); !reflect.DeepEqual(got, []string{"accountNotification"}) {
+		t.Errorf("notificationMethods() = %v, want [accountNotification]", got)
+	}
+}
+
+func TestSolanaSequenceNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		sub    string
+		data   string
+		want   uint64
+		wantOK bool
+	}{
+		{
+			name:   "slot",
+			sub:    "slot",
+			data:   `{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":74,"root":42,"slot":75},"subscription":0}}`,
+			want:   75,
+			wantOK: true,
+		},
+		{
+			name: "slot without a number",
+			sub:  "slot",
+			data: `{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{},"subscription":0}}`,
+		},
+		{
+			name: "account updates are not numbered",
+			sub:  "account",
+			data: `{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":75}},"subscription":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ProtocolSolana.sequenceNumber(types.Subscription{Type: tt.sub}, []byte(tt.data))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("sequenceNumber() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_SolanaSlotGaps(t *testing.T) {
+	node := newFakeNode(t, 2, func(conn *websocket.Conn, connection int, subIDs []string) {
+		// Slots 13 and 14 never arrive on the first subscription
+		slots := map[string][]uint64{subIDs[0]: {10, 11, 12, 15, 16}, subIDs[1]: {10, 11, 12, 13}}
+		for _, subID := range subIDs {
+			for _, slot := range slots[subID] {
+				_ = conn.WriteJSON(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "slotNotification",
+					"params": map[string]interface{}{
+						"subscription": json.Number(subID),
+						"result":       map[string]interface{}{"parent": slot - 1, "root": slot - 32, "slot": slot},
+					},
+				})
+			}
+		}
+	})
+	node.numericIDs = true
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.Subscriptions = "slot"
+	config.SubCount = 2
+	config.Protocol = string(ProtocolSolana)
+	config.PubSub = ProtocolSolana.PubSub()
+	snap := runUntilFinished(t, config)
+
+	if got := node.Methods(); !reflect.DeepEqual(got, []string{"slotSubscribe", "slotSubscribe"}) {
+		t.Errorf("subscribe methods = %v, want slotSubscribe twice", got)
+	}
+	if snap.Stats.SubscriptionsCreated != 2 || snap.MessagesByType["slot"] != 9 {
+		t.Errorf("SubscriptionsCreated = %d, MessagesByType = %v, want 2 subscriptions and 9 slot notifications",
+			snap.Stats.SubscriptionsCreated, snap.MessagesByType)
+	}
+	// Both subscriptions see slot 10, which must not count as a repeat
+	want := types.SequenceStats{Received: 9, Highest: 16, Gaps: 1, Missed: 2}
+	if got := snap.Sequences["slot"]; got != want {
+		t.Errorf("Sequences[slot] = %+v, want %+v", got, want)
+	}
+}
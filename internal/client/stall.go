@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/gorilla/websocket"
+)
+
+// maxStallCheckInterval bounds how late a stall is detected
+const maxStallCheckInterval = time.Second
+
+// reasonStalled is recorded when a stalled subscription forces a reconnect
+const reasonStalled = "stalled"
+
+// stallWatchdog periodically checks the subscriptions of a connection for
+// stalls. When reconnect is set, the first stall closes the connection so the
+// client reconnects and subscribes again.
+type stallWatchdog struct {
+	conn       *websocket.Conn
+	collector  *stats.Collector
+	thresholds map[string]time.Duration
+	reconnect  bool
+	stop       chan struct{}
+	stopped    chan struct{}
+
+	mu     sync.Mutex
+	reason string // set when the watchdog closed the connection
+}
+
+// startStallWatchdog starts checking conn for stalls. It returns nil when no
+// thresholds are configured, which disables stall detection.
+func startStallWatchdog(conn *websocket.Conn, collector *stats.Collector, thresholds map[string]time.Duration, reconnect bool) *stallWatchdog {
+	if len(thresholds) == 0 {
+		return nil
+	}
+
+	w := &stallWatchdog{
+		conn:       conn,
+		collector:  collector,
+		thresholds: thresholds,
+		reconnect:  reconnect,
+		stop:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go w.run(stallCheckInterval(thresholds))
+	return w
+}
+
+// stallCheckInterval checks several times per threshold so stalls are
+// flagged close to when they start
+func stallCheckInterval(thresholds map[string]time.Duration) time.Duration {
+	interval := maxStallCheckInterval
+	for _, threshold := range thresholds {
+		interval = min(interval, threshold/4)
+	}
+	return max(interval, time.Millisecond)
+}
+
+// run checks for stalls every interval until stopped
+func (w *stallWatchdog) run(interval time.Duration) {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			stalls := w.collector.CheckStalls(w.thresholds)
+			if len(stalls) == 0 || !w.reconnect {
+				continue
+			}
+
+			stall := stalls[0]
+			w.mu.Lock()
+			w.reason = fmt.Sprintf("%s: %s silent for more than %v", reasonStalled, stall.SubscriptionType, stall.Threshold)
+			w.mu.Unlock()
+
+			// Closing the connection fails the pending read in listenForMessages
+			_ = w.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "subscription stalled"),
+				time.Now().Add(time.Second))
+			_ = w.conn.Close()
+			return
+		}
+	}
+}
+
+// Reason returns why the watchdog closed the connection, or "" if it did not
+func (w *stallWatchdog) Reason() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason
+}
+
+// Stop stops checking for stalls and waits for the watchdog goroutine to exit
+func (w *stallWatchdog) Stop() {
+	if w == nil {
+		return
+	}
+	close(w.stop)
+	<-w.stopped
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// stallConfig returns a single-connection config that expects newHeads every 50ms
+func stallConfig(url string, reconnect bool) *types.Config {
+	config := heartbeatConfig(url)
+	config.PingInterval = 0
+	config.StallThresholds = map[string]time.Duration{"newHeads": 50 * time.Millisecond}
+	config.StallReconnect = reconnect
+	return config
+}
+
+func TestWebSocketClient_StallForcesReconnect(t *testing.T) {
+	// The server goes silent after one notification but keeps the connection open
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = notify(conn, subIDs[0], map[string]interface{}{"number": "0x1"})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+	node.maxConnections = 1
+
+	snap := runUntilFinished(t, stallConfig(node.URL(), true))
+
+	if len(snap.ConnectionHistory) != 1 {
+		t.Fatalf("ConnectionHistory has %d entries, want 1", len(snap.ConnectionHistory))
+	}
+	if reason := snap.ConnectionHistory[0].Reason; !strings.HasPrefix(reason, reasonStalled+": newHeads") {
+		t.Errorf("Reason = %q, want a newHeads stall", reason)
+	}
+	if len(snap.Stalls) != 1 || len(snap.ActiveStalls) != 0 {
+		t.Fatalf("Stalls = %+v, ActiveStalls = %+v, want one resolved stall", snap.Stalls, snap.ActiveStalls)
+	}
+	if stall := snap.Stalls[0]; stall.Resolution != types.StallConnectionClosed || stall.Duration < 50*time.Millisecond {
+		t.Errorf("stall = %+v, want one longer than the threshold ended by the closed connection", stall)
+	}
+}
+
+func TestWebSocketClient_StallResumes(t *testing.T) {
+	// The server pauses for longer than the threshold, resumes, then closes.
+	// The client only starts reading once subscribing is done, so the pause
+	// has to outlast the subscription delay as well.
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = notify(conn, subIDs[0], map[string]interface{}{"number": "0x1"})
+		time.Sleep(300 * time.Millisecond)
+		_ = notify(conn, subIDs[0], map[string]interface{}{"number": "0x2"})
+		time.Sleep(20 * time.Millisecond)
+	})
+	node.maxConnections = 1
+
+	snap := runUntilFinished(t, stallConfig(node.URL(), false))
+
+	if len(snap.Stalls) != 1 {
+		t.Fatalf("Stalls = %+v, want one", snap.Stalls)
+	}
+	if stall := snap.Stalls[0]; stall.Resolution != types.StallResumed || stall.SubscriptionType != "newHeads" {
+		t.Errorf("stall = %+v, want a resumed newHeads stall", stall)
+	}
+	if reason := snap.ConnectionHistory[0].Reason; strings.HasPrefix(reason, reasonStalled) {
+		t.Errorf("Reason = %q, a stall should not close the connection without StallReconnect", reason)
+	}
+}
+
+func TestStallCheckInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds map[string]time.Duration
+		want       time.Duration
+	}{
+		{"long thresholds", map[string]time.Duration{"newHeads": time.Minute}, maxStallCheckInterval},
+		{"shortest threshold wins", map[string]time.Duration{"newHeads": time.Minute, "logs": 2 * time.Second}, 500 * time.Millisecond},
+		{"tiny threshold", map[string]time.Duration{"newHeads": time.Microsecond}, time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stallCheckInterval(tt.thresholds); got != tt.want {
+				t.Errorf("stallCheckInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A disabled watchdog is nil and safe to use
+	watchdog := startStallWatchdog(nil, nil, nil, true)
+	if watchdog != nil || watchdog.Reason() != "" {
+		t.Fatal("startStallWatchdog() without thresholds should return nil")
+	}
+	watchdog.Stop()
+}
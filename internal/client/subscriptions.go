@@ -0,0 +1,91 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// fullTransactionsModifier requests full transaction objects instead of hashes
+const fullTransactionsModifier = "full"
+
+// ParseSubscriptions parses the comma-separated --subs value.
+// Each entry is a subscription type optionally followed by a modifier,
+// e.g. "newHeads,newPendingTransactions:full".
+func ParseSubscriptions(value string) ([]types.Subscription, error) {
+	var subs []types.Subscription
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		subType, modifier, hasModifier := strings.Cut(entry, ":")
+		sub := types.Subscription{Type: strings.TrimSpace(subType)}
+		if hasModifier {
+			modifier = strings.TrimSpace(modifier)
+			if modifier != fullTransactionsModifier || sub.Type != "newPendingTransactions" {
+				return nil, fmt.Errorf("unsupported subscription modifier %q for %s", modifier, sub.Type)
+			}
+			sub.FullTransactions = true
+		}
+		subs = append(subs, sub)
+	}
+
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("no subscription types specified")
+	}
+	return subs, nil
+}
+
+// subscriptionParams builds the eth_subscribe params for a subscription
+func subscriptionParams(sub types.Subscription) interface{} {
+	switch sub.Type {
+	case "newHeads":
+		return []string{"newHeads"}
+	case "newPendingTransactions":
+		if sub.FullTransactions {
+			return []interface{}{"newPendingTransactions", true}
+		}
+		return []string{"newPendingTransactions"}
+	case "logs":
+		return []interface{}{"logs", map[string]interface{}{"topics": []interface{}{nil}}}
+	default:
+		return []string{sub.Type}
+	}
+}
+
+// ParseStallThresholds parses the comma-separated --stall-threshold value,
+// e.g. "newHeads=10s,newPendingTransactions:full=30s". Every key must name
+// one of subs so a typo cannot silently disable stall detection.
+func ParseStallThresholds(value string, subs []types.Subscription) (map[string]time.Duration, error) {
+	subscribed := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		subscribed[sub.Key()] = true
+	}
+
+	thresholds := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, rawThreshold, ok := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("stall threshold %q must be in the form type=duration", entry)
+		}
+		if !subscribed[key] {
+			return nil, fmt.Errorf("stall threshold for %s, which is not subscribed", key)
+		}
+		threshold, err := time.ParseDuration(strings.TrimSpace(rawThreshold))
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("stall threshold for %s must be a positive duration, got %q", key, rawThreshold)
+		}
+		thresholds[key] = threshold
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestParseSubscriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []types.Subscription
+		wantErr bool
+	}{
+		{
+			name:  "single type",
+			value: "newHeads",
+			want:  []types.Subscription{{Type: "newHeads"}},
+		},
+		{
+			name:  "multiple types with whitespace",
+			value: " newHeads , logs ,",
+			want:  []types.Subscription{{Type: "newHeads"}, {Type: "logs"}},
+		},
+		{
+			name:  "full transactions modifier",
+			value: "newPendingTransactions:full",
+			want:  []types.Subscription{{Type: "newPendingTransactions", FullTransactions: true}},
+		},
+		{
+			name:  "hash and full transaction streams together",
+			value: "newPendingTransactions,newPendingTransactions:full",
+			want: []types.Subscription{
+				{Type: "newPendingTransactions"},
+				{Type: "newPendingTransactions", FullTransactions: true},
+			},
+		},
+		{
+			name:    "full modifier on unsupported type",
+			value:   "newHeads:full",
+			wantErr: true,
+		},
+		{
+			name:    "unknown modifier",
+			value:   "newPendingTransactions:verbose",
+			wantErr: true,
+		},
+		{
+			name:    "empty value",
+			value:   " , ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSubscriptions(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSubscriptions(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSubscriptions(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStallThresholds(t *testing.T) {
+	subs := []types.Subscription{
+		{Type: "newHeads"},
+		{Type: "newPendingTransactions", FullTransactions: true},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "empty value disables stall detection",
+			value: "",
+			want:  map[string]time.Duration{},
+		},
+		{
+			name:  "multiple thresholds with whitespace",
+			value: " newHeads = 10s , newPendingTransactions:full=1m,",
+			want: map[string]time.Duration{
+				"newHeads":                    10 * time.Second,
+				"newPendingTransactions:full": time.Minute,
+			},
+		},
+		{
+			name:    "type that is not subscribed",
+			value:   "logs=10s",
+			wantErr: true,
+		},
+		{
+			name:    "missing duration",
+			value:   "newHeads",
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			value:   "newHeads=soon",
+			wantErr: true,
+		},
+		{
+			name:    "zero duration",
+			value:   "newHeads=0s",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStallThresholds(tt.value, subs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStallThresholds(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseStallThresholds(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionParams(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  types.Subscription
+		want interface{}
+	}{
+		{
+			name: "newHeads",
+			sub:  types.Subscription{Type: "newHeads"},
+			want: []string{"newHeads"},
+		},
+		{
+			name: "pending transaction hashes",
+			sub:  types.Subscription{Type: "newPendingTransactions"},
+			want: []string{"newPendingTransactions"},
+		},
+		{
+			name: "full pending transactions",
+			sub:  types.Subscription{Type: "newPendingTransactions", FullTransactions: true},
+			want: []interface{}{"newPendingTransactions", true},
+		},
+		{
+			name: "logs",
+			sub:  types.Subscription{Type: "logs"},
+			want: []interface{}{"logs", map[string]interface{}{"topics": []interface{}{nil}}},
+		},
+		{
+			name: "custom",
+			sub:  types.Subscription{Type: "custom"},
+			want: []string{"custom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subscriptionParams(tt.sub); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subscriptionParams(%+v) = %#v, want %#v", tt.sub, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTransactionNotification(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantHash     string
+		wantHashOnly bool
+		wantErr      bool
+	}{
+		{
+			name: "full transaction",
+			data: `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1",` +
+				`"result":{"hash":"0xabc","from":"0x01","to":"0x02","nonce":"0x0","value":"0x1","gas":"0x5208","input":"0x"}}}`,
+			wantHash: "0xabc",
+		},
+		{
+			name: "contract creation",
+			data: `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1",` +
+				`"result":{"hash":"0xdef","from":"0x01","to":null,"input":"0x6080"}}}`,
+			wantHash: "0xdef",
+		},
+		{
+			name:         "hash only",
+			data:         `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":"0xabc"}}`,
+			wantHashOnly: true,
+		},
+		{
+			name:    "missing hash",
+			data:    `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":{"from":"0x01"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed result",
+			data:    `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0x1","result":[1,2]}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, hashOnly, err := decodeTransactionNotification([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeTransactionNotification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if hashOnly != tt.wantHashOnly {
+				t.Errorf("hashOnly = %v, want %v", hashOnly, tt.wantHashOnly)
+			}
+			if tt.wantHash != "" && (tx == nil || tx.Hash != tt.wantHash) {
+				t.Errorf("tx = %+v, want hash %q", tx, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_HandleMessage_FullTransactions(t *testing.T) {
+	config := &types.Config{
+		URL:           "wss://ethereum.rpc.grove.city/v1/app123",
+		ServiceID:     "ethereum",
+		Subscriptions: "newPendingTransactions:full",
+		SubCount:      1,
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.startSession()
+	client.session.pending[1] = types.Subscription{Type: "newPendingTransactions", FullTransactions: true}
+
+	messages := []string{
+		`{"jsonrpc":"2.0","id":1,"result":"0xsub"}`,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xsub","result":{"hash":"0xabc","from":"0x01"}}}`,
+		`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xsub","result":"0xabc"}}`,
+	}
+	for _, msg := range messages {
+		if err := client.handleMessage(message{data: []byte(msg)}); err != nil {
+			t.Fatalf("handleMessage(%s) error = %v", msg, err)
+		}
+	}
+
+	if err := client.handleMessage(message{data: []byte("not json")}); err == nil {
+		t.Error("handleMessage() should fail on invalid JSON")
+	}
+
+	if got := statsManager.Snapshot().Stats.SubscriptionEvents; got != 2 {
+		t.Errorf("SubscriptionEvents = %d, want 2", got)
+	}
+
+	txStats, ok := statsManager.Snapshot().TransactionStats["newPendingTransactions:full"]
+	if !ok {
+		t.Fatal("TransactionStats missing newPendingTransactions:full")
+	}
+	if txStats.Payload.Count != 2 || txStats.Decoded != 1 || txStats.HashOnly != 1 {
+		t.Errorf("TransactionStats = %+v, want 2 payloads, 1 decoded, 1 hash-only", txStats)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Substrate subscription types. Each has its own methods, e.g.
+// chain_subscribeFinalizedHeads, chain_unsubscribeFinalizedHeads and
+// chain_finalizedHead, so they are listed in substrateMethods.
+const (
+	substrateNewHeads       = "newHeads"
+	substrateFinalizedHeads = "finalizedHeads"
+	substrateAllHeads       = "allHeads"
+	substrateStorage        = "storage"
+)
+
+// substrateMethods are the methods of every Substrate subscription type
+var substrateMethods = map[string]types.PubSubMethods{
+	substrateNewHeads:       {Subscribe: "chain_subscribeNewHeads", Unsubscribe: "chain_unsubscribeNewHeads", Notification: "chain_newHead"},
+	substrateFinalizedHeads: {Subscribe: "chain_subscribeFinalizedHeads", Unsubscribe: "chain_unsubscribeFinalizedHeads", Notification: "chain_finalizedHead"},
+	substrateAllHeads:       {Subscribe: "chain_subscribeAllHeads", Unsubscribe: "chain_unsubscribeAllHeads", Notification: "chain_allHead"},
+	substrateStorage:        {Subscribe: "state_subscribeStorage", Unsubscribe: "state_unsubscribeStorage", Notification: "state_storage"},
+}
+
+// substrateBlockNumberKey is the storage key of System.Number, the number of
+// the block being built, which changes with every block. Subscribing to all
+// keys is refused by most public nodes.
+const substrateBlockNumberKey = "0x26aa394eea5630e07c48ae0c9558cef702a5c1b19ab7a04f536c519aca4983ac"
+
+// substratePubSub returns the Substrate subscription API. Head subscriptions
+// take no params.
+func substratePubSub() types.PubSub {
+	methods := make(map[string]types.PubSubMethods, len(substrateMethods))
+	for subType, typeMethods := range substrateMethods {
+		methods[subType] = typeMethods
+	}
+	newHeads := substrateMethods[substrateNewHeads]
+	return types.PubSub{
+		SubscribeMethod:    newHeads.Subscribe,
+		UnsubscribeMethod:  newHeads.Unsubscribe,
+		NotificationMethod: newHeads.Notification,
+		SubscribeParams: map[string]json.RawMessage{
+			anyTypeParams:    json.RawMessage(`[]`),
+			substrateStorage: json.RawMessage(`[["` + substrateBlockNumberKey + `"]]`),
+		},
+		UnsubscribeParams: json.RawMessage(`["{id}"]`),
+		TypeMethods:       methods,
+	}
+}
+
+// checkSubstrateSubscriptions rejects types Substrate does not stream
+func checkSubstrateSubscriptions(subs []types.Subscription) error {
+	supported := make([]string, 0, len(substrateMethods))
+	for subType := range substrateMethods {
+		supported = append(supported, subType)
+	}
+	slices.Sort(supported)
+
+	for _, sub := range subs {
+		if !slices.Contains(supported, sub.Type) {
+			return fmt.Errorf("unsupported Substrate subscription type %q (want one of %v)", sub.Type, supported)
+		}
+	}
+	return nil
+}
+
+// substrateHead returns the number of the header in a best or finalized
+// head notification. Heads of every fork are not tracked, as they do not
+// follow one chain.
+func substrateHead(sub types.Subscription, data []byte) (number uint64, finalized bool, ok bool) {
+	if sub.Type != substrateNewHeads && sub.Type != substrateFinalizedHeads {
+		return 0, false, false
+	}
+	var notification struct {
+		Params struct {
+			Result struct {
+				Number string `json:"number"`
+			} `json:"result"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return 0, false, false
+	}
+
+	// Block numbers are hex encoded
+	hex, isHex := strings.CutPrefix(notification.Params.Result.Number, "0x")
+	number, err := strconv.ParseUint(hex, 16, 64)
+	if !isHex || err != nil {
+		return 0, false, false
+	}
+	return number, sub.Type == substrateFinalizedHeads, true
+}
+
+// substrateSequenceNumber returns the number of a best or finalized head.
+// Best heads are notified for every block of the best chain, so a jump shows
+// heads that were not delivered and a repeat a reorganisation. Current nodes
+// notify every finalized head too, while older ones only notify the last of
+// several blocks finalized together.
+func substrateSequenceNumber(sub types.Subscription, data []byte) (uint64, bool) {
+	number, _, ok := substrateHead(sub, data)
+	return number, ok
+}
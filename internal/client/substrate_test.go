@@ -0,0 +1,174 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestCheckSubstrateSubscriptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		subs    string
+		wantErr bool
+	}{
+		{name: "every type", subs: "newHeads,finalizedHeads,allHeads,storage"},
+		{name: "Solana type", subs: "newHeads,slot", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := ParseSubscriptions(tt.subs)
+			if err != nil {
+				t.Fatalf("ParseSubscriptions(%q) error = %v", tt.subs, err)
+			}
+			if err := ProtocolSubstrate.CheckSubscriptions(subs, ProtocolSubstrate.PubSub()); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSubscriptions(%q) error = %v, wantErr %v", tt.subs, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubstratePubSub(t *testing.T) {
+	pubsub := newPubSub(ProtocolSubstrate.PubSub())
+
+	tests := []struct {
+		sub              string
+		wantSubscribe    string
+		wantParams       string
+		wantUnsubscribe  string
+		wantNotification string
+	}{
+		{sub: "newHeads", wantSubscribe: "chain_subscribeNewHeads", wantParams: `[]`, wantUnsubscribe: "chain_unsubscribeNewHeads", wantNotification: "chain_newHead"},
+		{sub: "finalizedHeads", wantSubscribe: "chain_subscribeFinalizedHeads", wantParams: `[]`, wantUnsubscribe: "chain_unsubscribeFinalizedHeads", wantNotification: "chain_finalizedHead"},
+		{sub: "storage", wantSubscribe: "state_subscribeStorage", wantParams: `[["` + substrateBlockNumberKey + `"]]`, wantUnsubscribe: "state_unsubscribeStorage", wantNotification: "state_storage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sub, func(t *testing.T) {
+			sub := types.Subscription{Type: tt.sub}
+			request, err := pubsub.subscribeRequest(sub, 1)
+			if err != nil {
+				t.Fatalf("subscribeRequest() error = %v", err)
+			}
+			if params, _ := json.Marshal(request.Params); request.Method != tt.wantSubscribe || string(params) != tt.wantParams {
+				t.Errorf("subscribeRequest() = %s %s, want %s %s", request.Method, params, tt.wantSubscribe, tt.wantParams)
+			}
+
+			request, err = pubsub.unsubscribeRequest(sub, "sub-1", 2)
+			if err != nil {
+				t.Fatalf("unsubscribeRequest() error = %v", err)
+			}
+			if params, _ := json.Marshal(request.Params); request.Method != tt.wantUnsubscribe || string(params) != `["sub-1"]` {
+				t.Errorf("unsubscribeRequest() = %s %s, want %s [\"sub-1\"]", request.Method, params, tt.wantUnsubscribe)
+			}
+
+			if !pubsub.isNotification(tt.wantNotification, sub) {
+				t.Errorf("isNotification(%s) = false, want true", tt.wantNotification)
+			}
+		})
+	}
+}
+
+func TestSubstrateHead(t *testing.T) {
+	tests := []struct {
+		name          string
+		sub           string
+		data          string
+		want          uint64
+		wantFinalized bool
+		wantOK        bool
+	}{
+		{
+			name:   "best head",
+			sub:    "newHeads",
+			data:   `{"jsonrpc":"2.0","method":"chain_newHead","params":{"subscription":"a","result":{"number":"0x1a2b","parentHash":"0x00"}}}`,
+			want:   0x1a2b,
+			wantOK: true,
+		},
+		{
+			name:          "finalized head",
+			sub:           "finalizedHeads",
+			data:          `{"jsonrpc":"2.0","method":"chain_finalizedHead","params":{"subscription":"a","result":{"number":"0x10"}}}`,
+			want:          16,
+			wantFinalized: true,
+			wantOK:        true,
+		},
+		{
+			name: "heads of every fork are not tracked",
+			sub:  "allHeads",
+			data: `{"jsonrpc":"2.0","method":"chain_allHead","params":{"subscription":"a","result":{"number":"0x10"}}}`,
+		},
+		{
+			name: "number without hex prefix",
+			sub:  "newHeads",
+			data: `{"jsonrpc":"2.0","method":"chain_newHead","params":{"subscription":"a","result":{"number":"16"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, finalized, ok := ProtocolSubstrate.head(types.Subscription{Type: tt.sub}, []byte(tt.data))
+			if got != tt.want || finalized != tt.wantFinalized || ok != tt.wantOK {
+				t.Errorf("head() = %d, %v, %v, want %d, %v, %v", got, finalized, ok, tt.want, tt.wantFinalized, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_SubstrateFinalityLag(t *testing.T) {
+	node := newFakeNode(t, 2, func(conn *websocket.Conn, connection int, subIDs []string) {
+		// Best head 12 never arrives
+		heads := []struct {
+			method string
+			subID  string
+			number int
+		}{
+			{method: "chain_newHead", subID: subIDs[0], number: 10},
+			{method: "chain_finalizedHead", subID: subIDs[1], number: 9},
+			{method: "chain_newHead", subID: subIDs[0], number: 11},
+			{method: "chain_newHead", subID: subIDs[0], number: 13},
+			{method: "chain_finalizedHead", subID: subIDs[1], number: 10},
+		}
+		for _, head := range heads {
+			_ = conn.WriteJSON(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  head.method,
+				"params": map[string]interface{}{
+					"subscription": head.subID,
+					"result":       map[string]interface{}{"number": fmt.Sprintf("0x%x", head.number)},
+				},
+			})
+		}
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.Subscriptions = "newHeads,finalizedHeads"
+	config.Protocol = string(ProtocolSubstrate)
+	config.PubSub = ProtocolSubstrate.PubSub()
+	snap := runUntilFinished(t, config)
+
+	if got := node.Methods(); !reflect.DeepEqual(got, []string{"chain_subscribeNewHeads", "chain_subscribeFinalizedHeads"}) {
+		t.Errorf("subscribe methods = %v", got)
+	}
+	if snap.MessagesByType["newHeads"] != 3 || snap.MessagesByType["finalizedHeads"] != 2 {
+		t.Errorf("MessagesByType = %v, want 3 best and 2 finalized heads", snap.MessagesByType)
+	}
+	if got, want := snap.Sequences["newHeads"], (types.SequenceStats{Received: 3, Highest: 13, Gaps: 1, Missed: 1}); got != want {
+		t.Errorf("Sequences[newHeads] = %+v, want %+v", got, want)
+	}
+	want := types.FinalityStats{Samples: 4, BestHead: 13, Finalized: 10, LastLag: 3, MaxLag: 4, TotalLag: 10}
+	if snap.Finality != want {
+		t.Errorf("Finality = %+v, want %+v", snap.Finality, want)
+	}
+	// Only best head 10 was finalized
+	if got := snap.Latency(types.LatencyFinalityLag).Count(); got != 1 {
+		t.Errorf("finality lag latency samples = %d, want 1", got)
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures the TLS client used for wss:// URLs
+type TLSOptions struct {
+	CAFile             string // PEM bundle that replaces the system roots
+	CertFile           string // client certificate for mutual TLS
+	KeyFile            string
+	ServerName         string // overrides the SNI and the name the certificate is verified against
+	MinVersion         uint16
+	InsecureSkipVerify bool
+}
+
+// tlsVersions maps the accepted --tls-min-version values to their protocol versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses a TLS version such as "1.2"; an empty value means Go's default
+func ParseTLSVersion(value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(value), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("invalid TLS version %q (valid: 1.0, 1.1, 1.2, 1.3)", value)
+	}
+	return version, nil
+}
+
+// NewTLSConfig builds the TLS client configuration, or returns nil when every
+// option is unset so the dialer keeps its defaults
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if opts == (TLSOptions{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         opts.ServerName,
+		MinVersion:         opts.MinVersion,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		bundle, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CAFile)
+		}
+	}
+
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("a client certificate and key must be given together")
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
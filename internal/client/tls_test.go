@@ -0,0 +1,211 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// writePEM writes a single PEM block to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCertificate generates a self-signed client certificate and returns
+// the paths of its PEM certificate and key, and the certificate itself
+func clientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "load tester"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	return writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER), cert
+}
+
+// tlsConfigFor returns a single-connection config for a wss:// fake node
+func tlsConfigFor(t *testing.T, node *fakeNode, opts TLSOptions) *types.Config {
+	t.Helper()
+	tlsConfig, err := NewTLSConfig(opts)
+	if err != nil {
+		t.Fatalf("NewTLSConfig() unexpected error: %v", err)
+	}
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.TLS = tlsConfig
+	return config
+}
+
+// serverCA writes the certificate of a TLS fake node to a CA bundle
+func serverCA(t *testing.T, node *fakeNode) string {
+	t.Helper()
+	return writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", node.server.Certificate().Raw)
+}
+
+// closeAfterNotification sends one notification and lets the connection close
+func closeAfterNotification(conn *websocket.Conn, connection int, subIDs []string) {
+	_ = notify(conn, subIDs[0], map[string]interface{}{"number": "0x1"})
+}
+
+func TestWebSocketClient_TLS(t *testing.T) {
+	node := newFakeTLSNode(t, nil, closeAfterNotification)
+	node.maxConnections = 1
+
+	tests := []struct {
+		name      string
+		opts      TLSOptions
+		wantClass types.FailureClass // empty when the connection should succeed
+	}{
+		{name: "system roots reject the test CA", opts: TLSOptions{MinVersion: tls.VersionTLS12}, wantClass: types.FailureTLS},
+		{name: "custom CA bundle", opts: TLSOptions{CAFile: serverCA(t, node)}},
+		// The httptest certificate is issued for example.com and 127.0.0.1
+		{name: "SNI override", opts: TLSOptions{CAFile: serverCA(t, node), ServerName: "example.com"}},
+		{name: "wrong server name", opts: TLSOptions{CAFile: serverCA(t, node), ServerName: "other.example"}, wantClass: types.FailureTLS},
+		{name: "insecure skip verify", opts: TLSOptions{InsecureSkipVerify: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node.mu.Lock()
+			node.connections = 0
+			node.mu.Unlock()
+
+			snap := runUntilFinished(t, tlsConfigFor(t, node, tt.opts))
+
+			if tt.wantClass != "" {
+				if len(snap.Failures) != 1 || snap.Failures[tt.wantClass] == 0 || snap.Stats.TotalConnections != 0 {
+					t.Errorf("Failures = %v, connections = %d, want only %s failures", snap.Failures, snap.Stats.TotalConnections, tt.wantClass)
+				}
+				return
+			}
+			if snap.Stats.TotalConnections != 1 || len(snap.UpgradeResponses) == 0 {
+				t.Fatalf("connections = %d, failures = %v, want one TLS connection", snap.Stats.TotalConnections, snap.Failures)
+			}
+			response := snap.UpgradeResponses[0]
+			if response.TLSVersion != "TLS 1.3" || !strings.HasPrefix(response.TLSCipher, "TLS_") {
+				t.Errorf("response = %+v, want the negotiated TLS version and cipher", response)
+			}
+			if snap.CurrentTLSSession() != response.TLSSession() || snap.TLSSessions()[response.TLSSession()] != 1 {
+				t.Errorf("TLSSessions() = %v, want one session", snap.TLSSessions())
+			}
+			if snap.Handshakes[0].TLS <= 0 {
+				t.Errorf("handshake = %+v, want a TLS phase", snap.Handshakes[0])
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_MutualTLS(t *testing.T) {
+	certFile, keyFile, cert := clientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+	node := newFakeTLSNode(t, &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}, closeAfterNotification)
+
+	// TLS 1.3 servers verify the client certificate after the client's
+	// handshake completes, so a missing certificate surfaces on the first read
+	snap := runUntilFinished(t, tlsConfigFor(t, node, TLSOptions{CAFile: serverCA(t, node)}))
+	if snap.Stats.EventsReceived != 0 {
+		t.Errorf("EventsReceived = %d without a client certificate, want 0", snap.Stats.EventsReceived)
+	}
+
+	node.mu.Lock()
+	node.maxConnections = node.connections + 1
+	node.mu.Unlock()
+	snap = runUntilFinished(t, tlsConfigFor(t, node, TLSOptions{CAFile: serverCA(t, node), CertFile: certFile, KeyFile: keyFile}))
+	if snap.Stats.TotalConnections != 1 || snap.MessagesByType["newHeads"] != 1 {
+		t.Errorf("connections = %d, messages = %v, want a notification over mutual TLS", snap.Stats.TotalConnections, snap.MessagesByType)
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint16
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"TLS1.3", tls.VersionTLS13, false},
+		{"1.4", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTLSVersion(%q) = %v, %v, want %v (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile, _ := clientCertificate(t)
+	emptyBundle := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(emptyBundle, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if config, err := NewTLSConfig(TLSOptions{}); config != nil || err != nil {
+		t.Errorf("NewTLSConfig() without options = %v, %v, want nil", config, err)
+	}
+
+	config, err := NewTLSConfig(TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "gateway.internal", MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatalf("NewTLSConfig() unexpected error: %v", err)
+	}
+	if config.RootCAs == nil || len(config.Certificates) != 1 || config.ServerName != "gateway.internal" || config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("NewTLSConfig() = %+v", config)
+	}
+
+	for name, opts := range map[string]TLSOptions{
+		"missing CA bundle":    {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"CA bundle without CA": {CAFile: emptyBundle},
+		"certificate only":     {CertFile: certFile},
+		"key only":             {KeyFile: keyFile},
+		"mismatched key pair":  {CertFile: certFile, KeyFile: certFile},
+	} {
+		if _, err := NewTLSConfig(opts); err == nil {
+			t.Errorf("NewTLSConfig() with %s: expected an error", name)
+		}
+	}
+}
@@ -1,69 +1,137 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
+	"github.com/commoddity/websocket-load-test/internal/backoff"
 	"github.com/commoddity/websocket-load-test/internal/stats"
 	"github.com/commoddity/websocket-load-test/internal/terminal"
 	"github.com/commoddity/websocket-load-test/internal/types"
 	"github.com/gorilla/websocket"
 )
 
+// ErrRetriesExhausted is returned by Err when the client gave up reconnecting
+var ErrRetriesExhausted = errors.New("reconnection retries exhausted")
+
 // WebSocketClient manages WebSocket connections and subscriptions
 type WebSocketClient struct {
-	config             *types.Config
-	statsManager       *stats.Manager
-	subscriptionIDs    map[string]int
-	idToSubscription   map[int]string
-	totalSubscriptions int
-	done               chan struct{}
+	config        *types.Config
+	statsManager  *stats.Manager
+	collector     *stats.Collector
+	dialer        *websocket.Dialer
+	protocol      Protocol
+	pubsub        pubSub
+	session       *session
+	sessions      int
+	nextRequestID int
+	callSchedule  *callSchedule
+	calls         *callWorkload // nil unless a call workload is running
+	pongDue       bool          // a graphql-transport-ws ping awaits its pong
+	backoff       *backoff.Backoff
+	done          chan struct{}
+	finished      chan struct{}
+	err           error
 }
 
 // NewWebSocketClient creates a new WebSocket client
 func NewWebSocketClient(config *types.Config, statsManager *stats.Manager, done chan struct{}) *WebSocketClient {
+	collector := statsManager.NewCollector()
+	pubsub := newPubSub(config.PubSub)
+
+	// Invalid subscriptions are reported when subscribing
+	subs, _ := ParseSubscriptions(config.Subscriptions)
+	collector.SetNotificationMethods(pubsub.notificationMethods(subs))
+
 	return &WebSocketClient{
-		config:           config,
-		statsManager:     statsManager,
-		subscriptionIDs:  make(map[string]int),
-		idToSubscription: make(map[int]string),
-		done:             done,
+		config:        config,
+		statsManager:  statsManager,
+		collector:     collector,
+		dialer:        newDialer(config, collector),
+		protocol:      Protocol(config.Protocol),
+		pubsub:        pubsub,
+		nextRequestID: 1,
+		callSchedule:  newCallSchedule(config.CallRate),
+		backoff: backoff.New(backoff.Policy{
+			Strategy:   backoff.Strategy(config.BackoffStrategy),
+			Base:       config.BackoffBase,
+			Max:        config.BackoffMax,
+			MaxRetries: config.MaxRetries,
+		}),
+		done:     done,
+		finished: make(chan struct{}),
 	}
 }
 
-// GetTotalSubscriptions returns the total number of subscriptions
-func (c *WebSocketClient) GetTotalSubscriptions() int {
-	return c.totalSubscriptions
-}
-
 // Start begins the connection loop
 func (c *WebSocketClient) Start() {
 	go c.connectionLoop()
 }
 
+// Finished is closed once the connection loop has stopped, either because
+// the done channel was closed or because the client gave up reconnecting
+func (c *WebSocketClient) Finished() <-chan struct{} {
+	return c.finished
+}
+
+// Err returns the reason the client stopped on its own, or nil.
+// It is only valid after Finished is closed.
+func (c *WebSocketClient) Err() error {
+	return c.err
+}
+
 // connectionLoop handles the main connection lifecycle
 func (c *WebSocketClient) connectionLoop() {
+	defer close(c.finished)
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			c.connectAndListen()
+			if !c.connectAndListen() {
+				// A client stopped by the done channel keeps the state it had
+				if c.err != nil {
+					c.collector.MarkClosed()
+				}
+				return
+			}
 		}
 	}
 }
 
-// connectAndListen establishes a WebSocket connection and listens for messages
-func (c *WebSocketClient) connectAndListen() {
+// waitForRetry sleeps for the next backoff delay. It returns false if the
+// retry budget is exhausted or the client is shutting down.
+func (c *WebSocketClient) waitForRetry() bool {
+	delay, ok := c.backoff.Next()
+	if !ok {
+		c.err = fmt.Errorf("%w after %d consecutive failures", ErrRetriesExhausted, c.backoff.Attempts())
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// connectAndListen establishes a WebSocket connection and listens for messages.
+// It returns false when the connection loop should stop.
+func (c *WebSocketClient) connectAndListen() bool {
 	// Parse the WebSocket URL
 	u, err := url.Parse(c.config.URL)
 	if err != nil {
 		terminal.Red.Printf("❌ Invalid URL: %v\n", err)
-		time.Sleep(5 * time.Second)
-		return
+		return c.waitForRetry()
 	}
 
 	// Convert to WebSocket scheme if needed
@@ -82,75 +150,179 @@ func (c *WebSocketClient) connectAndListen() {
 		headers.Add("Authorization", c.config.AuthHeader)
 	}
 
-	c.statsManager.IncrementConnectionAttempts()
+	c.collector.IncrementConnectionAttempts()
 
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), headers)
+	trace := newHandshakeTrace()
+	conn, resp, err := c.dialer.DialContext(trace.context(context.Background()), u.String(), headers)
+	c.collector.RecordHandshake(trace.timing(time.Now(), err == nil || resp != nil, err != nil))
 	if err != nil {
-		c.statsManager.IncrementReconnections()
-		time.Sleep(5 * time.Second)
-		return
+		if resp != nil {
+			c.collector.RecordUpgradeResponse(trace.upgradeResponse(resp, time.Now()))
+		}
+		body := handshakeBody(resp)
+		failure := dialFailure(err, resp, body)
+		c.collector.RecordDialFailure(failure)
+
+		// Wrong credentials will not fix themselves, so fail fast
+		if isAuthFailure(failure.Class) && !c.config.RetryAuthFailures {
+			c.err = &AuthError{Status: resp.Status, Body: body}
+			return false
+		}
+
+		c.collector.IncrementReconnections()
+		return c.waitForRetry()
 	}
 
 	defer conn.Close()
 
-	// Update stats
-	c.statsManager.StartNewConnection()
+	// Update stats and start the retry sequence over
+	c.collector.StartNewConnection()
+	c.collector.RecordUpgradeResponse(trace.upgradeResponse(resp, time.Now()))
+	c.backoff.Reset()
+	c.startSession()
+	defer c.endSession()
 
-	// Show initial stats display
-	c.statsManager.DisplayRunningStats(c.totalSubscriptions)
+	// Some protocols need a handshake of their own before subscribing
+	if err := c.initialize(conn); err != nil {
+		failure := c.readFailure(err)
+		failure.Reason = fmt.Sprintf("%s: %v", reasonInitFailed, err)
+		c.disconnect(failure)
+		c.endSession()
+		return c.waitForRetry()
+	}
 
 	// Send subscription requests
 	c.sendSubscriptions(conn)
 
-	// Listen for messages
-	c.listenForMessages(conn)
+	// Ping the server so half-open connections are detected. Pongs are only
+	// read while listening, so heartbeats start once subscribing is done.
+	heartbeat := startHeartbeat(conn, c.collector, c.config.PingInterval, c.config.PongTimeout)
+	defer heartbeat.Stop()
+
+	// Watch for subscriptions that go silent while the connection stays open
+	watchdog := startStallWatchdog(conn, c.collector, c.config.StallThresholds, c.config.StallReconnect)
+	defer watchdog.Stop()
+
+	// Send workload calls once subscribing is done, like heartbeats
+	c.calls = startCallWorkload(conn, c.collector, c.callSchedule, c.config.CallMethod, c.config.CallParams)
+	defer c.stopCalls()
+
+	// Listen for messages, cancelling the subscriptions on shutdown
+	stopWatching := c.watchShutdown(conn, heartbeat)
+	defer stopWatching()
+	if !c.listenForMessages(conn, watchdog) {
+		if c.err == nil {
+			c.stopCalls()
+			c.unsubscribe(conn)
+		}
+		return false
+	}
+	c.endSession()
+	c.stopCalls()
+	return c.waitForRetry()
+}
+
+// watchShutdown fails the pending read on conn once the done channel is
+// closed, so a quiet connection still notices the shutdown and unsubscribes.
+// The returned function stops watching.
+func (c *WebSocketClient) watchShutdown(conn *websocket.Conn, heartbeat *heartbeat) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-c.done:
+			heartbeat.expireRead(conn)
+		case <-stop:
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// shuttingDown reports whether the done channel is closed
+func (c *WebSocketClient) shuttingDown() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// initialize runs the handshake of protocols that need one on a new connection
+func (c *WebSocketClient) initialize(conn *websocket.Conn) error {
+	switch c.protocol {
+	case ProtocolGraphQL:
+		return c.initGraphQL(conn)
+	}
+	return nil
+}
+
+// stopCalls stops the call workload of the current connection, if any
+func (c *WebSocketClient) stopCalls() {
+	c.calls.Stop()
+	c.calls = nil
+}
+
+// startSession creates the subscription state for a new connection
+func (c *WebSocketClient) startSession() {
+	c.sessions++
+	c.session = newSession(c.sessions > 1)
+}
+
+// endSession retires the server subscription IDs of the current connection
+func (c *WebSocketClient) endSession() {
+	if c.session == nil {
+		return
+	}
+	for _, id := range c.session.subscriptionIDs() {
+		c.collector.RetireSubscription(id)
+	}
+	c.session = nil
 }
 
 // sendSubscriptions sends all subscription requests to the WebSocket server
 func (c *WebSocketClient) sendSubscriptions(conn *websocket.Conn) {
-	subTypes := strings.Split(c.config.Subscriptions, ",")
-	requestID := 1
-
-	for _, sub := range subTypes {
-		sub = strings.TrimSpace(sub)
-		if sub == "" {
-			continue
-		}
+	subs, err := ParseSubscriptions(c.config.Subscriptions)
+	if err != nil {
+		terminal.Red.Printf("❌ Invalid subscriptions: %v\n", err)
+		return
+	}
 
+	for _, sub := range subs {
 		// Create multiple instances of each subscription type
 		for instance := 1; instance <= c.config.SubCount; instance++ {
-			var params interface{}
-			switch sub {
-			case "newHeads":
-				params = []string{"newHeads"}
-			case "newPendingTransactions":
-				params = []string{"newPendingTransactions"}
-			case "logs":
-				params = []interface{}{"logs", map[string]interface{}{"topics": []interface{}{nil}}}
-			default:
-				params = []string{sub}
-			}
+			// Request IDs are never reused, even across connections
+			requestID := c.nextRequestID
+			c.nextRequestID++
 
-			subscribeReq := types.JSONRPCRequest{
-				JSONRPC: "2.0",
-				ID:      requestID,
-				Method:  "eth_subscribe",
-				Params:  params,
+			subscribeReq, err := c.pubsub.subscribeRequest(sub, requestID)
+			var payload []byte
+			if err == nil {
+				payload, err = c.protocol.encode(subscribeReq)
 			}
-
-			if err := conn.WriteJSON(subscribeReq); err != nil {
-				terminal.Red.Printf("❌ Failed to send subscription for %s #%d: %v\n", sub, instance, err)
-				requestID++
+			sentAt := time.Now()
+			if err == nil {
+				err = conn.WriteMessage(websocket.TextMessage, payload)
+			}
+			if err != nil {
+				terminal.Red.Printf("❌ Failed to send subscription for %s #%d: %v\n", sub.Key(), instance, err)
 				continue
 			}
+			c.collector.RecordSentMessage(sub.Key(), len(payload))
 
-			// Store mapping for response tracking
-			subKey := fmt.Sprintf("%s#%d", sub, instance)
-			c.subscriptionIDs[subKey] = requestID
-			c.idToSubscription[requestID] = sub
-
-			c.totalSubscriptions++
-			requestID++
+			// Track the request until the server confirms it. Subscriptions the
+			// protocol does not confirm start at once, without a latency.
+			c.collector.RecordSubscriptionRequest()
+			if subscriptionID, started := c.protocol.startedID(requestID); started {
+				c.session.request(requestID, sub, time.Time{})
+				c.confirmSubscription(requestID, subscriptionID)
+			} else {
+				c.session.request(requestID, sub, sentAt)
+			}
 
 			// Add small delay between subscriptions to avoid overwhelming the server
 			time.Sleep(100 * time.Millisecond)
@@ -158,41 +330,259 @@ func (c *WebSocketClient) sendSubscriptions(conn *websocket.Conn) {
 	}
 }
 
-// listenForMessages listens for incoming WebSocket messages
-func (c *WebSocketClient) listenForMessages(conn *websocket.Conn) {
+// unsubscribe cancels every subscription confirmed on the connection. It
+// does not wait for the server to acknowledge the requests.
+func (c *WebSocketClient) unsubscribe(conn *websocket.Conn) {
+	if c.session == nil {
+		return
+	}
+	for _, subscriptionID := range c.session.subscriptionIDs() {
+		sub := c.session.active[subscriptionID]
+		requestID := c.nextRequestID
+		c.nextRequestID++
+
+		request, err := c.pubsub.unsubscribeRequest(sub, c.session.ids[subscriptionID], requestID)
+		var payload []byte
+		if err == nil {
+			payload, err = c.protocol.encode(request)
+		}
+		if err == nil {
+			err = conn.WriteMessage(websocket.TextMessage, payload)
+		}
+		if err != nil {
+			return
+		}
+		c.collector.RecordSentMessage(sub.Key(), len(payload))
+	}
+}
+
+// listenForMessages listens for incoming WebSocket messages.
+// It returns false if the client is shutting down and true if the connection was lost.
+func (c *WebSocketClient) listenForMessages(conn *websocket.Conn, watchdog *stallWatchdog) bool {
 	for {
 		select {
 		case <-c.done:
-			return
+			return false
 		default:
-			var response types.JSONRPCResponse
-			err := conn.ReadJSON(&response)
+			msg, err := readMessage(conn)
 			if err != nil {
-				c.statsManager.EndConnection()
-				c.statsManager.IncrementReconnections()
-				time.Sleep(2 * time.Second)
-				return
+				// The read was failed on purpose by watchShutdown
+				if c.shuttingDown() {
+					return false
+				}
+				// A stall watchdog that forced a reconnect knows why better than the read error
+				if reason := watchdog.Reason(); reason != "" {
+					c.disconnect(types.Failure{Class: types.FailureStalled, Reason: reason})
+					return true
+				}
+				if isTimeout(err) {
+					c.collector.RecordPongTimeout()
+				}
+				c.disconnect(c.readFailure(err))
+				return true
+			}
+			if err := c.handleMessage(msg); err != nil {
+				var authErr *AuthError
+				if errors.As(err, &authErr) {
+					failure := types.Failure{Class: types.FailureRPCAuth, Reason: authErr.Error()}
+					if c.config.RetryAuthFailures {
+						c.disconnect(failure)
+						return true
+					}
+					c.collector.EndConnectionWithFailure(failure)
+					c.err = authErr
+					return false
+				}
+				c.disconnect(types.Failure{
+					Class:  types.FailureJSONDecode,
+					Reason: fmt.Sprintf("%s: %v", reasonInvalidMessage, err),
+				})
+				return true
+			}
+			if c.pongDue {
+				// A failed write shows up as a failed read next
+				c.pongDue = false
+				_ = c.writeGraphQL(conn, graphQLMessage{Type: graphQLPong})
+			}
+		}
+	}
+}
+
+// Disconnect reasons recorded in the connection history
+const (
+	reasonPongTimeout    = "pong timeout"
+	reasonReadError      = "read error"
+	reasonInvalidMessage = "invalid message"
+	reasonInitFailed     = "protocol handshake failed"
+)
+
+// disconnect records the end of the current connection
+func (c *WebSocketClient) disconnect(failure types.Failure) {
+	c.collector.EndConnectionWithFailure(failure)
+	c.collector.IncrementReconnections()
+}
+
+// isTimeout reports whether err is a deadline expiry
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// readErrorReason describes why reading from the connection failed
+func (c *WebSocketClient) readErrorReason(err error) string {
+	// The only read deadlines left once listening are the ones set by
+	// heartbeats, as the one set on shutdown is never reported
+	if isTimeout(err) {
+		return fmt.Sprintf("%s (no pong within %v)", reasonPongTimeout, c.config.PongTimeout)
+	}
+
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return fmt.Sprintf("closed by server (%d %s)", closeErr.Code, closeErr.Text)
+	}
+	return fmt.Sprintf("%s: %v", reasonReadError, err)
+}
+
+// handleMessage decodes a raw WebSocket message and processes it.
+// It returns an *AuthError when the server rejected the credentials.
+func (c *WebSocketClient) handleMessage(msg message) error {
+	response, err := c.protocol.decode(msg.data)
+	if err != nil {
+		return err
+	}
+
+	// Workload call errors are counted with the calls, whatever they say
+	subscribe := c.answersSubscribe(response)
+	if !c.handleResponse(response) {
+		if authErr := rpcAuthError(response, subscribe); authErr != nil {
+			return authErr
+		}
+	}
+
+	messageType := types.RPCMessageType
+	if c.collector.IsNotification(response.Method) {
+		messageType = "unknown"
+		if subscriptionID, sub, ok := c.subscriptionForNotification(response); ok {
+			messageType = sub.Key()
+			if number, ok := c.protocol.sequenceNumber(sub, msg.data); ok {
+				c.collector.RecordSequence(subscriptionID, sub.Key(), number)
+			}
+			if number, finalized, ok := c.protocol.head(sub, msg.data); ok {
+				c.collector.RecordHead(number, finalized)
 			}
 
-			// Handle the response
-			c.handleResponse(response)
+			// Decode full transaction payloads and account for their size
+			if sub.FullTransactions {
+				_, hashOnly, err := decodeTransactionNotification(msg.data)
+				c.collector.RecordTransactionPayload(sub.Key(), len(msg.data), hashOnly, err)
+			}
 		}
 	}
+	c.collector.RecordWireMessage(messageType, msg.wireBytes, len(msg.data), msg.readTime)
+
+	// GraphQL operations can end on their own, and the server pings
+	if c.protocol == ProtocolGraphQL {
+		c.handleGraphQLMessage(msg.data)
+	}
+	return nil
+}
+
+// subscriptionForNotification looks up the subscription a notification
+// belongs to, returning its server subscription ID
+func (c *WebSocketClient) subscriptionForNotification(response types.JSONRPCResponse) (string, types.Subscription, bool) {
+	params, ok := response.Params.(map[string]interface{})
+	if !ok || c.session == nil {
+		return "", types.Subscription{}, false
+	}
+	subscriptionID, ok := types.SubscriptionID(params["subscription"])
+	if !ok {
+		return "", types.Subscription{}, false
+	}
+	sub, ok := c.session.active[subscriptionID]
+	return subscriptionID, sub, ok && c.pubsub.isNotification(response.Method, sub)
 }
 
-// handleResponse processes incoming WebSocket responses
-func (c *WebSocketClient) handleResponse(response types.JSONRPCResponse) {
-	c.statsManager.HandleResponse(response)
+// decodeTransactionNotification decodes the full transaction carried by a
+// newPendingTransactions notification. hashOnly is set when the node ignored
+// the full objects flag and sent a bare transaction hash instead.
+func decodeTransactionNotification(data []byte) (tx *types.Transaction, hashOnly bool, err error) {
+	var notification struct {
+		Params struct {
+			Result json.RawMessage `json:"result"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return nil, false, err
+	}
+
+	result := notification.Params.Result
+	if len(result) > 0 && result[0] == '"' {
+		return nil, true, nil
+	}
+
+	tx = &types.Transaction{}
+	if err := json.Unmarshal(result, tx); err != nil {
+		return nil, false, err
+	}
+	if tx.Hash == "" {
+		return nil, false, fmt.Errorf("transaction payload has no hash")
+	}
+	return tx, false, nil
+}
+
+// handleResponse processes incoming WebSocket responses. It returns true
+// when the response answered a workload call.
+func (c *WebSocketClient) handleResponse(response types.JSONRPCResponse) bool {
+	c.collector.HandleResponse(response)
+	if c.calls.complete(response, time.Now()) {
+		return true
+	}
 
 	// Handle subscription confirmation responses
-	if response.Result != nil {
+	if response.Result != nil && c.session != nil {
 		if id, ok := response.ID.(float64); ok {
-			if subType, exists := c.idToSubscription[int(id)]; exists {
-				// Store the actual subscription ID returned by the server
-				if resultStr, ok := response.Result.(string); ok {
-					c.statsManager.SetSubscriptionMapping(resultStr, subType)
-				}
-			}
+			// Store the actual subscription ID returned by the server
+			c.confirmSubscription(int(id), c.confirmedID(int(id), response.Result))
+		}
+	}
+	return false
+}
+
+// answersSubscribe reports whether response answers a subscribe request of
+// the current connection that is still awaiting confirmation
+func (c *WebSocketClient) answersSubscribe(response types.JSONRPCResponse) bool {
+	id, ok := response.ID.(float64)
+	if !ok || c.session == nil {
+		return false
+	}
+	_, pending := c.session.pending[int(id)]
+	return pending
+}
+
+// confirmSubscription activates the subscription of the subscribe request
+// requestID under the ID the server refers to it by
+func (c *WebSocketClient) confirmSubscription(requestID int, result any) {
+	if sub, sentAt, confirmed := c.session.confirm(requestID, result); confirmed {
+		subscriptionID, _ := types.SubscriptionID(result)
+		c.collector.SetSubscriptionMapping(subscriptionID, sub.Key())
+		c.collector.RecordSubscriptionCreated(c.session.resubscribing)
+		if !sentAt.IsZero() {
+			c.collector.RecordLatency(types.LatencySubscribeConfirm, time.Since(sentAt))
 		}
 	}
 }
+
+// confirmedID returns the subscription ID confirmed by the result of the
+// subscribe request requestID, which is the result itself unless the
+// protocol identifies subscriptions by their params
+func (c *WebSocketClient) confirmedID(requestID int, result any) any {
+	sub, ok := c.session.pending[requestID]
+	if !ok {
+		return result
+	}
+	request, err := c.pubsub.subscribeRequest(sub, requestID)
+	if err != nil {
+		return result
+	}
+	return c.protocol.subscriptionID(request.Params, result)
+}
@@ -1,10 +1,13 @@
 package client
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/commoddity/websocket-load-test/internal/stats"
 	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
 )
 
 func TestNewWebSocketClient(t *testing.T) {
@@ -58,56 +61,158 @@ func TestNewWebSocketClient(t *testing.T) {
 				t.Error("Done channel not set correctly")
 			}
 
-			if client.subscriptionIDs == nil {
-				t.Error("SubscriptionIDs map not initialized")
+			if client.session != nil {
+				t.Error("Session should not exist before connecting")
 			}
 
-			if client.idToSubscription == nil {
-				t.Error("IdToSubscription map not initialized")
+			if client.nextRequestID != 1 {
+				t.Errorf("nextRequestID = %d, want 1", client.nextRequestID)
 			}
 		})
 	}
 }
 
-func TestWebSocketClient_GetTotalSubscriptions(t *testing.T) {
-	tests := []struct {
-		name                  string
-		initialSubscriptions  int
-		expectedSubscriptions int
-	}{
-		{
-			name:                  "zero subscriptions",
-			initialSubscriptions:  0,
-			expectedSubscriptions: 0,
-		},
-		{
-			name:                  "some subscriptions",
-			initialSubscriptions:  5,
-			expectedSubscriptions: 5,
-		},
+func TestWebSocketClient_Sessions(t *testing.T) {
+	config := &types.Config{
+		URL:           "wss://ethereum.rpc.grove.city/v1/app123",
+		ServiceID:     "ethereum",
+		AuthHeader:    "api_key_123",
+		Subscriptions: "newHeads",
+		SubCount:      1,
 	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			config := &types.Config{
-				URL:           "wss://ethereum.rpc.grove.city/v1/app123",
-				ServiceID:     "ethereum",
-				AuthHeader:    "api_key_123",
-				Subscriptions: "newHeads",
-				SubCount:      1,
-			}
-			statsManager := stats.NewManager()
-			done := make(chan struct{})
-			defer close(done)
+	client := NewWebSocketClient(config, statsManager, done)
 
-			client := NewWebSocketClient(config, statsManager, done)
-			client.totalSubscriptions = tt.initialSubscriptions
+	// Simulate two connections, each confirming one subscription
+	for conn, subID := range []string{"0xfirst", "0xsecond"} {
+		client.collector.StartNewConnection()
+		client.startSession()
 
-			got := client.GetTotalSubscriptions()
-			if got != tt.expectedSubscriptions {
-				t.Errorf("GetTotalSubscriptions() = %d, want %d", got, tt.expectedSubscriptions)
-			}
-		})
+		requestID := client.nextRequestID
+		client.nextRequestID++
+		client.session.pending[requestID] = types.Subscription{Type: "newHeads"}
+
+		client.handleResponse(types.JSONRPCResponse{ID: float64(requestID), Result: subID})
+
+		if len(client.session.pending) != 0 {
+			t.Errorf("connection %d: pending = %d, want 0", conn+1, len(client.session.pending))
+		}
+		if _, ok := client.session.active[subID]; !ok {
+			t.Errorf("connection %d: subscription %s not active", conn+1, subID)
+		}
+		if got := statsManager.Snapshot().Stats.ActiveSubscriptions; got != 1 {
+			t.Errorf("connection %d: ActiveSubscriptions = %d, want 1", conn+1, got)
+		}
+
+		client.endSession()
+		client.collector.EndConnection("closed")
+
+		if got := statsManager.Snapshot().Stats.ActiveSubscriptions; got != 0 {
+			t.Errorf("connection %d: ActiveSubscriptions after close = %d, want 0", conn+1, got)
+		}
+		if client.session != nil {
+			t.Errorf("connection %d: session should be cleared after close", conn+1)
+		}
+	}
+
+	stats := statsManager.Snapshot().Stats
+	if stats.SubscriptionsCreated != 2 {
+		t.Errorf("SubscriptionsCreated = %d, want 2", stats.SubscriptionsCreated)
+	}
+	if stats.Resubscriptions != 1 {
+		t.Errorf("Resubscriptions = %d, want 1", stats.Resubscriptions)
+	}
+}
+
+func TestWebSocketClient_ResubscribesAfterReconnect(t *testing.T) {
+	// Both connections confirm their subscriptions, deliver one event each and
+	// drop; the third upgrade is rejected so the client gives up and stops
+	node := newFakeNode(t, 2, func(conn *websocket.Conn, connection int, subIDs []string) {
+		for _, subID := range subIDs {
+			_ = notify(conn, subID, map[string]interface{}{"number": "0x1"})
+		}
+	})
+	node.maxConnections = 2
+
+	config := &types.Config{
+		URL:             node.URL(),
+		ServiceID:       "ethereum",
+		Subscriptions:   "newHeads,logs",
+		SubCount:        1,
+		BackoffStrategy: "constant",
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      time.Millisecond,
+		MaxRetries:      1,
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.Start()
+
+	select {
+	case <-client.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not finish")
+	}
+
+	snap := statsManager.Snapshot()
+	stats := snap.Stats
+	if stats.TotalConnections != 2 {
+		t.Fatalf("TotalConnections = %d, want 2", stats.TotalConnections)
+	}
+	if got := snap.Latency(types.LatencySubscribeConfirm).Count(); got != 4 {
+		t.Errorf("subscribe confirm latencies = %d, want one per confirmed subscription", got)
+	}
+	if stats.SubscriptionsCreated != 4 {
+		t.Errorf("SubscriptionsCreated = %d, want 4", stats.SubscriptionsCreated)
+	}
+	if stats.Resubscriptions != 2 {
+		t.Errorf("Resubscriptions = %d, want 2", stats.Resubscriptions)
+	}
+	if stats.ActiveSubscriptions != 0 {
+		t.Errorf("ActiveSubscriptions = %d, want 0 after the connection closed", stats.ActiveSubscriptions)
+	}
+	if stats.SubscriptionEvents != 4 {
+		t.Errorf("SubscriptionEvents = %d, want 4", stats.SubscriptionEvents)
+	}
+
+	ids := node.RequestIDs()
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("request ID %d reused across connections: %v", id, ids)
+		}
+		seen[id] = true
+	}
+}
+
+func TestWebSocketClient_HandleResponse_UnknownRequestID(t *testing.T) {
+	config := &types.Config{
+		URL:           "wss://ethereum.rpc.grove.city/v1/app123",
+		ServiceID:     "ethereum",
+		Subscriptions: "newHeads",
+		SubCount:      1,
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.startSession()
+
+	// A result for a request this session never sent must not activate anything
+	client.handleResponse(types.JSONRPCResponse{ID: float64(42), Result: "0xstale"})
+
+	if len(client.session.active) != 0 {
+		t.Errorf("active = %d, want 0", len(client.session.active))
+	}
+	if got := statsManager.Snapshot().Stats.SubscriptionsCreated; got != 0 {
+		t.Errorf("SubscriptionsCreated = %d, want 0", got)
 	}
 }
 
@@ -170,14 +275,15 @@ func TestWebSocketClient_HandleResponse(t *testing.T) {
 
 			// Setup test data if needed
 			if tt.setupID {
-				client.idToSubscription[1] = "newHeads"
+				client.startSession()
+				client.session.pending[1] = types.Subscription{Type: "newHeads"}
 			}
 
 			// This should not panic and should handle the response
 			client.handleResponse(tt.response)
 
 			// Verify stats were updated
-			stats := statsManager.GetStats()
+			stats := statsManager.Snapshot().Stats
 			if stats.EventsReceived != 1 {
 				t.Errorf("EventsReceived = %d, want 1", stats.EventsReceived)
 			}
@@ -185,6 +291,74 @@ func TestWebSocketClient_HandleResponse(t *testing.T) {
 	}
 }
 
+func TestWebSocketClient_GivesUpAfterMaxRetries(t *testing.T) {
+	config := &types.Config{
+		// Nothing listens on port 1, so every dial fails immediately
+		URL:             "ws://127.0.0.1:1/v1/app123",
+		ServiceID:       "ethereum",
+		Subscriptions:   "newHeads",
+		SubCount:        1,
+		BackoffStrategy: "constant",
+		BackoffBase:     time.Millisecond,
+		BackoffMax:      time.Millisecond,
+		MaxRetries:      3,
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+	defer close(done)
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.Start()
+
+	select {
+	case <-client.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not give up after MaxRetries")
+	}
+
+	if !errors.Is(client.Err(), ErrRetriesExhausted) {
+		t.Errorf("Err() = %v, want ErrRetriesExhausted", client.Err())
+	}
+	snap := statsManager.Snapshot()
+	if got := snap.Stats.ConnectionAttempts; got != 4 {
+		t.Errorf("ConnectionAttempts = %d, want 4 (initial attempt plus 3 retries)", got)
+	}
+	if got := snap.Connections[0].State(); got != types.ConnectionClosed {
+		t.Errorf("State() = %q, want %q", got, types.ConnectionClosed)
+	}
+}
+
+func TestWebSocketClient_StopsWhenDone(t *testing.T) {
+	config := &types.Config{
+		URL:             "ws://127.0.0.1:1/v1/app123",
+		ServiceID:       "ethereum",
+		Subscriptions:   "newHeads",
+		SubCount:        1,
+		BackoffStrategy: "constant",
+		BackoffBase:     time.Hour,
+		BackoffMax:      time.Hour,
+	}
+	statsManager := stats.NewManager()
+	done := make(chan struct{})
+
+	client := NewWebSocketClient(config, statsManager, done)
+	client.Start()
+	close(done)
+
+	select {
+	case <-client.Finished():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not stop while waiting to retry")
+	}
+
+	if client.Err() != nil {
+		t.Errorf("Err() = %v, want nil after a requested shutdown", client.Err())
+	}
+	if got := statsManager.Snapshot().Connections[0].State(); got == types.ConnectionClosed {
+		t.Errorf("State() = %q, want the state before the requested shutdown", got)
+	}
+}
+
 func TestValidateSubscriptionParams(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/gorilla/websocket"
+)
+
+// wireConn counts the bytes read from and written to a connection, and
+// splits what it reads into WebSocket frames before any decompression to
+// queue the wire size of each data message as it completes. The dialer and
+// the reading goroutine are its only readers, one after the other, so the
+// frame counter needs no locking.
+type wireConn struct {
+	net.Conn
+	collector *stats.Collector
+	frames    frameCounter
+}
+
+// Read reads from the connection and counts the bytes read
+func (c *wireConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.collector.RecordBytesReceived(n)
+	c.frames.count(p[:n])
+	return n, err
+}
+
+// Write writes to the connection and counts the bytes written
+func (c *wireConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.collector.RecordBytesSent(n)
+	return n, err
+}
+
+// nextMessage returns the wire size of the oldest data message not yet taken,
+// or zero if none has completed
+func (c *wireConn) nextMessage() int {
+	return c.frames.next()
+}
+
+// upgradeResponseEnd terminates the HTTP upgrade response that precedes the frames
+const upgradeResponseEnd = "\r\n\r\n"
+
+// frameCounter splits the server-to-client byte stream into WebSocket frames
+// (RFC 6455 section 5.2). Data frames count towards the message they belong
+// to; control frames are skipped.
+type frameCounter struct {
+	upgradeMatched int    // bytes of upgradeResponseEnd matched so far
+	header         []byte // the frame header read so far
+	remaining      uint64 // payload bytes left in the current frame
+	fin            bool   // the current frame ends its message
+	control        bool   // the current frame is a ping, pong or close
+	message        int    // wire bytes of the data message being received
+	completed      []int  // wire sizes of data messages not yet taken
+}
+
+// count advances the counter over p
+func (f *frameCounter) count(p []byte) {
+	for len(p) > 0 {
+		switch {
+		case f.upgradeMatched < len(upgradeResponseEnd):
+			switch {
+			case p[0] == upgradeResponseEnd[f.upgradeMatched]:
+				f.upgradeMatched++
+			case p[0] == '\r':
+				f.upgradeMatched = 1
+			default:
+				f.upgradeMatched = 0
+			}
+			p = p[1:]
+		case f.remaining > 0:
+			n := min(uint64(len(p)), f.remaining)
+			f.remaining -= n
+			if !f.control {
+				f.message += int(n)
+			}
+			p = p[n:]
+			if f.remaining == 0 {
+				f.endFrame()
+			}
+		default:
+			f.header = append(f.header, p[0])
+			p = p[1:]
+			if size, ok := frameHeaderSize(f.header); ok && len(f.header) == size {
+				f.startFrame()
+			}
+		}
+	}
+}
+
+// startFrame parses the completed frame header
+func (f *frameCounter) startFrame() {
+	f.fin = f.header[0]&0x80 != 0
+	f.control = f.header[0]&0x08 != 0
+	switch length := f.header[1] & 0x7f; length {
+	case 126:
+		f.remaining = uint64(binary.BigEndian.Uint16(f.header[2:4]))
+	case 127:
+		f.remaining = binary.BigEndian.Uint64(f.header[2:10])
+	default:
+		f.remaining = uint64(length)
+	}
+	if !f.control {
+		f.message += len(f.header)
+	}
+	f.header = f.header[:0]
+	if f.remaining == 0 {
+		f.endFrame()
+	}
+}
+
+// endFrame completes the data message when its final frame has been read
+func (f *frameCounter) endFrame() {
+	if f.control || !f.fin {
+		return
+	}
+	f.completed = append(f.completed, f.message)
+	f.message = 0
+}
+
+// next takes the wire size of the oldest completed data message
+func (f *frameCounter) next() int {
+	if len(f.completed) == 0 {
+		return 0
+	}
+	size := f.completed[0]
+	f.completed = f.completed[1:]
+	return size
+}
+
+// frameHeaderSize returns the length of the frame header that starts with
+// header, once enough of it has been read to tell
+func frameHeaderSize(header []byte) (int, bool) {
+	if len(header) < 2 {
+		return 0, false
+	}
+	size := 2
+	switch header[1] & 0x7f {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+	if header[1]&0x80 != 0 {
+		size += 4 // masking key
+	}
+	return size, true
+}
+
+// dialFunc opens the transport to addr
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// countingDialer wraps the connections opened by dial so their traffic is
+// counted by collector
+func countingDialer(dial dialFunc, collector *stats.Collector) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &wireConn{Conn: conn, collector: collector}, nil
+	}
+}
+
+// tlsDialer opens the transport with dial and runs the TLS handshake the way
+// the WebSocket dialer would, firing the same trace hooks. Doing it here puts
+// the frame counter above TLS, where the frames are readable.
+func tlsDialer(dial dialFunc, config *tls.Config) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := config.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		tlsConn := tls.Client(conn, cfg)
+
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		err = tlsConn.HandshakeContext(ctx)
+		if trace != nil && trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// message is a WebSocket message as read from the connection
+type message struct {
+	data      []byte
+	wireBytes int           // size of its frames on the wire, compressed when negotiated
+	readTime  time.Duration // spent reading the payload once the first frame arrived
+}
+
+// readMessage reads the next data message. The read time covers inflating
+// the payload when compression was negotiated.
+func readMessage(conn *websocket.Conn) (message, error) {
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return message{}, err
+	}
+	start := time.Now()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return message{}, err
+	}
+
+	msg := message{data: data, readTime: time.Since(start)}
+	if wire, ok := conn.NetConn().(*wireConn); ok {
+		msg.wireBytes = wire.nextMessage()
+	}
+	return msg, nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// frame encodes an unmasked server frame with a payload of the given size
+func frame(fin bool, opcode byte, size int) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	var header []byte
+	switch {
+	case size < 126:
+		header = []byte{first, byte(size)}
+	case size <= 0xffff:
+		header = binary.BigEndian.AppendUint16([]byte{first, 126}, uint16(size))
+	default:
+		header = binary.BigEndian.AppendUint64([]byte{first, 127}, uint64(size))
+	}
+	return append(header, make([]byte, size)...)
+}
+
+func TestFrameCounter(t *testing.T) {
+	upgrade := []byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\n")
+	stream := slices.Concat(upgrade,
+		frame(true, websocket.TextMessage, 5),
+		frame(true, websocket.PingMessage, 4),
+		frame(true, websocket.TextMessage, 200),
+		// A fragmented message with a pong between its fragments
+		frame(false, websocket.TextMessage, 10),
+		frame(true, websocket.PongMessage, 0),
+		frame(true, 0, 5),
+		frame(true, websocket.BinaryMessage, 70000),
+		frame(true, websocket.TextMessage, 0),
+		frame(true, websocket.CloseMessage, 2),
+	)
+	want := []int{2 + 5, 4 + 200, (2 + 10) + (2 + 5), 10 + 70000, 2}
+
+	// Messages are split the same way however the stream arrives
+	for _, chunk := range []int{1, 3, 4096, len(stream)} {
+		var counter frameCounter
+		for rest := stream; len(rest) > 0; {
+			n := min(chunk, len(rest))
+			counter.count(rest[:n])
+			rest = rest[n:]
+		}
+
+		var got []int
+		for size := counter.next(); size != 0; size = counter.next() {
+			got = append(got, size)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("chunks of %d: messages = %v, want %v", chunk, got, want)
+		}
+	}
+}
+
+func TestWebSocketClient_WireBytes(t *testing.T) {
+	// Repetitive payloads, like transaction streams, compress well
+	result := map[string]interface{}{"data": strings.Repeat("0000", 500)}
+
+	tests := []struct {
+		name           string
+		tls            bool
+		compression    bool
+		wantCompressed bool
+	}{
+		{name: "uncompressed"},
+		{name: "compressed", compression: true, wantCompressed: true},
+		{name: "compressed over TLS", tls: true, compression: true, wantCompressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onSubscribed := func(conn *websocket.Conn, connection int, subIDs []string) {
+				_ = notify(conn, subIDs[0], result)
+			}
+			var (
+				node   *fakeNode
+				config *types.Config
+			)
+			if tt.tls {
+				node = newFakeTLSNode(t, nil, onSubscribed)
+				config = tlsConfigFor(t, node, TLSOptions{CAFile: serverCA(t, node)})
+			} else {
+				node = newFakeNode(t, 1, onSubscribed)
+				config = heartbeatConfig(node.URL())
+				config.PingInterval = 0
+			}
+			node.maxConnections = 1
+			node.upgrader.EnableCompression = true
+			config.Compression = tt.compression
+
+			snap := runUntilFinished(t, config)
+			if got := snap.CompressedConnections(); (got == 1) != tt.wantCompressed {
+				t.Errorf("CompressedConnections() = %d, want compressed %v", got, tt.wantCompressed)
+			}
+
+			wire := snap.WireStats["newHeads"]
+			if wire.Payload.Count != 1 || wire.Payload.TotalBytes < 2000 || wire.ReadTime <= 0 || wire.SentMessages != 1 {
+				t.Fatalf("WireStats[newHeads] = %+v, want one notification", wire)
+			}
+			if tt.compression {
+				if wire.SavingPercent() < 50 {
+					t.Errorf("WireStats[newHeads] = %+v, want compression to save over half the bytes", wire)
+				}
+			} else if wire.WireBytes != wire.Payload.TotalBytes+4 {
+				// One frame with a 16-bit extended length
+				t.Errorf("WireStats[newHeads] = %+v, want the payload plus a 4 byte frame header", wire)
+			}
+			// The totals add the upgrade exchange, the subscribe response and the close
+			if s := snap.Stats; s.BytesReceived <= wire.WireBytes || s.BytesSent <= wire.SentBytes {
+				t.Errorf("BytesReceived = %d, BytesSent = %d, want more than the newHeads traffic", s.BytesReceived, s.BytesSent)
+			}
+			if rpc := snap.WireStats[types.RPCMessageType]; rpc.Payload.Count != 1 || rpc.WireBytes == 0 {
+				t.Errorf("WireStats[rpc] = %+v, want the subscribe response", rpc)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+// callIDPrefix starts the request ID of every workload call. Call IDs are
+// strings so they can never be mistaken for numeric subscribe request IDs.
+const callIDPrefix = "call-"
+
+// ParseCallParams parses the --call-params value, which must be a JSON array
+// or object as JSON-RPC requires. An empty value sends no params.
+func ParseCallParams(value string) (json.RawMessage, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	params, err := parseParamsTemplate(value)
+	if err != nil {
+		return nil, fmt.Errorf("call params: %w", err)
+	}
+	return params, nil
+}
+
+// callSchedule is the open-loop schedule of a client's workload calls: call
+// i is due at start + i × interval, whatever happened to the calls before it.
+// The schedule outlives connections. Calls that fell due while the client was
+// disconnected are not sent in a burst once the next connection is up, which
+// would hit a rate-limited gateway with the very load that cut it off;
+// they are counted as missed, with how long they had been due as their
+// corrected latency.
+type callSchedule struct {
+	start    time.Time // set when the first connection starts sending
+	interval time.Duration
+	next     int // index of the next call to send
+}
+
+// newCallSchedule creates the schedule of rate calls per second. It returns
+// nil when rate is not positive, which disables the call workload.
+func newCallSchedule(rate float64) *callSchedule {
+	if rate <= 0 {
+		return nil
+	}
+	return &callSchedule{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// due returns when call i is due to be sent
+func (s *callSchedule) due(i int) time.Time {
+	return s.start.Add(time.Duration(i) * s.interval)
+}
+
+// skipMissed moves the schedule past the calls that fell due before now,
+// returning how long each of them had been due
+func (s *callSchedule) skipMissed(now time.Time) []time.Duration {
+	var overdue []time.Duration
+	for due := s.due(s.next); due.Before(now); due = s.due(s.next) {
+		overdue = append(overdue, now.Sub(due))
+		s.next++
+	}
+	return overdue
+}
+
+// pendingCall is a workload call awaiting its response
+type pendingCall struct {
+	due  time.Time
+	sent time.Time
+}
+
+// callWorkload sends the calls of a schedule on one connection and matches
+// their responses. Every latency is measured twice: from when the call was
+// actually sent, and from when it was due. A connection that stalls, or a
+// sender that falls behind, delays the calls that would have measured the
+// stall; measuring from the due time keeps that delay in the tail
+// (coordinated omission) instead of hiding it.
+type callWorkload struct {
+	conn      *websocket.Conn
+	collector *stats.Collector
+	schedule  *callSchedule
+	method    string
+	params    json.RawMessage
+	stop      chan struct{}
+	stopped   chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]pendingCall // call ID → call awaiting its response
+}
+
+// startCallWorkload starts sending the calls of schedule on conn. It returns
+// nil when schedule is nil, which disables the call workload.
+func startCallWorkload(conn *websocket.Conn, collector *stats.Collector, schedule *callSchedule, method string, params json.RawMessage) *callWorkload {
+	if schedule == nil {
+		return nil
+	}
+	now := time.Now()
+	if schedule.start.IsZero() {
+		schedule.start = now
+	}
+	if overdue := schedule.skipMissed(now); len(overdue) > 0 {
+		collector.RecordCallsMissed(overdue)
+	}
+
+	w := &callWorkload{
+		conn:      conn,
+		collector: collector,
+		schedule:  schedule,
+		method:    method,
+		params:    params,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		pending:   make(map[string]pendingCall),
+	}
+	go w.run()
+	return w
+}
+
+// run sends every call when it falls due until stopped. A call that is
+// already overdue is sent straight away rather than skipped.
+func (w *callWorkload) run() {
+	defer close(w.stopped)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		due := w.schedule.due(w.schedule.next)
+		timer.Reset(max(time.Until(due), 0))
+		select {
+		case <-w.stop:
+			return
+		case <-timer.C:
+		}
+
+		// A failed write means the connection is gone; the call is missed
+		// unless the next connection is up before it is due
+		if !w.send(due) {
+			return
+		}
+		w.schedule.next++
+	}
+}
+
+// send writes the next call, tracking it until its response arrives
+func (w *callWorkload) send(due time.Time) bool {
+	id := callIDPrefix + strconv.Itoa(w.schedule.next+1)
+	request := types.JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: w.method}
+	if len(w.params) > 0 {
+		request.Params = w.params
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return false
+	}
+
+	// Track the call before writing so a fast response always finds it
+	w.mu.Lock()
+	w.pending[id] = pendingCall{due: due, sent: time.Now()}
+	w.mu.Unlock()
+
+	if err := w.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+		return false
+	}
+	w.collector.RecordCallSent()
+	w.collector.RecordSentMessage(types.RPCMessageType, len(payload))
+	return true
+}
+
+// complete records the response to a workload call received at now. It
+// returns false when the response does not belong to a call of this workload.
+func (w *callWorkload) complete(response types.JSONRPCResponse, now time.Time) bool {
+	id, ok := response.ID.(string)
+	if w == nil || !ok {
+		return false
+	}
+
+	w.mu.Lock()
+	call, exists := w.pending[id]
+	delete(w.pending, id)
+	w.mu.Unlock()
+	if !exists {
+		return false
+	}
+
+	w.collector.RecordCallResponse(response.Error != nil, now.Sub(call.sent), now.Sub(call.due))
+	return true
+}
+
+// Stop stops sending calls, waits for the sending goroutine to exit and
+// counts the calls that never got a response
+func (w *callWorkload) Stop() {
+	if w == nil {
+		return
+	}
+	close(w.stop)
+	<-w.stopped
+
+	w.mu.Lock()
+	unanswered := len(w.pending)
+	clear(w.pending)
+	w.mu.Unlock()
+	if unanswered > 0 {
+		w.collector.RecordCallsUnanswered(unanswered)
+	}
+}
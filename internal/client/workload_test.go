@@ -0,0 +1,192 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+	"github.com/gorilla/websocket"
+)
+
+func TestParseCallParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty sends no params", value: "", want: ""},
+		{name: "array", value: `["latest", false]`, want: `["latest", false]`},
+		{name: "object with whitespace", value: ` {"block":"latest"} `, want: `{"block":"latest"}`},
+		{name: "scalar", value: `"latest"`, wantErr: true},
+		{name: "invalid JSON", value: `[latest`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCallParams(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCallParams(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ParseCallParams(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallSchedule(t *testing.T) {
+	if newCallSchedule(0) != nil {
+		t.Error("newCallSchedule(0) should disable the workload")
+	}
+
+	schedule := newCallSchedule(4)
+	schedule.start = time.Unix(100, 0)
+	if got := schedule.due(6); !got.Equal(time.Unix(101, int64(500*time.Millisecond))) {
+		t.Errorf("due(6) = %v, want 1.5s after the start", got)
+	}
+
+	// Calls due at 100s, 100.25s and 100.5s are missed; 100.75s is not due yet
+	overdue := schedule.skipMissed(time.Unix(100, int64(600*time.Millisecond)))
+	want := []time.Duration{600 * time.Millisecond, 350 * time.Millisecond, 100 * time.Millisecond}
+	if !reflect.DeepEqual(overdue, want) || schedule.next != 3 {
+		t.Errorf("skipMissed() = %v with next %d, want %v with next 3", overdue, schedule.next, want)
+	}
+}
+
+// answerCalls answers every workload call read from conn until it fails.
+// The call with errorID gets an error response.
+func answerCalls(t *testing.T, conn *websocket.Conn, errorID string) {
+	for {
+		var req struct {
+			ID     string `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if req.Method != "eth_blockNumber" {
+			t.Errorf("call method = %q, want eth_blockNumber", req.Method)
+		}
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x10"}
+		if req.ID == errorID {
+			response = map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32000, "message": "busy"}}
+		}
+		_ = conn.WriteJSON(response)
+	}
+}
+
+func TestWebSocketClient_CallWorkload(t *testing.T) {
+	// The server answers calls for a while, then closes the connection
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		answerCalls(t, conn, callIDPrefix+"3")
+	})
+	node.maxConnections = 1
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.CallRate = 100
+	config.CallMethod = "eth_blockNumber"
+	snap := runUntilFinished(t, config)
+
+	calls := snap.Calls
+	if calls.Sent < 5 || calls.Errors != 1 || calls.Answered+calls.Errors+calls.Unanswered != calls.Sent {
+		t.Fatalf("Calls = %+v, want every call sent answered, failed or unanswered", calls)
+	}
+	if snap.Stats.ConfirmationEvents != 1 {
+		t.Errorf("ConfirmationEvents = %d, call responses must not count as subscription confirmations", snap.Stats.ConfirmationEvents)
+	}
+	if snap.WireStats[types.RPCMessageType].SentMessages != calls.Sent {
+		t.Errorf("rpc SentMessages = %d, want %d", snap.WireStats[types.RPCMessageType].SentMessages, calls.Sent)
+	}
+
+	rtt, corrected := snap.Latency(types.LatencyCallRTT), snap.Latency(types.LatencyCallRTTCorrected)
+	if rtt.Count() != int64(calls.Answered+calls.Errors) || corrected.Count() != rtt.Count() {
+		t.Errorf("latency counts = %d and %d, want one per response", rtt.Count(), corrected.Count())
+	}
+	// A call is never sent before it is due
+	if corrected.Min() < rtt.Min() || corrected.Max() < rtt.Max() {
+		t.Errorf("corrected latency (%v–%v) below the uncorrected latency (%v–%v)", corrected.Min(), corrected.Max(), rtt.Min(), rtt.Max())
+	}
+}
+
+func TestCallWorkload_SkipsCallsMissedWhileDisconnected(t *testing.T) {
+	node := newFakeNode(t, 0, func(conn *websocket.Conn, connection int, subIDs []string) {
+		answerCalls(t, conn, "")
+	})
+	conn, _, err := websocket.DefaultDialer.Dial(node.URL(), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	// Ten calls fell due while there was no connection to send them on
+	manager := stats.NewManager()
+	collector := manager.NewCollector()
+	schedule := newCallSchedule(10)
+	schedule.start = time.Now().Add(-time.Second)
+	workload := startCallWorkload(conn, collector, schedule, "eth_blockNumber", json.RawMessage(`["latest"]`))
+
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var response types.JSONRPCResponse
+			if err := json.Unmarshal(data, &response); err == nil && !workload.complete(response, time.Now()) {
+				t.Errorf("response %s does not match a call", data)
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for manager.Snapshot().Calls.Answered < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	workload.Stop()
+
+	// The missed calls were not sent in a burst, but the wait still shows
+	snap := manager.Snapshot()
+	if snap.Calls.Missed < 10 || snap.Calls.Sent > 3 {
+		t.Fatalf("Calls = %+v, want the overdue calls missed rather than sent", snap.Calls)
+	}
+	corrected := snap.Latency(types.LatencyCallRTTCorrected)
+	if corrected.Count() != int64(snap.Calls.Missed+snap.Calls.Answered) || corrected.Max() < 900*time.Millisecond {
+		t.Errorf("corrected latency = %d samples up to %v, want one per missed or answered call up to about 1s", corrected.Count(), corrected.Max())
+	}
+	if rtt := snap.Latency(types.LatencyCallRTT); rtt.Count() != int64(snap.Calls.Answered) {
+		t.Errorf("call_rtt samples = %d, want only the calls sent", rtt.Count())
+	}
+}
+
+func TestWebSocketClient_CallWorkloadAcrossReconnect(t *testing.T) {
+	// Each connection answers calls briefly, then the client waits half a
+	// second before reconnecting
+	node := newFakeNode(t, 1, func(conn *websocket.Conn, connection int, subIDs []string) {
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		answerCalls(t, conn, "")
+	})
+	node.maxConnections = 2
+
+	config := heartbeatConfig(node.URL())
+	config.PingInterval = 0
+	config.BackoffBase = 500 * time.Millisecond
+	config.BackoffMax = 500 * time.Millisecond
+	config.CallRate = 100
+	config.CallMethod = "eth_blockNumber"
+	snap := runUntilFinished(t, config)
+
+	// Sending the calls due during the outage would add about 50
+	calls := snap.Calls
+	if calls.Missed < 30 || calls.Sent > 40 {
+		t.Errorf("Calls = %+v, want the calls due while reconnecting missed, not sent", calls)
+	}
+	if corrected := snap.Latency(types.LatencyCallRTTCorrected); corrected.Max() < 300*time.Millisecond {
+		t.Errorf("max corrected latency = %v, want the outage in the tail", corrected.Max())
+	}
+}
@@ -0,0 +1,237 @@
+package histogram
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"time"
+)
+
+// Histogram is a high-dynamic-range latency histogram. Values are bucketed
+// in microseconds with three significant digits, so every recorded latency
+// is reported to within 0.1% (exactly below 2.048ms) whatever its
+// magnitude. Buckets are stored sparsely, so there is no upper bound on the
+// values it can track and an idle histogram costs almost nothing.
+//
+// Min, max and mean are tracked exactly. The zero value is an empty
+// histogram ready to use; a Histogram is not safe for concurrent use.
+type Histogram struct {
+	counts map[int]int64 // bucket index → recorded values
+	total  int64
+	min    time.Duration
+	max    time.Duration
+	sum    time.Duration
+}
+
+// Bucket layout: bucket 0 holds every microsecond value below subBucketCount
+// exactly, and each following bucket covers twice the range of the previous
+// one at half the resolution, keeping the relative error constant.
+const (
+	subBucketHalfCountMagnitude = 10
+	subBucketHalfCount          = 1 << subBucketHalfCountMagnitude
+	subBucketCount              = 2 * subBucketHalfCount
+	unit                        = time.Microsecond
+)
+
+// New creates an empty histogram
+func New() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a latency to the histogram. Negative latencies count as zero.
+func (h *Histogram) Record(d time.Duration) {
+	h.RecordN(d, 1)
+}
+
+// RecordN adds n occurrences of the same latency to the histogram
+func (h *Histogram) RecordN(d time.Duration, n int64) {
+	if n <= 0 {
+		return
+	}
+	d = max(d, 0)
+	if h.counts == nil {
+		h.counts = make(map[int]int64)
+	}
+	h.counts[bucketIndex(int64(d/unit))] += n
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.total += n
+	h.sum += d * time.Duration(n)
+}
+
+// Merge adds every value recorded in other to h
+func (h *Histogram) Merge(other *Histogram) {
+	if other.Count() == 0 {
+		return
+	}
+	if h.counts == nil {
+		h.counts = make(map[int]int64, len(other.counts))
+	}
+	for index, count := range other.counts {
+		h.counts[index] += count
+	}
+	if h.total == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	h.max = max(h.max, other.max)
+	h.total += other.total
+	h.sum += other.sum
+}
+
+// Clone returns an independent copy of h
+func (h *Histogram) Clone() *Histogram {
+	clone := New()
+	clone.Merge(h)
+	return clone
+}
+
+// Count returns the number of recorded values. A nil histogram is empty.
+func (h *Histogram) Count() int64 {
+	if h == nil {
+		return 0
+	}
+	return h.total
+}
+
+// Min returns the smallest recorded value
+func (h *Histogram) Min() time.Duration {
+	if h.Count() == 0 {
+		return 0
+	}
+	return h.min
+}
+
+// Max returns the largest recorded value
+func (h *Histogram) Max() time.Duration {
+	if h.Count() == 0 {
+		return 0
+	}
+	return h.max
+}
+
+// Mean returns the exact mean of the recorded values
+func (h *Histogram) Mean() time.Duration {
+	if h.Count() == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// Percentile returns the value below which p percent of the recorded values
+// fall, reported as the highest value equivalent to its bucket and clamped
+// to the recorded range. Percentile(0) is the minimum and Percentile(100)
+// the maximum.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.Count() == 0 {
+		return 0
+	}
+
+	target := max(int64(math.Ceil(p/100*float64(h.total))), 1)
+	var cumulative int64
+	for _, index := range h.indices() {
+		cumulative += h.counts[index]
+		if cumulative >= target {
+			value := time.Duration(highestEquivalentValue(valueFromIndex(index))) * unit
+			return min(max(value, h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+// indices returns the occupied bucket indices in ascending value order
+func (h *Histogram) indices() []int {
+	indices := make([]int, 0, len(h.counts))
+	for index := range h.counts {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// bucketIndex returns the index of the bucket holding value
+func bucketIndex(value int64) int {
+	bucket := 64 - (subBucketHalfCountMagnitude + 1) - bits.LeadingZeros64(uint64(value)|(subBucketCount-1))
+	subBucket := int(value >> bucket)
+	return (bucket+1)<<subBucketHalfCountMagnitude + subBucket - subBucketHalfCount
+}
+
+// valueFromIndex returns the lowest value held by the bucket at index
+func valueFromIndex(index int) int64 {
+	bucket := index>>subBucketHalfCountMagnitude - 1
+	subBucket := int64(index&(subBucketHalfCount-1)) + subBucketHalfCount
+	if bucket < 0 {
+		subBucket -= subBucketHalfCount
+		bucket = 0
+	}
+	return subBucket << bucket
+}
+
+// highestEquivalentValue returns the highest value sharing a bucket with value
+func highestEquivalentValue(value int64) int64 {
+	bucket := 64 - (subBucketHalfCountMagnitude + 1) - bits.LeadingZeros64(uint64(value)|(subBucketCount-1))
+	return value + 1<<bucket - 1
+}
+
+// encoded is the serialized form of a Histogram. Each bucket is the lowest
+// value it holds, in microseconds, followed by its count.
+type encoded struct {
+	Unit    string     `json:"unit"`
+	Count   int64      `json:"count"`
+	MinNs   int64      `json:"min_ns"`
+	MaxNs   int64      `json:"max_ns"`
+	SumNs   int64      `json:"sum_ns"`
+	Buckets [][2]int64 `json:"buckets"`
+}
+
+// encodedUnit names the unit of serialized bucket values
+const encodedUnit = "us"
+
+// MarshalJSON serializes the histogram so it can be stored and merged later
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	e := encoded{Unit: encodedUnit, Buckets: [][2]int64{}}
+	if h.Count() > 0 {
+		e.Count = h.total
+		e.MinNs = int64(h.min)
+		e.MaxNs = int64(h.max)
+		e.SumNs = int64(h.sum)
+		for _, index := range h.indices() {
+			e.Buckets = append(e.Buckets, [2]int64{valueFromIndex(index), h.counts[index]})
+		}
+	}
+	return json.Marshal(e)
+}
+
+// UnmarshalJSON restores a histogram serialized by MarshalJSON
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var e encoded
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if e.Unit != encodedUnit {
+		return fmt.Errorf("unsupported histogram unit %q", e.Unit)
+	}
+
+	*h = Histogram{}
+	for _, bucket := range e.Buckets {
+		value, count := bucket[0], bucket[1]
+		if value < 0 || count <= 0 {
+			return fmt.Errorf("invalid histogram bucket [%d, %d]", value, count)
+		}
+		if h.counts == nil {
+			h.counts = make(map[int]int64, len(e.Buckets))
+		}
+		h.counts[bucketIndex(value)] += count
+		h.total += count
+	}
+	if h.total != e.Count {
+		return fmt.Errorf("histogram buckets hold %d values, want %d", h.total, e.Count)
+	}
+	h.min = time.Duration(e.MinNs)
+	h.max = time.Duration(e.MaxNs)
+	h.sum = time.Duration(e.SumNs)
+	return nil
+}
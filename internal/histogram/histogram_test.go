@@ -0,0 +1,181 @@
+package histogram
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	h := New()
+	for i := 100; i >= 1; i-- {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Millisecond},
+		{p: 1, want: time.Millisecond},
+		{p: 50, want: 50*time.Millisecond + 15*time.Microsecond},
+		{p: 90, want: 90*time.Millisecond + 47*time.Microsecond},
+		{p: 99, want: 99*time.Millisecond + 7*time.Microsecond},
+		{p: 100, want: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := h.Percentile(tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	if got := h.Count(); got != 100 {
+		t.Errorf("Count() = %d, want 100", got)
+	}
+	if got := h.Min(); got != time.Millisecond {
+		t.Errorf("Min() = %v, want 1ms", got)
+	}
+	if got := h.Max(); got != 100*time.Millisecond {
+		t.Errorf("Max() = %v, want 100ms", got)
+	}
+	if got := h.Mean(); got != 50500*time.Microsecond {
+		t.Errorf("Mean() = %v, want 50.5ms", got)
+	}
+}
+
+func TestHistogram_Precision(t *testing.T) {
+	// Every value must be reported to within 0.1% across many orders of magnitude
+	for _, value := range []time.Duration{
+		3 * time.Microsecond,
+		2047 * time.Microsecond,
+		2049 * time.Microsecond,
+		12345 * time.Microsecond,
+		987654321 * time.Microsecond,
+		40 * time.Hour,
+	} {
+		h := New()
+		h.Record(value)
+		h.Record(2 * value) // keeps the value from being clamped to the maximum
+
+		got := h.Percentile(50)
+		if got < value || float64(got-value) > float64(value)/1000 {
+			t.Errorf("Percentile(50) of %v = %v, want within 0.1%%", value, got)
+		}
+	}
+}
+
+func TestHistogram_Buckets(t *testing.T) {
+	// Indices must round trip to ascending, non-overlapping buckets
+	previous := int64(-1)
+	for index := 0; index < 20*subBucketHalfCount; index++ {
+		value := valueFromIndex(index)
+		if value <= previous {
+			t.Fatalf("valueFromIndex(%d) = %d, not above the previous bucket %d", index, value, previous)
+		}
+		if got := bucketIndex(value); got != index {
+			t.Fatalf("bucketIndex(%d) = %d, want %d", value, got, index)
+		}
+		if got := bucketIndex(highestEquivalentValue(value)); got != index {
+			t.Fatalf("bucketIndex(highestEquivalentValue(%d)) = %d, want %d", value, got, index)
+		}
+		previous = highestEquivalentValue(value)
+	}
+	if got := bucketIndex(math.MaxInt64); got <= 0 {
+		t.Errorf("bucketIndex(MaxInt64) = %d, want a valid bucket", got)
+	}
+}
+
+func TestHistogram_Empty(t *testing.T) {
+	var nilHistogram *Histogram
+	for name, h := range map[string]*Histogram{"zero": {}, "nil": nilHistogram} {
+		if h.Count() != 0 || h.Min() != 0 || h.Max() != 0 || h.Mean() != 0 || h.Percentile(99) != 0 {
+			t.Errorf("%s histogram should report zero for every statistic", name)
+		}
+	}
+
+	h := New()
+	h.Record(-time.Second)
+	h.RecordN(time.Second, 0)
+	if h.Count() != 1 || h.Max() != 0 {
+		t.Errorf("negative latencies should count as zero and non-positive counts be ignored, got count %d max %v", h.Count(), h.Max())
+	}
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	a, b, all := New(), New(), New()
+	for i := 1; i <= 50; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		all.Record(time.Duration(i) * time.Millisecond)
+	}
+	for i := 51; i <= 100; i++ {
+		b.RecordN(time.Duration(i)*time.Second, 2)
+		all.RecordN(time.Duration(i)*time.Second, 2)
+	}
+
+	merged := New()
+	merged.Merge(a)
+	merged.Merge(b)
+	merged.Merge(nil)
+	if merged.Count() != all.Count() || merged.Min() != all.Min() || merged.Max() != all.Max() || merged.Mean() != all.Mean() {
+		t.Errorf("merged = (%d, %v, %v, %v), want (%d, %v, %v, %v)", merged.Count(), merged.Min(), merged.Max(), merged.Mean(),
+			all.Count(), all.Min(), all.Max(), all.Mean())
+	}
+	for _, p := range []float64{10, 50, 90, 99, 99.9} {
+		if merged.Percentile(p) != all.Percentile(p) {
+			t.Errorf("merged Percentile(%v) = %v, want %v", p, merged.Percentile(p), all.Percentile(p))
+		}
+	}
+
+	// A clone must not share buckets with its source
+	clone := a.Clone()
+	clone.Record(time.Hour)
+	if a.Count() != 50 || a.Max() != 50*time.Millisecond {
+		t.Errorf("recording into a clone changed the source: count %d max %v", a.Count(), a.Max())
+	}
+}
+
+func TestHistogram_JSON(t *testing.T) {
+	h := New()
+	for _, d := range []time.Duration{1500 * time.Nanosecond, 3 * time.Millisecond, 3 * time.Millisecond, 2 * time.Second} {
+		h.Record(d)
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"unit":"us","count":4,"min_ns":1500,"max_ns":2000000000,"sum_ns":2006001500,"buckets":[[1,1],[3000,2],[1999872,1]]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var restored Histogram
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for _, p := range []float64{0, 50, 75, 100} {
+		if restored.Percentile(p) != h.Percentile(p) {
+			t.Errorf("restored Percentile(%v) = %v, want %v", p, restored.Percentile(p), h.Percentile(p))
+		}
+	}
+	if restored.Count() != h.Count() || restored.Mean() != h.Mean() {
+		t.Errorf("restored = (%d, %v), want (%d, %v)", restored.Count(), restored.Mean(), h.Count(), h.Mean())
+	}
+
+	empty, err := json.Marshal(New())
+	if err != nil || string(empty) != `{"unit":"us","count":0,"min_ns":0,"max_ns":0,"sum_ns":0,"buckets":[]}` {
+		t.Errorf("Marshal(empty) = %s, %v", empty, err)
+	}
+
+	for name, data := range map[string]string{
+		"unit":      `{"unit":"ms","count":0,"buckets":[]}`,
+		"count":     `{"unit":"us","count":2,"buckets":[[1,1]]}`,
+		"bucket":    `{"unit":"us","count":0,"buckets":[[1,0]]}`,
+		"malformed": `{"unit":`,
+	} {
+		if err := json.Unmarshal([]byte(data), &restored); err == nil {
+			t.Errorf("Unmarshal() with invalid %s should fail", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// ConnectionSort is the column the per-connection table is sorted by
+type ConnectionSort string
+
+// Per-connection table columns. Numeric columns sort largest first and
+// states sort worst first, so the connections that need attention lead.
+const (
+	SortIndex      ConnectionSort = "index"
+	SortState      ConnectionSort = "state"
+	SortUptime     ConnectionSort = "uptime"
+	SortReconnects ConnectionSort = "reconnects"
+	SortSubs       ConnectionSort = "subs"
+	SortEvents     ConnectionSort = "events"
+	SortRate       ConnectionSort = "rate"
+	SortLastEvent  ConnectionSort = "last-event"
+	SortError      ConnectionSort = "error"
+)
+
+// ConnectionSorts lists every per-connection table column
+var ConnectionSorts = []ConnectionSort{SortIndex, SortState, SortUptime, SortReconnects, SortSubs, SortEvents, SortRate, SortLastEvent, SortError}
+
+// ParseConnectionSort validates the column to sort the per-connection table by
+func ParseConnectionSort(value string) (ConnectionSort, error) {
+	for _, column := range ConnectionSorts {
+		if string(column) == value {
+			return column, nil
+		}
+	}
+
+	names := make([]string, len(ConnectionSorts))
+	for i, column := range ConnectionSorts {
+		names[i] = string(column)
+	}
+	return "", fmt.Errorf("unknown connection sort %q (expected one of %s)", value, strings.Join(names, ", "))
+}
+
+// sortConnectionRows orders rows by column, breaking ties by slot index
+func sortConnectionRows(rows []stats.ConnectionRow, by ConnectionSort) {
+	less := func(a, b stats.ConnectionRow) (bool, bool) {
+		switch by {
+		case SortState:
+			return slices.Index(types.ConnectionStates, a.State) < slices.Index(types.ConnectionStates, b.State), a.State == b.State
+		case SortUptime:
+			return a.Uptime > b.Uptime, a.Uptime == b.Uptime
+		case SortReconnects:
+			return a.Reconnects > b.Reconnects, a.Reconnects == b.Reconnects
+		case SortSubs:
+			return a.ActiveSubscriptions > b.ActiveSubscriptions, a.ActiveSubscriptions == b.ActiveSubscriptions
+		case SortEvents:
+			return a.Events > b.Events, a.Events == b.Events
+		case SortRate:
+			return a.EventsPerSecond > b.EventsPerSecond, a.EventsPerSecond == b.EventsPerSecond
+		case SortLastEvent:
+			return a.LastEventAge > b.LastEventAge, a.LastEventAge == b.LastEventAge
+		case SortError:
+			// Connections with an error first, then by error
+			if (a.LastError == "") != (b.LastError == "") {
+				return a.LastError != "", false
+			}
+			return a.LastError < b.LastError, a.LastError == b.LastError
+		}
+		return false, true
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if ordered, tied := less(rows[i], rows[j]); !tied {
+			return ordered
+		}
+		return rows[i].Index < rows[j].Index
+	})
+}
+
+// maxErrorWidth truncates the last error column of the per-connection table
+const maxErrorWidth = 48
+
+// writeConnectionTable writes the per-connection table sorted by column.
+// A positive limit shows only that many rows and says how many were left out.
+func writeConnectionTable(w io.Writer, snap stats.Snapshot, by ConnectionSort, limit int) {
+	rows := snap.ConnectionRows()
+	sortConnectionRows(rows, by)
+	hidden := 0
+	if limit > 0 && len(rows) > limit {
+		hidden = len(rows) - limit
+		rows = rows[:limit]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  #\tSTATE\tUPTIME\tRECONNECTS\tSUBS\tEVENTS\tEVENTS/S\tLAST EVENT\tLAST ERROR")
+	for _, row := range rows {
+		lastEvent := "-"
+		if row.LastEventAge > 0 {
+			lastEvent = row.LastEventAge.Round(time.Second).String() + " ago"
+		}
+		lastError := row.LastError
+		if lastError == "" {
+			lastError = "-"
+		} else if len(lastError) > maxErrorWidth {
+			lastError = lastError[:maxErrorWidth-3] + "..."
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%v\t%d\t%d\t%d\t%s\t%s\t%s\n", row.Index, row.State, row.Uptime.Round(time.Second),
+			row.Reconnects, row.ActiveSubscriptions, row.Events, strconv.FormatFloat(row.EventsPerSecond, 'f', 2, 64), lastEvent, lastError)
+	}
+	tw.Flush()
+	if hidden > 0 {
+		fmt.Fprintf(w, "  ... and %d more connections\n", hidden)
+	}
+}
+
+// formatConnectionStates counts connection slots by state on a single line, worst first
+func formatConnectionStates(snap stats.Snapshot) string {
+	states := snap.ConnectionStates()
+	parts := make([]string, 0, len(states))
+	for _, state := range types.ConnectionStates {
+		if count := states[state]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, state))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
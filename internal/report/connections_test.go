@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestParseConnectionSort(t *testing.T) {
+	for _, column := range ConnectionSorts {
+		if got, err := ParseConnectionSort(string(column)); err != nil || got != column {
+			t.Errorf("ParseConnectionSort(%q) = %q, %v", column, got, err)
+		}
+	}
+	if _, err := ParseConnectionSort("latency"); err == nil || !strings.Contains(err.Error(), "last-event") {
+		t.Errorf("ParseConnectionSort(\"latency\") error = %v, want the valid columns listed", err)
+	}
+}
+
+func TestSortConnectionRows(t *testing.T) {
+	rows := []stats.ConnectionRow{
+		{Index: 0, State: types.ConnectionSubscribed, Uptime: time.Minute, Events: 50, EventsPerSecond: 5, LastEventAge: time.Second},
+		{Index: 1, State: types.ConnectionStalled, Uptime: time.Hour, Reconnects: 3, ActiveSubscriptions: 2, Events: 10, EventsPerSecond: 1, LastEventAge: time.Minute, LastError: "read error"},
+		{Index: 2, State: types.ConnectionConnecting, Reconnects: 3, LastError: "dial timeout"},
+		{Index: 3, State: types.ConnectionSubscribed, Uptime: time.Minute, ActiveSubscriptions: 2, Events: 50, EventsPerSecond: 5, LastEventAge: time.Second},
+	}
+
+	tests := []struct {
+		by   ConnectionSort
+		want []int
+	}{
+		{SortIndex, []int{0, 1, 2, 3}},
+		{SortState, []int{1, 2, 0, 3}},
+		{SortUptime, []int{1, 0, 3, 2}},
+		{SortReconnects, []int{1, 2, 0, 3}},
+		{SortSubs, []int{1, 3, 0, 2}},
+		{SortEvents, []int{0, 3, 1, 2}},
+		{SortRate, []int{0, 3, 1, 2}},
+		{SortLastEvent, []int{1, 0, 3, 2}},
+		{SortError, []int{2, 1, 0, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			sorted := slices.Clone(rows)
+			sortConnectionRows(sorted, tt.by)
+			got := make([]int, len(sorted))
+			for i, row := range sorted {
+				got[i] = row.Index
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sorted by %s = %v, want %v", tt.by, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteConnectionTable(t *testing.T) {
+	manager := stats.NewManager()
+	for range 3 {
+		manager.NewCollector().StartNewConnection()
+	}
+	closed := manager.NewCollector()
+	closed.RecordDialFailure(types.Failure{Class: types.FailureDialTimeout, Reason: "dial timeout: " + strings.Repeat("x", 100)})
+	closed.MarkClosed()
+	snap := manager.Snapshot()
+
+	var buf bytes.Buffer
+	writeConnectionTable(&buf, snap, SortState, 2)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], "LAST ERROR") || !strings.Contains(lines[3], "and 2 more connections") {
+		t.Fatalf("table = %q, want a header, two rows and the number left out", lines)
+	}
+	// The closed connection leads, with its error truncated
+	if fields := strings.Fields(lines[1]); fields[0] != "3" || fields[1] != "closed" || !strings.HasSuffix(lines[1], "  dial timeout: "+strings.Repeat("x", maxErrorWidth-17)+"...") {
+		t.Errorf("first row = %q, want the closed connection with a truncated error", lines[1])
+	}
+
+	if got := formatConnectionStates(snap); got != "1 closed, 3 connecting" {
+		t.Errorf("formatConnectionStates() = %q", got)
+	}
+}
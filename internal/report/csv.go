@@ -0,0 +1,128 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// csvHeader names the columns written by CSVReporter
+var csvHeader = []string{
+	"timestamp", "elapsed_seconds", "connected", "connections", "reconnections",
+	"connection_attempts", "active_subscriptions", "events", "subscription_events",
+	"confirmations", "errors", "events_per_second", "outages", "downtime_seconds",
+	"pings_sent", "pongs_received", "pong_timeouts", "last_rtt_ms", "stalls", "active_stalls", "failures",
+	"handshakes", "handshake_p50_ms", "handshake_p99_ms", "wire_bytes", "payload_bytes",
+	"bytes_received", "bytes_sent", "receive_bytes_per_second", "send_bytes_per_second",
+	"events_per_second_10s", "events_per_second_1m", "events_per_second_5m",
+	"subscribe_confirm_p99_ms", "ping_rtt_p50_ms", "ping_rtt_p99_ms",
+	"calls_sent", "call_rtt_p99_ms", "call_rtt_corrected_p99_ms",
+	"sequence_gaps", "sequence_missed", "finality_lag_blocks",
+	"operation_errors",
+}
+
+// CSVReporter writes one row per update, producing a time series
+type CSVReporter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewCSV creates a CSV reporter writing to w
+func NewCSV(w io.Writer) *CSVReporter {
+	return &CSVReporter{w: csv.NewWriter(w)}
+}
+
+// Update appends a row for the snapshot
+func (c *CSVReporter) Update(snap stats.Snapshot) error {
+	return c.write(snap)
+}
+
+// Final appends a last row for the final snapshot
+func (c *CSVReporter) Final(snap stats.Snapshot) error {
+	return c.write(snap)
+}
+
+// write appends a row, writing the header first if needed
+func (c *CSVReporter) write(snap stats.Snapshot) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	s := snap.Stats
+	handshake := snap.HandshakeLatency(types.PhaseTotal)
+	wire := snap.TotalWireStats()
+	row := []string{
+		snap.Time.Format(time.RFC3339),
+		formatFloat(snap.Runtime().Seconds()),
+		strconv.FormatBool(snap.Connected()),
+		strconv.Itoa(s.TotalConnections),
+		strconv.Itoa(s.TotalReconnections),
+		strconv.Itoa(s.ConnectionAttempts),
+		strconv.Itoa(s.ActiveSubscriptions),
+		strconv.Itoa(s.EventsReceived),
+		strconv.Itoa(s.SubscriptionEvents),
+		strconv.Itoa(s.ConfirmationEvents),
+		strconv.Itoa(s.ErrorEvents),
+		formatFloat(snap.OverallRate()),
+		strconv.Itoa(len(snap.Outages)),
+		formatFloat(snap.TotalDowntime().Seconds()),
+		strconv.Itoa(snap.Ping.Sent),
+		strconv.Itoa(snap.Ping.Received),
+		strconv.Itoa(snap.Ping.Timeouts),
+		formatFloat(milliseconds(snap.Ping.LastRTT)),
+		strconv.Itoa(snap.StallCount()),
+		strconv.Itoa(len(snap.ActiveStalls)),
+		strconv.Itoa(snap.FailureCount()),
+		strconv.Itoa(snap.HandshakeAttempts()),
+		formatFloat(milliseconds(handshake.Percentile(50))),
+		formatFloat(milliseconds(handshake.Percentile(99))),
+		strconv.FormatInt(wire.WireBytes, 10),
+		strconv.FormatInt(wire.Payload.TotalBytes, 10),
+		strconv.FormatInt(s.BytesReceived, 10),
+		strconv.FormatInt(s.BytesSent, 10),
+		formatFloat(snap.ReceiveRate()),
+		formatFloat(snap.SendRate()),
+	}
+	for _, rate := range snap.EventRates() {
+		row = append(row, formatFloat(rate))
+	}
+	pingRTT := snap.Latency(types.LatencyPingRTT)
+	row = append(row,
+		formatFloat(milliseconds(snap.Latency(types.LatencySubscribeConfirm).Percentile(99))),
+		formatFloat(milliseconds(pingRTT.Percentile(50))),
+		formatFloat(milliseconds(pingRTT.Percentile(99))),
+		strconv.Itoa(snap.Calls.Sent),
+		formatFloat(milliseconds(snap.Latency(types.LatencyCallRTT).Percentile(99))),
+		formatFloat(milliseconds(snap.Latency(types.LatencyCallRTTCorrected).Percentile(99))))
+	var gaps int
+	var missed uint64
+	for _, sequence := range snap.Sequences {
+		gaps += sequence.Gaps
+		missed += sequence.Missed
+	}
+	row = append(row, strconv.Itoa(gaps), strconv.FormatUint(missed, 10), strconv.FormatUint(snap.Finality.LastLag, 10))
+	var operationErrors int
+	for _, operation := range snap.Operations {
+		operationErrors += operation.Errors
+	}
+	row = append(row, strconv.Itoa(operationErrors))
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+
+	// Flush every row so the file can be tailed while the test runs
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatFloat renders a float with millisecond-level precision
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestCSV_WritesTimeSeries(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewCSV(&buf)
+	snap := testSnapshot(t)
+
+	if err := reporter.Update(snap); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if err := reporter.Update(snap); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if err := reporter.Final(snap); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want header + 3 rows", len(records))
+	}
+	if records[0][0] != "timestamp" || len(records[0]) != len(csvHeader) {
+		t.Errorf("header = %v", records[0])
+	}
+
+	row := make(map[string]string)
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	if row["connected"] != "true" || row["connections"] != "2" || row["events"] != "2" || row["outages"] != "1" || row["last_rtt_ms"] != "2.000" || row["stalls"] != "2" || row["active_stalls"] != "1" || row["failures"] != "1" || row["handshakes"] != "2" || row["handshake_p99_ms"] != "3.000" ||
+		row["wire_bytes"] != "290" || row["payload_bytes"] != "1040" ||
+		row["bytes_received"] != "2048" || row["bytes_sent"] != "512" || row["events_per_second_10s"] != "0.000" ||
+		row["subscribe_confirm_p99_ms"] != "5.000" || row["ping_rtt_p50_ms"] != "2.000" || row["ping_rtt_p99_ms"] != "2.000" ||
+		row["calls_sent"] != "2" || row["call_rtt_p99_ms"] != "4.000" || row["call_rtt_corrected_p99_ms"] != "10.000" ||
+		row["sequence_gaps"] != "1" || row["sequence_missed"] != "2" || row["finality_lag_blocks"] != "4" ||
+		row["operation_errors"] != "1" {
+		t.Errorf("row = %v", row)
+	}
+}
@@ -0,0 +1,711 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/terminal"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+var spinnerChars = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// DashboardReporter renders the colorful live terminal dashboard
+type DashboardReporter struct {
+	w    io.Writer
+	opts Options
+
+	rendered     bool
+	spinnerIndex int
+
+	// The screen is fully cleared whenever a connection opens or closes
+	lastConnections int
+	lastClosed      int
+}
+
+// NewDashboard creates a dashboard reporter writing to w
+func NewDashboard(w io.Writer, opts Options) *DashboardReporter {
+	return &DashboardReporter{w: w, opts: opts}
+}
+
+// Update redraws the dashboard in place
+func (d *DashboardReporter) Update(snap stats.Snapshot) error {
+	s := snap.Stats
+	w := d.w
+
+	// Nothing to show until the first connection has been made
+	if s.TotalConnections == 0 {
+		return nil
+	}
+
+	terminalWidth := terminal.GetTerminalWidth()
+
+	if !d.rendered || s.TotalConnections != d.lastConnections || len(snap.ConnectionHistory) != d.lastClosed {
+		fmt.Fprint(w, "\033[2J\033[H")
+	} else {
+		fmt.Fprint(w, "\033[H\033[0J")
+	}
+	d.rendered = true
+	d.lastConnections = s.TotalConnections
+	d.lastClosed = len(snap.ConnectionHistory)
+
+	// Grove Portal Header at the very top
+	separatorWidth := terminalWidth
+	if separatorWidth > 100 {
+		separatorWidth = 100
+	}
+	if separatorWidth < 20 {
+		separatorWidth = 20
+	}
+
+	fmt.Fprintln(w, strings.Repeat("═", separatorWidth))
+	terminal.Green.Fprintln(w, "🌿 GROVE PORTAL - LIVE MESSAGE FEED")
+	fmt.Fprintln(w, strings.Repeat("═", separatorWidth))
+
+	// Show connection information if config is available and logging is enabled
+	if config := d.opts.Config; d.opts.ShowMessages && config != nil {
+		terminal.Cyan.Fprintf(w, "🌿 Service: %s\n", config.ServiceID)
+		terminal.Cyan.Fprintf(w, "🔗 Connected to: %s\n", config.URL)
+
+		// Extract app ID from URL for display
+		if strings.Contains(config.URL, "/v1/") {
+			parts := strings.Split(config.URL, "/v1/")
+			if len(parts) > 1 && parts[1] != "" {
+				terminal.Cyan.Fprintf(w, "🆔 Portal App ID: %s\n", parts[1])
+			}
+		}
+		fmt.Fprintln(w, strings.Repeat("═", separatorWidth))
+	}
+
+	// Update spinner
+	d.spinnerIndex = (d.spinnerIndex + 1) % len(spinnerChars)
+
+	// Header with spinner
+	headerText := fmt.Sprintf("%s WebSocket Client Dashboard - Live Stats", spinnerChars[d.spinnerIndex])
+	if len(headerText) > terminalWidth {
+		headerText = headerText[:terminalWidth-3] + "..."
+	}
+	terminal.Green.Fprintln(w, headerText)
+
+	// Separator line
+	fmt.Fprintln(w, strings.Repeat("═", separatorWidth))
+
+	// Connection Stats
+	terminal.Cyan.Fprintln(w, "📡 CONNECTION METRICS")
+	fmt.Fprintf(w, "🔗 Total Connections:     %s%d%s\n", terminal.Green.Sprint(""), s.TotalConnections, "")
+	fmt.Fprintf(w, "🔄 Reconnections:         %s%d%s\n", terminal.Yellow.Sprint(""), s.TotalReconnections, "")
+	fmt.Fprintf(w, "🎯 Connection Attempts:   %s%d%s\n", terminal.Blue.Sprint(""), s.ConnectionAttempts, "")
+	fmt.Fprintf(w, "⏱️  Current Conn Duration: %s%v%s\n", terminal.Green.Sprint(""), snap.CurrentConnDuration().Round(time.Second), "")
+	if session := snap.CurrentTLSSession(); session != "" {
+		fmt.Fprintf(w, "🔒 TLS Session:           %s%s%s\n", terminal.Green.Sprint(""), session, "")
+	}
+	fmt.Fprintf(w, "🏃 Total Runtime:         %s%v%s\n", terminal.Cyan.Sprint(""), snap.Runtime().Round(time.Second), "")
+
+	// Show average connection duration
+	if len(snap.ConnectionHistory) > 0 {
+		fmt.Fprintf(w, "📊 Avg Connection Time:   %s%v%s\n", terminal.Blue.Sprint(""), snap.AverageClosedConnection().Round(time.Second), "")
+	}
+
+	// Outage and recovery stats
+	if len(snap.Outages) > 0 {
+		fmt.Fprintf(w, "🩹 Outages / MTTR:        %s%d%s / %v\n", terminal.Yellow.Sprint(""), len(snap.Outages), "", snap.MeanTimeToRecovery().Round(time.Millisecond))
+	}
+	for _, outage := range snap.CurrentOutages {
+		fmt.Fprintf(w, "🔌 Disconnected For:      %s%v%s (%d attempts)\n", terminal.Red.Sprint(""), snap.Time.Sub(outage.StartTime).Round(time.Second), "", outage.Attempts)
+	}
+
+	// Heartbeat stats
+	printHeartbeat(w, snap, "💓 HEARTBEAT")
+
+	// Workload calls
+	printCalls(w, snap, "📞 CALLS")
+
+	// Gaps in numbered notifications
+	printSequences(w, snap, "🔢 SEQUENCE")
+
+	// Finalized head lag
+	printFinality(w, snap, "⛓️  FINALITY")
+
+	// GraphQL operation outcomes
+	printOperations(w, snap, "🧬 OPERATIONS")
+
+	// Failures by class
+	printFailures(w, snap, "⚠️  FAILURES")
+
+	// Handshake phase timings
+	printHandshakes(w, snap, "🤝 HANDSHAKE TIMING")
+
+	// Latency percentiles
+	printLatencies(w, snap, "📐 LATENCY")
+
+	// Headers of the latest upgrade response
+	d.printResponseHeaders(snap, "📨 RESPONSE HEADERS", 1)
+
+	// Subscription Stats
+	fmt.Fprintln(w)
+	terminal.Magenta.Fprintln(w, "📡 SUBSCRIPTION METRICS")
+	fmt.Fprintf(w, "📊 Active Subscriptions:  %s%d%s\n", terminal.Magenta.Sprint(""), s.ActiveSubscriptions, "")
+	fmt.Fprintf(w, "🆕 Ever Created:          %s%d%s (%d requested)\n", terminal.Blue.Sprint(""), s.SubscriptionsCreated, "", s.SubscriptionRequests)
+	fmt.Fprintf(w, "🔁 Resubscriptions:       %s%d%s\n", terminal.Yellow.Sprint(""), s.Resubscriptions, "")
+	fmt.Fprintf(w, "✅ Confirmations:         %s%d%s\n", terminal.Green.Sprint(""), s.ConfirmationEvents, "")
+	fmt.Fprintf(w, "🧊 Subscription Events:   %s%d%s\n", terminal.Cyan.Sprint(""), s.SubscriptionEvents, "")
+	fmt.Fprintf(w, "❌ Error Events:          %s%d%s\n", terminal.Red.Sprint(""), s.ErrorEvents, "")
+
+	// Show messages by subscription type
+	if len(snap.MessagesByType) > 0 {
+		fmt.Fprintln(w)
+		terminal.Blue.Fprintln(w, "📊 MESSAGES BY TYPE")
+		for _, subType := range sortedKeys(snap.MessagesByType) {
+			emoji := terminal.GetSubscriptionEmoji(subType)
+			fmt.Fprintf(w, "%s %s: %s%d%s msgs (%s, avg %.2f/s)", emoji, subType, terminal.Cyan.Sprint(""), snap.MessagesByType[subType], "",
+				formatRates(snap.TypeRates(subType)), snap.TypeRate(subType))
+			if stall, ok := activeStall(snap, subType); ok {
+				terminal.Red.Fprintf(w, " 🚨 STALLED for %v", snap.Time.Sub(stall.StartTime).Round(time.Second))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	// Stalled subscriptions
+	printStalls(w, snap, "🚨 STALLS", false)
+
+	// Full transaction payload stats
+	printTransactionStats(w, snap, "📦 FULL TRANSACTION PAYLOADS")
+
+	// Bytes received and sent, by message type
+	printThroughput(w, snap, "📶 THROUGHPUT")
+
+	// Wire bytes against decompressed payload bytes
+	printWireStats(w, snap, "🗜️  WIRE BYTES")
+
+	// Message Stats
+	fmt.Fprintln(w)
+	terminal.Blue.Fprintln(w, "📨 MESSAGE METRICS")
+	fmt.Fprintf(w, "📈 Total Messages:        %s%d%s\n", terminal.Blue.Sprint(""), s.EventsReceived, "")
+	fmt.Fprintf(w, "📨 Current Conn Messages: %s%d%s\n", terminal.Cyan.Sprint(""), s.CurrentConnMessages, "")
+	fmt.Fprintf(w, "⚡ Messages/Second:       %s%.2f%s\n", terminal.Yellow.Sprint(""), snap.CurrentConnRate(), "")
+	fmt.Fprintf(w, "🌊 Recent Rate:           %s%s%s\n", terminal.Green.Sprint(""), formatRates(snap.EventRates()), "")
+	fmt.Fprintf(w, "📊 Overall Rate:          %s%.2f%s/sec\n", terminal.Cyan.Sprint(""), snap.OverallRate(), "")
+	fmt.Fprintf(w, "⏰ Last Event:            %s%v%s ago\n", terminal.Green.Sprint(""), snap.SinceLastEvent().Round(time.Second), "")
+
+	// Performance Stats
+	fmt.Fprintln(w)
+	terminal.Yellow.Fprintln(w, "⚡ PERFORMANCE METRICS")
+
+	if s.EventsReceived > 0 {
+		fmt.Fprintf(w, "✅ Success Rate:          %s%.1f%%%s\n", terminal.Green.Sprint(""), snap.SuccessRate(), "")
+	}
+	if s.SubscriptionsCreated > 0 {
+		fmt.Fprintf(w, "📊 Events/Subscription:   %s%.1f%s\n", terminal.Cyan.Sprint(""), snap.EventsPerSubscription(), "")
+	}
+
+	// Connection duration metrics
+	if s.LongestConnection > 0 {
+		fmt.Fprintf(w, "🏆 Longest Connection:    %s%v%s\n", terminal.Green.Sprint(""), s.LongestConnection.Round(time.Second), "")
+	}
+	if s.ShortestConnection > 0 {
+		fmt.Fprintf(w, "⚡ Shortest Connection:   %s%v%s\n", terminal.Yellow.Sprint(""), s.ShortestConnection.Round(time.Second), "")
+	}
+
+	// Per-connection table
+	d.printConnectionTable(snap, "🧮 CONNECTIONS", maxConnectionRows)
+
+	// Connection History Section
+	if len(snap.ConnectionHistory) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w)
+		terminal.Yellow.Fprintln(w, "📋 CONNECTION HISTORY")
+
+		// Show last 5 connections
+		start := 0
+		if len(snap.ConnectionHistory) > 5 {
+			start = len(snap.ConnectionHistory) - 5
+		}
+
+		for i := start; i < len(snap.ConnectionHistory); i++ {
+			conn := snap.ConnectionHistory[i]
+			if len(snap.Connections) > 1 {
+				fmt.Fprintf(w, "[slot %d] ", conn.Slot)
+			}
+			fmt.Fprintf(w, "🔗 Connection #%s%d%s: %s%d%s msgs, %s received in %s%v%s (%s to %s)",
+				terminal.Green.Sprint(""), conn.ConnectionNum, "",
+				terminal.Cyan.Sprint(""), conn.Messages, "",
+				terminal.FormatBytes(conn.BytesReceived),
+				terminal.Blue.Sprint(""), conn.Duration.Round(time.Second), "",
+				conn.StartTime.Format("15:04:05"),
+				conn.EndTime.Format("15:04:05"))
+			if conn.Reason != "" {
+				fmt.Fprintf(w, " - %s", conn.Reason)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	// Footer
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("═", separatorWidth))
+	fmt.Fprintf(w, "🕐 Last Updated: %s\n", snap.Time.Format("15:04:05"))
+
+	// Show latest messages by subscription type if logging is enabled
+	if d.opts.ShowMessages && len(snap.LatestMessages) > 0 {
+		d.printLatestMessages(snap)
+	}
+	return nil
+}
+
+// printLatestMessages prints the latest message of each subscription type as JSON
+func (d *DashboardReporter) printLatestMessages(snap stats.Snapshot) {
+	w := d.w
+
+	fmt.Fprintln(w)
+	terminal.Yellow.Fprintln(w, "📝 LATEST MESSAGES BY TYPE")
+
+	// Display messages grouped by subscription type, sorted for consistent display
+	for _, subType := range sortedKeys(snap.LatestMessages) {
+		msg := snap.LatestMessages[subType]
+
+		emoji := terminal.GetSubscriptionEmoji(subType)
+		if subType == "confirmations" {
+			emoji = "✅"
+		} else if subType == "errors" {
+			emoji = "❌"
+		}
+
+		fmt.Fprintln(w)
+		terminal.Cyan.Fprintf(w, "%s %s - Received at %s:\n",
+			emoji, subType, msg.ReceivedAt.Format("15:04:05"))
+
+		// Format the JSON nicely
+		jsonBytes, err := json.MarshalIndent(msg.Content, "", "  ")
+		if err != nil {
+			terminal.Red.Fprintf(w, "Error formatting JSON: %v\n", err)
+		} else {
+			fmt.Fprintln(w, string(jsonBytes))
+		}
+	}
+}
+
+// Final clears the dashboard and prints the session summary
+func (d *DashboardReporter) Final(snap stats.Snapshot) error {
+	s := snap.Stats
+	w := d.w
+
+	// Clear screen and show final summary
+	fmt.Fprint(w, "\033[2J\033[H")
+
+	terminal.Cyan.Fprintln(w, "🏁 FINAL SESSION SUMMARY")
+	fmt.Fprintln(w, strings.Repeat("═", 60))
+
+	// Connection Summary
+	terminal.Cyan.Fprintln(w, "📡 CONNECTION SUMMARY")
+	fmt.Fprintf(w, "🔗 Total Connections:     %s%d%s\n", terminal.Green.Sprint(""), s.TotalConnections, "")
+	fmt.Fprintf(w, "🔄 Total Reconnections:   %s%d%s\n", terminal.Yellow.Sprint(""), s.TotalReconnections, "")
+	fmt.Fprintf(w, "🎯 Connection Attempts:   %s%d%s\n", terminal.Blue.Sprint(""), s.ConnectionAttempts, "")
+	fmt.Fprintf(w, "📡 Subscriptions Created: %s%d%s (%d requested)\n", terminal.Magenta.Sprint(""), s.SubscriptionsCreated, "", s.SubscriptionRequests)
+	fmt.Fprintf(w, "🔁 Resubscriptions:       %s%d%s\n", terminal.Yellow.Sprint(""), s.Resubscriptions, "")
+	fmt.Fprintf(w, "📊 Active At Exit:        %s%d%s\n", terminal.Blue.Sprint(""), s.ActiveSubscriptions, "")
+	fmt.Fprintf(w, "⏱️  Total Uptime:         %s%v%s\n", terminal.Green.Sprint(""), s.TotalUptime.Round(time.Second), "")
+	fmt.Fprintf(w, "🏃 Total Runtime:         %s%v%s\n", terminal.Cyan.Sprint(""), snap.Runtime().Round(time.Second), "")
+
+	// Per-connection table
+	d.printConnectionTable(snap, "🧮 CONNECTION TABLE", 0)
+
+	// Recovery Summary
+	printRecoverySummary(w, snap)
+
+	// Heartbeat Summary
+	printHeartbeat(w, snap, "💓 HEARTBEAT SUMMARY")
+
+	// Call Summary
+	printCalls(w, snap, "📞 CALL SUMMARY")
+
+	// Sequence Summary
+	printSequences(w, snap, "🔢 SEQUENCE SUMMARY")
+
+	// Finality Summary
+	printFinality(w, snap, "⛓️  FINALITY SUMMARY")
+
+	// Operation Summary
+	printOperations(w, snap, "🧬 OPERATION SUMMARY")
+
+	// Failure Summary
+	printFailures(w, snap, "⚠️  FAILURE SUMMARY")
+
+	// Handshake Summary
+	printHandshakes(w, snap, "🤝 HANDSHAKE SUMMARY")
+
+	// Latency Summary
+	printLatencies(w, snap, "📐 LATENCY SUMMARY")
+
+	// TLS Summary
+	printTLSSessions(w, snap)
+
+	// Response Header Summary
+	d.printResponseHeaders(snap, "📨 RESPONSE HEADER SUMMARY", maxUpgradeResponses)
+
+	// Stall Summary
+	printStalls(w, snap, "🚨 STALL SUMMARY", true)
+
+	// Message Summary
+	fmt.Fprintln(w)
+	terminal.Blue.Fprintln(w, "📨 MESSAGE SUMMARY")
+	fmt.Fprintf(w, "📈 Total Messages:        %s%d%s\n", terminal.Blue.Sprint(""), s.EventsReceived, "")
+	fmt.Fprintf(w, "🧊 Subscription Events:   %s%d%s\n", terminal.Cyan.Sprint(""), s.SubscriptionEvents, "")
+	fmt.Fprintf(w, "✅ Confirmations:         %s%d%s\n", terminal.Green.Sprint(""), s.ConfirmationEvents, "")
+	fmt.Fprintf(w, "❌ Error Events:          %s%d%s\n", terminal.Red.Sprint(""), s.ErrorEvents, "")
+
+	// Full transaction payload summary
+	printTransactionStats(w, snap, "📦 FULL TRANSACTION PAYLOAD SUMMARY")
+
+	// Throughput Summary
+	printThroughput(w, snap, "📶 THROUGHPUT SUMMARY")
+
+	// Wire byte summary
+	printWireStats(w, snap, "🗜️  WIRE BYTES SUMMARY")
+
+	// Performance Summary
+	fmt.Fprintln(w)
+	terminal.Yellow.Fprintln(w, "⚡ PERFORMANCE SUMMARY")
+
+	if s.EventsReceived > 0 && s.TotalUptime > 0 {
+		fmt.Fprintf(w, "📈 Connection Event Rate: %s%.2f%s events/sec\n", terminal.Yellow.Sprint(""), snap.ConnectionEventRate(), "")
+	}
+	if s.EventsReceived > 0 && snap.Runtime() > 0 {
+		fmt.Fprintf(w, "📊 Overall Event Rate:    %s%.2f%s events/sec\n", terminal.Cyan.Sprint(""), snap.OverallRate(), "")
+	}
+	if snap.Runtime() > 0 {
+		fmt.Fprintf(w, "📡 Connection Reliability: %s%.1f%%%s\n", terminal.Green.Sprint(""), snap.Reliability(), "")
+	}
+	if s.EventsReceived > 0 {
+		fmt.Fprintf(w, "✅ Success Rate:          %s%.1f%%%s\n", terminal.Green.Sprint(""), snap.SuccessRate(), "")
+	}
+	if s.TotalConnections > 1 {
+		fmt.Fprintf(w, "⏳ Avg Connection Time:   %s%v%s\n", terminal.Blue.Sprint(""), snap.AverageUptimePerConnection().Round(time.Second), "")
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("═", 60))
+	terminal.Green.Fprintln(w, "👋 Session Complete - Thanks for using WebSocket Client!")
+	return nil
+}
+
+// printRecoverySummary prints outage counts, downtime and mean time to recovery
+func printRecoverySummary(w io.Writer, snap stats.Snapshot) {
+	if len(snap.Outages) == 0 && len(snap.CurrentOutages) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Yellow.Fprintln(w, "🩹 RECOVERY SUMMARY")
+	fmt.Fprintf(w, "🔌 Outages:               %s%d%s\n", terminal.Yellow.Sprint(""), len(snap.Outages), "")
+	fmt.Fprintf(w, "⏳ Total Downtime:        %s%v%s\n", terminal.Red.Sprint(""), snap.TotalDowntime().Round(time.Millisecond), "")
+	if len(snap.Outages) > 0 {
+		fmt.Fprintf(w, "🩹 Mean Time To Recovery: %s%v%s\n", terminal.Green.Sprint(""), snap.MeanTimeToRecovery().Round(time.Millisecond), "")
+		fmt.Fprintf(w, "🐢 Longest Outage:        %s%v%s\n", terminal.Yellow.Sprint(""), snap.LongestOutage().Round(time.Millisecond), "")
+	}
+	for _, outage := range snap.CurrentOutages {
+		fmt.Fprintf(w, "🚫 Unresolved Outage:     %s%v%s (%d attempts)\n", terminal.Red.Sprint(""),
+			snap.Time.Sub(outage.StartTime).Round(time.Millisecond), "", outage.Attempts)
+	}
+}
+
+// printHeartbeat prints ping/pong counts and round-trip times
+func printHeartbeat(w io.Writer, snap stats.Snapshot, title string) {
+	ping := snap.Ping
+	if ping.Sent == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Red.Fprintln(w, title)
+	fmt.Fprintf(w, "💓 Pings / Pongs:         %s%d%s / %d\n", terminal.Green.Sprint(""), ping.Sent, "", ping.Received)
+	if ping.Received > 0 {
+		fmt.Fprintf(w, "⏱️  Ping RTT:              %s%v%s last, %v avg, %v min, %v max\n", terminal.Cyan.Sprint(""),
+			ping.LastRTT.Round(time.Microsecond), "",
+			ping.AverageRTT().Round(time.Microsecond),
+			ping.MinRTT.Round(time.Microsecond),
+			ping.MaxRTT.Round(time.Microsecond))
+	}
+	fmt.Fprintf(w, "💔 Pong Timeouts:         %s%d%s\n", terminal.Red.Sprint(""), ping.Timeouts, "")
+}
+
+// printFailures prints dial and connection failures by class and the most recent failure
+func printFailures(w io.Writer, snap stats.Snapshot, title string) {
+	if snap.FailureCount() == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Red.Fprintln(w, title)
+	for _, class := range sortedKeys(snap.Failures) {
+		fmt.Fprintf(w, "❌ %-26s %s%d%s\n", string(class)+":", terminal.Red.Sprint(""), snap.Failures[class], "")
+	}
+	last := snap.LastFailure
+	fmt.Fprintf(w, "🕐 Last Failure:          %s%s%s (%v ago)\n", terminal.Yellow.Sprint(""), last.Reason, "",
+		snap.Time.Sub(last.Time).Round(time.Second))
+}
+
+// printHandshakes prints the distribution of each handshake phase across all connection attempts
+func printHandshakes(w io.Writer, snap stats.Snapshot, title string) {
+	if len(snap.Handshakes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Blue.Fprintln(w, title)
+	for _, phase := range types.HandshakePhases {
+		latency := snap.HandshakeLatency(phase)
+		if latency.Count() == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "⏱️  %-22s %s%s%s (%d)\n", phase+":", terminal.Cyan.Sprint(""), formatLatency(latency), "", latency.Count())
+	}
+}
+
+// printCalls prints how the workload calls of every connection were answered
+func printCalls(w io.Writer, snap stats.Snapshot, title string) {
+	calls := snap.Calls
+	if calls.Sent == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Magenta.Fprintln(w, title)
+	fmt.Fprintf(w, "📞 Calls Sent:            %s%d%s (%.2f/s)\n", terminal.Green.Sprint(""), calls.Sent, "", snap.CallRate())
+	fmt.Fprintf(w, "✅ Answered / Errors:     %d / %s%d%s\n", calls.Answered, terminal.Red.Sprint(""), calls.Errors, "")
+	fmt.Fprintf(w, "⌛ Unanswered:            %d\n", calls.Unanswered)
+	if calls.Missed > 0 {
+		fmt.Fprintf(w, "⏭️  Missed:               %s%d%s (due while disconnected)\n", terminal.Yellow.Sprint(""), calls.Missed, "")
+	}
+}
+
+// printSequences prints the gaps found in every numbered notification stream,
+// such as Solana slots
+func printSequences(w io.Writer, snap stats.Snapshot, title string) {
+	if len(snap.Sequences) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Cyan.Fprintln(w, title)
+	for _, subType := range sortedKeys(snap.Sequences) {
+		sequence := snap.Sequences[subType]
+		fmt.Fprintf(w, "%s %s: highest %d, %s%d gaps%s (%d missed), %d repeated (%d received)\n",
+			terminal.GetSubscriptionEmoji(subType), subType, sequence.Highest,
+			terminal.Yellow.Sprint(""), sequence.Gaps, "", sequence.Missed, sequence.Repeated, sequence.Received)
+	}
+}
+
+// printFinality prints the best and finalized heads and how far finality
+// trails, for protocols that report both
+func printFinality(w io.Writer, snap stats.Snapshot, title string) {
+	finality := snap.Finality
+	if finality.BestHead == 0 && finality.Finalized == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Cyan.Fprintln(w, title)
+	fmt.Fprintf(w, "🧊 Best Head:             #%d\n", finality.BestHead)
+	fmt.Fprintf(w, "🔒 Finalized Head:        #%d\n", finality.Finalized)
+	if finality.Samples > 0 {
+		fmt.Fprintf(w, "⏳ Finality Lag:          %s%d blocks%s (avg %.1f, max %d)\n",
+			terminal.Yellow.Sprint(""), finality.LastLag, "", finality.MeanLag(), finality.MaxLag)
+	}
+}
+
+// printOperations prints the results, errors and completions of every
+// GraphQL operation type
+func printOperations(w io.Writer, snap stats.Snapshot, title string) {
+	if len(snap.Operations) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Cyan.Fprintln(w, title)
+	for _, subType := range sortedKeys(snap.Operations) {
+		operation := snap.Operations[subType]
+		fmt.Fprintf(w, "%s %s: %d results, %s%d errors%s, %d completed\n",
+			terminal.GetSubscriptionEmoji(subType), subType, operation.Results,
+			terminal.Red.Sprint(""), operation.Errors, "", operation.Completed)
+	}
+}
+
+// printLatencies prints the distribution of every other latency recorded across all connections
+func printLatencies(w io.Writer, snap stats.Snapshot, title string) {
+	printed := false
+	for _, name := range types.Latencies {
+		latency := snap.Latency(name)
+		if latency.Count() == 0 {
+			continue
+		}
+		if !printed {
+			fmt.Fprintln(w)
+			terminal.Blue.Fprintln(w, title)
+			printed = true
+		}
+		fmt.Fprintf(w, "📐 %-23s %s%s%s (%d)\n", name+":", terminal.Cyan.Sprint(""), formatLatency(latency), "", latency.Count())
+	}
+}
+
+// printTLSSessions prints how many connections negotiated each TLS version and cipher suite
+func printTLSSessions(w io.Writer, snap stats.Snapshot) {
+	sessions := snap.TLSSessions()
+	if len(sessions) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Green.Fprintln(w, "🔒 TLS SESSION SUMMARY")
+	for _, session := range sortedKeys(sessions) {
+		fmt.Fprintf(w, "🔒 %s: %s%d%s\n", session, terminal.Green.Sprint(""), sessions[session], "")
+	}
+}
+
+// printResponseHeaders prints the selected headers of the most recent upgrade responses
+func (d *DashboardReporter) printResponseHeaders(snap stats.Snapshot, title string, limit int) {
+	if len(d.opts.Headers) == 0 || len(snap.UpgradeResponses) == 0 {
+		return
+	}
+
+	w := d.w
+	fmt.Fprintln(w)
+	terminal.Cyan.Fprintln(w, title)
+	for _, response := range snap.UpgradeResponses[max(len(snap.UpgradeResponses)-limit, 0):] {
+		fmt.Fprintf(w, "📨 %s %s%s%s: %s\n", response.Time.Format("15:04:05"), terminal.Blue.Sprint(""), responseLabel(response), "",
+			formatHeaders(response, d.opts.Headers))
+	}
+}
+
+// maxStallPeriods limits how many stall periods the final summary lists
+const maxStallPeriods = 10
+
+// printStalls prints stall counts and, when periods is set, the most recent stall periods
+func printStalls(w io.Writer, snap stats.Snapshot, title string, periods bool) {
+	if snap.StallCount() == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Red.Fprintln(w, title)
+	fmt.Fprintf(w, "🚨 Stalls Detected:       %s%d%s\n", terminal.Red.Sprint(""), snap.StallCount(), "")
+	fmt.Fprintf(w, "⏸️  Time Stalled:          %s%v%s\n", terminal.Yellow.Sprint(""), snap.StalledTime().Round(time.Second), "")
+	byType := snap.StallsByType()
+	for _, subType := range sortedKeys(byType) {
+		fmt.Fprintf(w, "%s %s: %s%d%s stalls\n", terminal.GetSubscriptionEmoji(subType), subType, terminal.Red.Sprint(""), byType[subType], "")
+	}
+	for _, stall := range snap.ActiveStalls {
+		terminal.Red.Fprintf(w, "⚠️  %s silent for %v on connection #%d (expected every %v)\n", stall.SubscriptionType,
+			snap.Time.Sub(stall.StartTime).Round(time.Second), stall.ConnectionNum, stall.Threshold)
+	}
+
+	if !periods || len(snap.Stalls) == 0 {
+		return
+	}
+	start := max(0, len(snap.Stalls)-maxStallPeriods)
+	for _, stall := range snap.Stalls[start:] {
+		fmt.Fprintf(w, "⏸️  %s on connection #%d: %v (%s to %s) - %s\n", stall.SubscriptionType, stall.ConnectionNum,
+			stall.Duration.Round(time.Second), stall.StartTime.Format("15:04:05"), stall.EndTime.Format("15:04:05"), stall.Resolution)
+	}
+}
+
+// printTransactionStats prints the full transaction payload section
+func printTransactionStats(w io.Writer, snap stats.Snapshot, title string) {
+	if len(snap.TransactionStats) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Magenta.Fprintln(w, title)
+
+	for _, subType := range sortedKeys(snap.TransactionStats) {
+		txStats := snap.TransactionStats[subType]
+		payload := txStats.Payload
+
+		emoji := terminal.GetSubscriptionEmoji(subType)
+		fmt.Fprintf(w, "%s %s: %s%d%s txs (%d decoded, %d failed, %d hash-only)\n",
+			emoji, subType, terminal.Cyan.Sprint(""), payload.Count, "",
+			txStats.Decoded, txStats.DecodeErrors, txStats.HashOnly)
+		fmt.Fprintf(w, "📦 Bytes Received:        %s%s%s\n", terminal.Blue.Sprint(""), terminal.FormatBytes(payload.TotalBytes), "")
+		fmt.Fprintf(w, "🚚 Throughput:            %s%s/sec%s\n", terminal.Yellow.Sprint(""), terminal.FormatBytes(int64(stats.PayloadBytesPerSecond(payload, snap.Time))), "")
+		fmt.Fprintf(w, "📏 Payload Size:          avg %s, min %s, max %s\n",
+			terminal.FormatBytes(int64(stats.AveragePayloadSize(payload))),
+			terminal.FormatBytes(int64(payload.MinBytes)),
+			terminal.FormatBytes(int64(payload.MaxBytes)))
+		fmt.Fprintf(w, "📊 Size Distribution:     %s\n", formatSizeDistribution(payload))
+	}
+}
+
+// printWireStats prints the bytes each message type took on the wire against
+// its decompressed payloads
+func printWireStats(w io.Writer, snap stats.Snapshot, title string) {
+	if len(snap.WireStats) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Blue.Fprintln(w, title)
+	fmt.Fprintf(w, "🗜️  Compression:           %s%s%s\n", terminal.Cyan.Sprint(""), compressionLabel(snap), "")
+	total := snap.TotalWireStats()
+	fmt.Fprintf(w, "📦 Total:                 %s%s%s on the wire, %s payload (%.1f%% saved)\n", terminal.Blue.Sprint(""),
+		terminal.FormatBytes(total.WireBytes), "", terminal.FormatBytes(total.Payload.TotalBytes), total.SavingPercent())
+	for _, messageType := range sortedKeys(snap.WireStats) {
+		wire := snap.WireStats[messageType]
+		fmt.Fprintf(w, "%s %s: %s%s%s of %s (%.1f%% saved), %v read/msg (%d msgs)\n", terminal.GetSubscriptionEmoji(messageType), messageType,
+			terminal.Cyan.Sprint(""), terminal.FormatBytes(wire.WireBytes), "", terminal.FormatBytes(wire.Payload.TotalBytes),
+			wire.SavingPercent(), wire.ReadTimePerMessage().Round(time.Microsecond), wire.Payload.Count)
+	}
+}
+
+// printThroughput prints the bytes received and sent and, per message type,
+// the payload throughput and size distribution
+func printThroughput(w io.Writer, snap stats.Snapshot, title string) {
+	s := snap.Stats
+	if s.BytesReceived == 0 && s.BytesSent == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	terminal.Blue.Fprintln(w, title)
+	fmt.Fprintf(w, "📥 Received:              %s%s%s (%s/sec)\n", terminal.Green.Sprint(""), terminal.FormatBytes(s.BytesReceived), "",
+		terminal.FormatBytes(int64(snap.ReceiveRate())))
+	fmt.Fprintf(w, "📤 Sent:                  %s%s%s (%s/sec)\n", terminal.Yellow.Sprint(""), terminal.FormatBytes(s.BytesSent), "",
+		terminal.FormatBytes(int64(snap.SendRate())))
+	for _, messageType := range sortedKeys(snap.WireStats) {
+		wire := snap.WireStats[messageType]
+		payload := wire.Payload
+		fmt.Fprintf(w, "%s %s: %s%s%s in %d msgs (%s/sec), avg %s, max %s, %d sent (%s)\n",
+			terminal.GetSubscriptionEmoji(messageType), messageType,
+			terminal.Cyan.Sprint(""), terminal.FormatBytes(payload.TotalBytes), "", payload.Count,
+			terminal.FormatBytes(int64(stats.PayloadBytesPerSecond(payload, snap.Time))),
+			terminal.FormatBytes(int64(stats.AveragePayloadSize(payload))),
+			terminal.FormatBytes(int64(payload.MaxBytes)),
+			wire.SentMessages, terminal.FormatBytes(wire.SentBytes))
+		fmt.Fprintf(w, "📊 Size Distribution:     %s\n", formatSizeDistribution(payload))
+	}
+}
+
+// maxConnectionRows limits the per-connection table of the live dashboard
+const maxConnectionRows = 10
+
+// printConnectionTable prints the state counts and the per-connection table,
+// limited to limit rows when positive
+func (d *DashboardReporter) printConnectionTable(snap stats.Snapshot, title string, limit int) {
+	w := d.w
+	if len(snap.Connections) == 0 {
+		return
+	}
+
+	by := d.opts.ConnectionSort
+	if by == "" {
+		by = SortIndex
+	}
+	fmt.Fprintln(w)
+	terminal.Cyan.Fprintf(w, "%s (sorted by %s)\n", title, by)
+	fmt.Fprintf(w, "🚦 States:                %s%s%s\n", terminal.Green.Sprint(""), formatConnectionStates(snap), "")
+	writeConnectionTable(w, snap, by, limit)
+}
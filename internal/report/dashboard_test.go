@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestDashboard_UpdateWaitsForFirstConnection(t *testing.T) {
+	var buf bytes.Buffer
+	dashboard := NewDashboard(&buf, Options{})
+
+	if err := dashboard.Update(stats.NewManager().Snapshot()); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("dashboard rendered %q before the first connection", buf.String())
+	}
+}
+
+func TestDashboard_Update(t *testing.T) {
+	var buf bytes.Buffer
+	config := &types.Config{ServiceID: "xrplevm", URL: "wss://xrplevm.rpc.grove.city/v1/app123"}
+	dashboard := NewDashboard(&buf, Options{Config: config, ShowMessages: true, Headers: testHeaders})
+	snap := testSnapshot(t)
+
+	if err := dashboard.Update(snap); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\033[2J\033[H") {
+		t.Error("first render should clear the whole screen")
+	}
+	for _, want := range []string{
+		"CONNECTION METRICS",
+		"Total Connections:     2",
+		"🆔 Portal App ID: app123",
+		"MESSAGES BY TYPE",
+		"newHeads: 1 msgs (10s 0.00/s, 1m 0.00/s, 5m 0.00/s, avg ",
+		"Recent Rate:           10s 0.00/s, 1m 0.00/s, 5m 0.00/s",
+		"FULL TRANSACTION PAYLOADS",
+		"CONNECTIONS (sorted by index)",
+		"States:                1 stalled",
+		"RECONNECTS",
+		"CONNECTION HISTORY",
+		" - closed",
+		"HEARTBEAT",
+		"🚨 STALLED for",
+		"FAILURES",
+		"http_5xx:",
+		"upgrade rejected: HTTP 503 Service Unavailable",
+		"Stalls Detected:       2",
+		"newHeads silent for",
+		"Pings / Pongs:         1 / 1",
+		"HANDSHAKE TIMING",
+		"TLS Session:           TLS 1.3 TLS_AES_128_GCM_SHA256",
+		"RESPONSE HEADERS",
+		"rejected HTTP 503: Retry-After=5",
+		"total:                 2ms p50, 3ms p90, 3ms p99, 3ms p99.9, 3ms max (2)",
+		"LATENCY",
+		"subscribe_confirm:      5ms p50, 5ms p90, 5ms p99, 5ms p99.9, 5ms max (1)",
+		"ping_rtt:               2ms p50, 2ms p90, 2ms p99, 2ms p99.9, 2ms max (1)",
+		"call_rtt_corrected:     10ms p50, 10ms p90, 10ms p99, 10ms p99.9, 10ms max (2)",
+		"Missed:               1 (due while disconnected)",
+		"finality_lag:",
+		"CALLS",
+		"Calls Sent:            2 (",
+		"Answered / Errors:     1 / 0",
+		"Unanswered:            1",
+		"SEQUENCE",
+		"slot: highest 104, 1 gaps (2 missed), 1 repeated (4 received)",
+		"FINALITY",
+		"Finalized Head:        #197",
+		"Finality Lag:          4 blocks (avg 3.5, max 4)",
+		"OPERATIONS",
+		"blocks: 2 results, 1 errors, 1 completed",
+		"THROUGHPUT",
+		"Received:              2.0 KB",
+		"Sent:                  512 B",
+		"2.0 KB received",
+		"WIRE BYTES",
+		"Compression:           permessage-deflate on 1 of 2 connections",
+		"newHeads: 250 B of 1000 B (75.0% saved), 1ms read/msg (1 msgs)",
+		"LATEST MESSAGES BY TYPE",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard output missing %q", want)
+		}
+	}
+
+	// Unchanged connections only move the cursor home
+	buf.Reset()
+	if err := dashboard.Update(snap); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\033[H\033[0J") {
+		t.Error("redraw without connection changes should not clear the whole screen")
+	}
+}
+
+func TestDashboard_Final(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewDashboard(&buf, Options{Headers: testHeaders}).Final(testSnapshot(t)); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"FINAL SESSION SUMMARY", "CONNECTION TABLE (sorted by index)", "RECOVERY SUMMARY", "HEARTBEAT SUMMARY", "CALL SUMMARY", "STALL SUMMARY", "FAILURE SUMMARY", "HANDSHAKE SUMMARY", "LATENCY SUMMARY", "TLS SESSION SUMMARY", "RESPONSE HEADER SUMMARY", "X-Request-Id=req-1", "connection closed", "FULL TRANSACTION PAYLOAD SUMMARY", "THROUGHPUT SUMMARY", "WIRE BYTES SUMMARY", "Session Complete"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("final summary missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/histogram"
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/terminal"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// formatSizeDistribution renders the payload size buckets as a single line
+func formatSizeDistribution(p types.PayloadStats) string {
+	if p.Count == 0 {
+		return "no payloads"
+	}
+
+	parts := make([]string, 0, len(p.SizeBuckets))
+	for i, count := range p.SizeBuckets {
+		parts = append(parts, fmt.Sprintf("%s: %d (%.0f%%)", sizeBucketLabel(i), count, float64(count)/float64(p.Count)*100))
+	}
+	return strings.Join(parts, " | ")
+}
+
+// sizeBucketLabel returns the human readable label of payload size bucket i
+func sizeBucketLabel(i int) string {
+	if i < len(types.PayloadSizeBuckets) {
+		return "<" + terminal.FormatBytes(int64(types.PayloadSizeBuckets[i]))
+	}
+	return "≥" + terminal.FormatBytes(int64(types.PayloadSizeBuckets[len(types.PayloadSizeBuckets)-1]))
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// activeStall returns the ongoing stall of a subscription type, if any
+func activeStall(snap stats.Snapshot, subType string) (types.Stall, bool) {
+	for _, stall := range snap.ActiveStalls {
+		if stall.SubscriptionType == subType {
+			return stall, true
+		}
+	}
+	return types.Stall{}, false
+}
+
+// maxUpgradeResponses limits how many upgrade responses the final summaries list
+const maxUpgradeResponses = 10
+
+// selectHeaders returns the named headers present in response
+func selectHeaders(response types.UpgradeResponse, names []string) map[string]string {
+	selected := make(map[string]string, len(names))
+	for _, name := range names {
+		if value, ok := response.Headers[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+// formatHeaders renders the named headers present in response as a single line
+func formatHeaders(response types.UpgradeResponse, names []string) string {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if value, ok := response.Headers[name]; ok {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	if len(parts) == 0 {
+		return "no selected headers"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// responseLabel describes which connection attempt an upgrade response belongs to
+func responseLabel(response types.UpgradeResponse) string {
+	if response.ConnectionNum == 0 {
+		return fmt.Sprintf("rejected HTTP %d", response.Status)
+	}
+	return fmt.Sprintf("connection #%d HTTP %d", response.ConnectionNum, response.Status)
+}
+
+// compressionLabel describes how many connections negotiated permessage-deflate
+func compressionLabel(snap stats.Snapshot) string {
+	compressed := snap.CompressedConnections()
+	if compressed == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("permessage-deflate on %d of %d connections", compressed, snap.Stats.TotalConnections)
+}
+
+// windowLabel names a rate window, e.g. "10s" or "5m"
+func windowLabel(window time.Duration) string {
+	if window%time.Minute == 0 {
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+	return fmt.Sprintf("%ds", window/time.Second)
+}
+
+// formatRates renders per-second rates over types.RateWindows as a single line
+func formatRates(rates []float64) string {
+	parts := make([]string, len(rates))
+	for i, rate := range rates {
+		parts[i] = fmt.Sprintf("%s %.2f/s", windowLabel(types.RateWindows[i]), rate)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// windowRates keys per-second rates over types.RateWindows by window label
+func windowRates(rates []float64) map[string]float64 {
+	labelled := make(map[string]float64, len(rates))
+	for i, rate := range rates {
+		labelled[windowLabel(types.RateWindows[i])] = rate
+	}
+	return labelled
+}
+
+// latencyPercentiles are the percentiles reported for every latency distribution
+var latencyPercentiles = []float64{50, 90, 99, 99.9}
+
+// percentileLabel names a percentile, e.g. "p50" or "p99.9"
+func percentileLabel(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// formatLatency renders the percentiles and maximum of a latency distribution as a single line
+func formatLatency(h *histogram.Histogram) string {
+	parts := make([]string, 0, len(latencyPercentiles)+1)
+	for _, p := range latencyPercentiles {
+		parts = append(parts, fmt.Sprintf("%v %s", h.Percentile(p).Round(time.Microsecond), percentileLabel(p)))
+	}
+	parts = append(parts, fmt.Sprintf("%v max", h.Max().Round(time.Microsecond)))
+	return strings.Join(parts, ", ")
+}
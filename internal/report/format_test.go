@@ -0,0 +1,43 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestFormatSizeDistribution(t *testing.T) {
+	empty := types.PayloadStats{}
+	if got := formatSizeDistribution(empty); got != "no payloads" {
+		t.Errorf("formatSizeDistribution(empty) = %q, want %q", got, "no payloads")
+	}
+
+	payload := types.PayloadStats{Count: 2, SizeBuckets: make([]int, len(types.PayloadSizeBuckets)+1)}
+	payload.SizeBuckets[0] = 1
+	payload.SizeBuckets[len(types.PayloadSizeBuckets)] = 1
+
+	got := formatSizeDistribution(payload)
+	for _, want := range []string{"<512 B: 1 (50%)", "≥64.0 KB: 1 (50%)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatSizeDistribution() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	got := sortedKeys(map[string]int{"logs": 1, "newHeads": 2, "alpha": 3})
+	want := []string{"alpha", "logs", "newHeads"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("sortedKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatRates(t *testing.T) {
+	if got, want := formatRates([]float64{2, 1.5, 0.25}), "10s 2.00/s, 1m 1.50/s, 5m 0.25/s"; got != want {
+		t.Errorf("formatRates() = %q, want %q", got, want)
+	}
+	if got := windowRates([]float64{2, 1.5, 0.25}); got["10s"] != 2 || got["1m"] != 1.5 || got["5m"] != 0.25 {
+		t.Errorf("windowRates() = %v", got)
+	}
+}
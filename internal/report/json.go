@@ -0,0 +1,32 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+)
+
+// JSONReporter writes the final summary as an indented JSON document
+type JSONReporter struct {
+	w       io.Writer
+	headers []string
+}
+
+// NewJSON creates a JSON reporter writing to w that includes the named
+// upgrade response headers
+func NewJSON(w io.Writer, headers []string) *JSONReporter {
+	return &JSONReporter{w: w, headers: headers}
+}
+
+// Update is a no-op; the JSON document is only written once the test ends
+func (j *JSONReporter) Update(stats.Snapshot) error {
+	return nil
+}
+
+// Final writes the summary document
+func (j *JSONReporter) Final(snap stats.Snapshot) error {
+	encoder := json.NewEncoder(j.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewSummary(snap, j.headers))
+}
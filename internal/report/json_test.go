@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSON_Final(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewJSON(&buf, testHeaders)
+	snap := testSnapshot(t)
+
+	if err := reporter.Update(snap); err != nil || buf.Len() != 0 {
+		t.Fatalf("Update() should write nothing, wrote %q (err %v)", buf.String(), err)
+	}
+	if err := reporter.Final(snap); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+
+	var summary Summary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if c := summary.Connections; c.BytesReceived != 2048 || c.BytesSent != 512 || c.ReceiveBytesPerSecond <= 0 {
+		t.Errorf("Connections = %+v, want the bytes received and sent", c)
+	}
+	if summary.Connections.Total != 2 || summary.Connections.Reconnections != 1 || !summary.Connections.Connected {
+		t.Errorf("Connections = %+v", summary.Connections)
+	}
+	if summary.Messages.Total != 2 || summary.Messages.ByType["newHeads"] != 1 {
+		t.Errorf("Messages = %+v", summary.Messages)
+	}
+	if summary.Recovery.Outages != 1 {
+		t.Errorf("Recovery.Outages = %d, want 1", summary.Recovery.Outages)
+	}
+	if pc := summary.PerConnection; len(pc) != 1 || pc[0].State != "stalled" || pc[0].Reconnects != 1 || pc[0].Events != 2 ||
+		pc[0].LastEventAgeSeconds == nil || pc[0].LastError != "upgrade rejected: HTTP 503 Service Unavailable" {
+		t.Errorf("PerConnection = %+v", pc)
+	}
+	if len(summary.History) != 1 || summary.History[0].Reason != "closed" || summary.History[0].BytesReceived != 2048 || summary.History[0].BytesSent != 512 {
+		t.Errorf("History = %+v, want one connection closed with reason and its traffic", summary.History)
+	}
+	if f := summary.Failures; f.Total != 1 || f.ByClass["http_5xx"] != 1 || f.Last == nil || f.Last.Class != "http_5xx" {
+		t.Errorf("Failures = %+v", f)
+	}
+	if h := summary.Handshakes; h.Attempts != 2 || h.Failed != 1 || h.Phases["total"].Count != 2 || h.Phases["total"].MaxMs != 3 {
+		t.Errorf("Handshakes = %+v", h)
+	} else if _, ok := h.Phases["tls"]; ok {
+		t.Errorf("Handshakes.Phases = %v, phases that never took place should be omitted", h.Phases)
+	}
+	if c := summary.Calls; c.Sent != 2 || c.Answered != 1 || c.Errors != 0 || c.Unanswered != 1 || c.Missed != 1 || c.CallsPerSecond <= 0 {
+		t.Errorf("Calls = %+v", c)
+	}
+	if s := summary.Sequences["slot"]; s != (SequenceSummary{Received: 4, Highest: 104, Gaps: 1, Missed: 2, Repeated: 1}) {
+		t.Errorf("Sequences[slot] = %+v", s)
+	}
+	if f := summary.Finality; f == nil || *f != (FinalitySummary{BestHead: 201, FinalizedHead: 197, Samples: 2, LastLag: 4, MeanLag: 3.5, MaxLag: 4}) {
+		t.Errorf("Finality = %+v", f)
+	}
+	if o := summary.Operations["blocks"]; o != (OperationSummary{Results: 2, Errors: 1, Completed: 1}) {
+		t.Errorf("Operations[blocks] = %+v", o)
+	}
+	if l := summary.Latencies; len(l) != 5 || l["call_rtt"].MaxMs != 4 || l["call_rtt_corrected"].MaxMs != 10 || l["ping_rtt"].Count != 1 || l["ping_rtt"].P999Ms != 2 || l["subscribe_confirm"].P50Ms != 5 || l["finality_lag"].P99Ms != 6000 {
+		t.Errorf("Latencies = %+v", l)
+	} else if h := l["ping_rtt"].Histogram; h == nil || h.Count() != 1 || h.Percentile(99) != 2*time.Millisecond {
+		t.Errorf("Latencies[ping_rtt].Histogram = %+v, want the serialized distribution", h)
+	}
+	if r := summary.Responses; len(r) != 2 || r[0].Connection != 1 || r[0].Headers["X-Request-Id"] != "req-1" || len(r[0].Headers) != 1 || r[0].TLSVersion != "TLS 1.3" ||
+		r[1].Connection != 0 || r[1].Status != 503 || r[1].Headers["Retry-After"] != "5" {
+		t.Errorf("Responses = %+v, want the selected headers of both upgrade responses", r)
+	}
+	if st := summary.Stalls; st.Total != 2 || st.Active != 1 || st.ByType["newHeads"] != 2 || len(st.Periods) != 2 {
+		t.Errorf("Stalls = %+v", st)
+	} else if st.Periods[0].Resolution != "connection closed" || st.Periods[0].EndTime == nil || st.Periods[1].EndTime != nil {
+		t.Errorf("Stalls.Periods = %+v, want a closed stall followed by an ongoing one", st.Periods)
+	}
+	if hb := summary.Heartbeat; hb.PingsSent != 1 || hb.PongsReceived != 1 || hb.AverageRTTMs != 2 || len(hb.Series) != 1 {
+		t.Errorf("Heartbeat = %+v", hb)
+	}
+	if w := summary.Wire; w.CompressedConnections != 1 || w.Total.WireBytes != 290 || w.Total.PayloadBytes != 1040 ||
+		w.ByType["newHeads"].SavingPercent != 75 || w.ByType["newHeads"].ReadMsPerMessage != 1 ||
+		w.ByType["newHeads"].SentMessages != 1 || w.ByType["newHeads"].SentBytes != 80 || w.ByType["newHeads"].SizeBuckets["<1.0 KB"] != 1 ||
+		w.ByType["rpc"].MaxBytes != 40 || w.ByType["rpc"].AverageBytes != 40 {
+		t.Errorf("Wire = %+v", w)
+	}
+	if m := summary.Messages; len(m.Rates) != 3 || len(m.RatesByType["newHeads"]) != 3 {
+		t.Errorf("Messages = %+v, want rates over every window", m)
+	}
+	tx, ok := summary.Transactions["newPendingTransactions:full"]
+	if !ok || tx.Count != 1 || tx.TotalBytes != 900 || tx.SizeBuckets["<1.0 KB"] != 1 {
+		t.Errorf("Transactions = %+v", summary.Transactions)
+	}
+}
@@ -0,0 +1,307 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/commoddity/websocket-load-test/internal/histogram"
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// metricPrefix namespaces every exported metric
+const metricPrefix = "wsload_"
+
+// MetricsReporter writes statistics in the Prometheus text exposition format.
+// With a file path the file is atomically rewritten on every update so it can
+// be scraped by a textfile collector; otherwise the metrics are written once at the end.
+type MetricsReporter struct {
+	w    io.Writer
+	path string
+}
+
+// NewMetrics creates a metrics reporter that writes the final metrics to w
+func NewMetrics(w io.Writer) *MetricsReporter {
+	return &MetricsReporter{w: w}
+}
+
+// NewMetricsFile creates a metrics reporter that keeps the file at path up to date
+func NewMetricsFile(path string) *MetricsReporter {
+	return &MetricsReporter{path: path}
+}
+
+// Update rewrites the metrics file, if any
+func (m *MetricsReporter) Update(snap stats.Snapshot) error {
+	if m.path == "" {
+		return nil
+	}
+	return m.writeFile(snap)
+}
+
+// Final writes the final metrics
+func (m *MetricsReporter) Final(snap stats.Snapshot) error {
+	if m.path == "" {
+		_, err := m.w.Write(formatMetrics(snap))
+		return err
+	}
+	return m.writeFile(snap)
+}
+
+// writeFile replaces the metrics file through a rename so readers never see a partial file
+func (m *MetricsReporter) writeFile(snap stats.Snapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), "."+filepath.Base(m.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(formatMetrics(snap)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), m.path)
+}
+
+// metric is a single sample with optional labels
+type metric struct {
+	labels string
+	value  float64
+}
+
+// formatMetrics renders a snapshot in the Prometheus text exposition format
+func formatMetrics(snap stats.Snapshot) []byte {
+	s := snap.Stats
+	var buf bytes.Buffer
+
+	write := func(name, kind, help string, samples ...metric) {
+		fmt.Fprintf(&buf, "# HELP %s%s %s\n", metricPrefix, name, help)
+		fmt.Fprintf(&buf, "# TYPE %s%s %s\n", metricPrefix, name, kind)
+		for _, sample := range samples {
+			fmt.Fprintf(&buf, "%s%s%s %s\n", metricPrefix, name, sample.labels, strconv.FormatFloat(sample.value, 'g', -1, 64))
+		}
+	}
+	value := func(v float64) metric { return metric{value: v} }
+
+	connected := 0.0
+	if snap.Connected() {
+		connected = 1
+	}
+
+	write("connected", "gauge", "Whether at least one connection is open.", value(connected))
+	write("runtime_seconds", "gauge", "Seconds since the load test started.", value(snap.Runtime().Seconds()))
+	write("connections_total", "counter", "Successful connections.", value(float64(s.TotalConnections)))
+	write("reconnections_total", "counter", "Reconnections after a connection was lost or failed.", value(float64(s.TotalReconnections)))
+	write("connection_attempts_total", "counter", "Connection attempts.", value(float64(s.ConnectionAttempts)))
+	write("uptime_seconds_total", "counter", "Seconds spent connected.", value(s.TotalUptime.Seconds()))
+	if len(snap.Connections) > 0 {
+		counts := snap.ConnectionStates()
+		states := make([]metric, len(types.ConnectionStates))
+		for i, state := range types.ConnectionStates {
+			states[i] = metric{labelSet("state", string(state)), float64(counts[state])}
+		}
+		write("connection_slots", "gauge", "Connection slots, by state.", states...)
+
+		var events, reconnects []metric
+		for _, row := range snap.ConnectionRows() {
+			connection := strconv.Itoa(row.Index)
+			events = append(events, metric{labelSet("connection", connection), float64(row.Events)})
+			reconnects = append(reconnects, metric{labelSet("connection", connection), float64(row.Reconnects)})
+		}
+		write("connection_events_total", "counter", "Messages received, by connection slot.", events...)
+		write("connection_reconnections_total", "counter", "Reconnections, by connection slot.", reconnects...)
+	}
+	write("bytes_received_total", "counter", "WebSocket bytes received, before decompression.", value(float64(s.BytesReceived)))
+	write("bytes_sent_total", "counter", "WebSocket bytes sent.", value(float64(s.BytesSent)))
+	write("outages_total", "counter", "Resolved outages between connections.", value(float64(len(snap.Outages))))
+	write("downtime_seconds_total", "counter", "Seconds spent disconnected, including ongoing outages.", value(snap.TotalDowntime().Seconds()))
+	if len(snap.Failures) > 0 {
+		samples := make([]metric, 0, len(snap.Failures))
+		for _, class := range sortedKeys(snap.Failures) {
+			samples = append(samples, metric{labelSet("class", string(class)), float64(snap.Failures[class])})
+		}
+		write("failures_total", "counter", "Dial and connection failures, by class.", samples...)
+	}
+	if len(snap.Handshakes) > 0 {
+		write("handshakes_total", "counter", "Connection attempts with recorded handshake timings.", value(float64(snap.HandshakeAttempts())))
+		var samples []metric
+		for _, phase := range types.HandshakePhases {
+			samples = append(samples, latencyQuantiles(snap.HandshakeLatency(phase), "phase", phase)...)
+		}
+		write("handshake_duration_seconds", "gauge", "Handshake phase durations across connection attempts, by phase and quantile.", samples...)
+	}
+	var latencies []metric
+	for _, name := range types.Latencies {
+		latencies = append(latencies, latencyQuantiles(snap.Latency(name), "name", name)...)
+	}
+	if len(latencies) > 0 {
+		write("latency_seconds", "gauge", "Latencies across every connection, by name and quantile.", latencies...)
+	}
+	write("pings_sent_total", "counter", "WebSocket pings sent.", value(float64(snap.Ping.Sent)))
+	write("pongs_received_total", "counter", "WebSocket pongs received.", value(float64(snap.Ping.Received)))
+	write("pong_timeouts_total", "counter", "Connections dropped because a pong did not arrive in time.", value(float64(snap.Ping.Timeouts)))
+	if snap.Ping.Received > 0 {
+		write("ping_rtt_seconds", "gauge", "Ping round-trip time.",
+			metric{`{stat="last"}`, snap.Ping.LastRTT.Seconds()},
+			metric{`{stat="avg"}`, snap.Ping.AverageRTT().Seconds()},
+			metric{`{stat="min"}`, snap.Ping.MinRTT.Seconds()},
+			metric{`{stat="max"}`, snap.Ping.MaxRTT.Seconds()})
+	}
+	write("calls_sent_total", "counter", "Workload calls sent.", value(float64(snap.Calls.Sent)))
+	write("call_responses_total", "counter", "Responses to workload calls, by result.",
+		metric{labelSet("result", "answered"), float64(snap.Calls.Answered)},
+		metric{labelSet("result", "error"), float64(snap.Calls.Errors)})
+	write("calls_unanswered_total", "counter", "Workload calls still awaiting a response when their connection closed.", value(float64(snap.Calls.Unanswered)))
+	write("calls_missed_total", "counter", "Workload calls that fell due while disconnected and were never sent.", value(float64(snap.Calls.Missed)))
+	if len(snap.Sequences) > 0 {
+		var gaps, missed, highest []metric
+		for _, subType := range sortedKeys(snap.Sequences) {
+			sequence := snap.Sequences[subType]
+			labels := labelSet("type", subType)
+			gaps = append(gaps, metric{labels, float64(sequence.Gaps)})
+			missed = append(missed, metric{labels, float64(sequence.Missed)})
+			highest = append(highest, metric{labels, float64(sequence.Highest)})
+		}
+		write("sequence_gaps_total", "counter", "Jumps in numbered notifications such as slots, by subscription type.", gaps...)
+		write("sequence_missed_total", "counter", "Numbers skipped by sequence gaps, by subscription type.", missed...)
+		write("sequence_highest", "gauge", "Highest number received in numbered notifications, by subscription type.", highest...)
+	}
+	if finality := snap.Finality; finality.BestHead > 0 || finality.Finalized > 0 {
+		write("head_number", "gauge", "Highest block number received, by head.",
+			metric{labelSet("head", "best"), float64(finality.BestHead)},
+			metric{labelSet("head", "finalized"), float64(finality.Finalized)})
+	}
+	if finality := snap.Finality; finality.Samples > 0 {
+		write("finality_lag_blocks", "gauge", "Blocks the finalized head trails the best head by.",
+			metric{`{stat="last"}`, float64(finality.LastLag)},
+			metric{`{stat="avg"}`, finality.MeanLag()},
+			metric{`{stat="max"}`, float64(finality.MaxLag)})
+	}
+	if len(snap.Operations) > 0 {
+		var results, errors, completed []metric
+		for _, subType := range sortedKeys(snap.Operations) {
+			operation := snap.Operations[subType]
+			labels := labelSet("type", subType)
+			results = append(results, metric{labels, float64(operation.Results)})
+			errors = append(errors, metric{labels, float64(operation.Errors)})
+			completed = append(completed, metric{labels, float64(operation.Completed)})
+		}
+		write("operation_results_total", "counter", "Results of GraphQL operations, by subscription type.", results...)
+		write("operation_errors_total", "counter", "Errors of GraphQL operations, by subscription type.", errors...)
+		write("operations_completed_total", "counter", "GraphQL operations the server completed, by subscription type.", completed...)
+	}
+	write("active_subscriptions", "gauge", "Subscriptions currently active.", value(float64(s.ActiveSubscriptions)))
+	write("subscription_requests_total", "counter", "Subscribe requests sent.", value(float64(s.SubscriptionRequests)))
+	write("subscriptions_created_total", "counter", "Subscriptions confirmed by the server.", value(float64(s.SubscriptionsCreated)))
+	write("resubscriptions_total", "counter", "Subscriptions re-created after a reconnect.", value(float64(s.Resubscriptions)))
+	write("messages_total", "counter", "Messages received, by kind.",
+		metric{`{kind="subscription"}`, float64(s.SubscriptionEvents)},
+		metric{`{kind="confirmation"}`, float64(s.ConfirmationEvents)},
+		metric{`{kind="error"}`, float64(s.ErrorEvents)},
+		metric{`{kind="other"}`, float64(s.EventsReceived - s.SubscriptionEvents - s.ConfirmationEvents - s.ErrorEvents)})
+
+	rates := make([]metric, len(types.RateWindows))
+	for i, rate := range snap.EventRates() {
+		rates[i] = metric{labelSet("window", windowLabel(types.RateWindows[i])), rate}
+	}
+	write("message_rate", "gauge", "Messages received per second over the most recent window.", rates...)
+
+	if len(snap.MessagesByType) > 0 {
+		samples := make([]metric, 0, len(snap.MessagesByType))
+		var typeRates []metric
+		for _, subType := range sortedKeys(snap.MessagesByType) {
+			samples = append(samples, metric{labelSet("type", subType), float64(snap.MessagesByType[subType])})
+			for i, rate := range snap.TypeRates(subType) {
+				typeRates = append(typeRates, metric{labelSet("type", subType, "window", windowLabel(types.RateWindows[i])), rate})
+			}
+		}
+		write("subscription_messages_total", "counter", "Subscription notifications received, by subscription type.", samples...)
+		write("subscription_message_rate", "gauge", "Subscription notifications received per second over the most recent window, by subscription type.", typeRates...)
+	}
+
+	write("stalled_seconds_total", "counter", "Seconds subscriptions spent stalled, including ongoing stalls.", value(snap.StalledTime().Seconds()))
+	if stallsByType := snap.StallsByType(); len(stallsByType) > 0 {
+		var stalls, active []metric
+		for _, subType := range sortedKeys(stallsByType) {
+			ongoing := 0.0
+			if _, ok := activeStall(snap, subType); ok {
+				ongoing = 1
+			}
+			stalls = append(stalls, metric{labelSet("type", subType), float64(stallsByType[subType])})
+			active = append(active, metric{labelSet("type", subType), ongoing})
+		}
+		write("stalls_total", "counter", "Subscriptions that went silent for longer than their threshold, by subscription type.", stalls...)
+		write("stalled", "gauge", "Whether a subscription type is currently stalled.", active...)
+	}
+
+	if !s.LastEventTime.IsZero() {
+		write("last_event_timestamp_seconds", "gauge", "Unix time of the most recent message.",
+			value(float64(s.LastEventTime.UnixNano())/1e9))
+	}
+
+	if len(snap.TransactionStats) > 0 {
+		var txs, txBytes []metric
+		for _, subType := range sortedKeys(snap.TransactionStats) {
+			txStats := snap.TransactionStats[subType]
+			txs = append(txs,
+				metric{labelSet("type", subType, "outcome", "decoded"), float64(txStats.Decoded)},
+				metric{labelSet("type", subType, "outcome", "decode_error"), float64(txStats.DecodeErrors)},
+				metric{labelSet("type", subType, "outcome", "hash_only"), float64(txStats.HashOnly)})
+			txBytes = append(txBytes, metric{labelSet("type", subType), float64(txStats.Payload.TotalBytes)})
+		}
+		write("transactions_total", "counter", "Full transaction notifications, by decode outcome.", txs...)
+		write("transaction_payload_bytes_total", "counter", "Bytes received in full transaction notifications.", txBytes...)
+	}
+
+	if len(snap.WireStats) > 0 {
+		var wireBytes, payloadBytes, readTime, sizes, sentBytes []metric
+		for _, messageType := range sortedKeys(snap.WireStats) {
+			wire := snap.WireStats[messageType]
+			wireBytes = append(wireBytes, metric{labelSet("type", messageType), float64(wire.WireBytes)})
+			payloadBytes = append(payloadBytes, metric{labelSet("type", messageType), float64(wire.Payload.TotalBytes)})
+			readTime = append(readTime, metric{labelSet("type", messageType), wire.ReadTime.Seconds()})
+			sentBytes = append(sentBytes, metric{labelSet("type", messageType), float64(wire.SentBytes)})
+			for i, count := range wire.Payload.SizeBuckets {
+				sizes = append(sizes, metric{labelSet("type", messageType, "size", sizeBucketLabel(i)), float64(count)})
+			}
+		}
+		write("compressed_connections_total", "counter", "Connections on which the server accepted permessage-deflate.", value(float64(snap.CompressedConnections())))
+		write("wire_bytes_total", "counter", "Bytes of received WebSocket frames before decompression, by message type.", wireBytes...)
+		write("payload_bytes_total", "counter", "Bytes of received message payloads after decompression, by message type.", payloadBytes...)
+		write("message_read_seconds_total", "counter", "Seconds spent reading and inflating message payloads, by message type.", readTime...)
+		write("message_sizes_total", "counter", "Received messages by message type and decompressed size bucket.", sizes...)
+		write("request_bytes_total", "counter", "Bytes of requests sent, such as subscribe requests, by message type.", sentBytes...)
+	}
+
+	return buf.Bytes()
+}
+
+// labelSet renders alternating label names and values as a Prometheus label set
+func labelSet(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%s", pairs[i], strconv.Quote(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// latencyQuantiles renders the reported percentiles and maximum of a latency
+// distribution labelled by key and value, or nothing when it is empty
+func latencyQuantiles(h *histogram.Histogram, key, value string) []metric {
+	if h.Count() == 0 {
+		return nil
+	}
+	samples := make([]metric, 0, len(latencyPercentiles)+1)
+	for _, p := range latencyPercentiles {
+		quantile := strconv.FormatFloat(p/100, 'g', 4, 64)
+		samples = append(samples, metric{labelSet(key, value, "quantile", quantile), h.Percentile(p).Seconds()})
+	}
+	return append(samples, metric{labelSet(key, value, "quantile", "1"), h.Max().Seconds()})
+}
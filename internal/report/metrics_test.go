@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	out := string(formatMetrics(testSnapshot(t)))
+
+	for _, want := range []string{
+		"# TYPE wsload_connections_total counter\nwsload_connections_total 2\n",
+		"wsload_connected 1\n",
+		"wsload_reconnections_total 1\n",
+		`wsload_messages_total{kind="subscription"} 1`,
+		`wsload_messages_total{kind="confirmation"} 1`,
+		`wsload_subscription_messages_total{type="newHeads"} 1`,
+		`wsload_subscription_message_rate{type="newHeads",window="1m"} 0`,
+		`wsload_message_rate{window="5m"} 0`,
+		`wsload_transactions_total{type="newPendingTransactions:full",outcome="decoded"} 1`,
+		`wsload_transaction_payload_bytes_total{type="newPendingTransactions:full"} 900`,
+		"wsload_outages_total 1\n",
+		"wsload_pongs_received_total 1\n",
+		`wsload_ping_rtt_seconds{stat="avg"} 0.002`,
+		`wsload_stalls_total{type="newHeads"} 2`,
+		`wsload_stalled{type="newHeads"} 1`,
+		`wsload_failures_total{class="http_5xx"} 1`,
+		"wsload_handshakes_total 2\n",
+		`wsload_handshake_duration_seconds{phase="upgrade",quantile="1"} 0.002`,
+		`wsload_latency_seconds{name="subscribe_confirm",quantile="0.999"} 0.005`,
+		`wsload_latency_seconds{name="ping_rtt",quantile="0.5"} 0.002`,
+		`wsload_latency_seconds{name="call_rtt_corrected",quantile="0.99"} 0.01`,
+		`wsload_latency_seconds{name="finality_lag",quantile="0.99"} 6`,
+		"wsload_calls_sent_total 2\n",
+		`wsload_call_responses_total{result="answered"} 1`,
+		"wsload_calls_unanswered_total 1\n",
+		"wsload_calls_missed_total 1\n",
+		`wsload_sequence_gaps_total{type="slot"} 1`,
+		`wsload_sequence_missed_total{type="slot"} 2`,
+		`wsload_sequence_highest{type="slot"} 104`,
+		`wsload_head_number{head="finalized"} 197`,
+		`wsload_finality_lag_blocks{stat="avg"} 3.5`,
+		`wsload_operation_results_total{type="blocks"} 2`,
+		`wsload_operation_errors_total{type="blocks"} 1`,
+		`wsload_operations_completed_total{type="blocks"} 1`,
+		"wsload_compressed_connections_total 1\n",
+		`wsload_wire_bytes_total{type="newHeads"} 250`,
+		`wsload_payload_bytes_total{type="rpc"} 40`,
+		`wsload_connection_slots{state="stalled"} 1`,
+		`wsload_connection_slots{state="subscribed"} 0`,
+		`wsload_connection_events_total{connection="0"} 2`,
+		`wsload_connection_reconnections_total{connection="0"} 1`,
+		"wsload_bytes_received_total 2048\n",
+		"wsload_bytes_sent_total 512\n",
+		`wsload_message_sizes_total{type="newHeads",size="<1.0 KB"} 1`,
+		`wsload_request_bytes_total{type="newHeads"} 80`,
+		`wsload_message_read_seconds_total{type="newHeads"} 0.001`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestMetrics_Stdout(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewMetrics(&buf)
+	snap := testSnapshot(t)
+
+	if err := reporter.Update(snap); err != nil || buf.Len() != 0 {
+		t.Fatalf("Update() should write nothing to stdout, wrote %q (err %v)", buf.String(), err)
+	}
+	if err := reporter.Final(snap); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "wsload_connections_total") {
+		t.Error("Final() should write the metrics")
+	}
+}
+
+func TestMetrics_FileRewrittenOnUpdate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wsload.prom")
+	reporter := NewMetricsFile(path)
+
+	snap := testSnapshot(t)
+	for i := 0; i < 3; i++ {
+		if err := reporter.Update(snap); err != nil {
+			t.Fatalf("Update() unexpected error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading metrics file: %v", err)
+	}
+	if strings.Count(string(data), "# TYPE wsload_connections_total") != 1 {
+		t.Error("metrics file should be replaced, not appended to")
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the metrics file", len(entries))
+	}
+}
+
+func TestLabelSet(t *testing.T) {
+	if got := labelSet("type", `a"b`, "outcome", "ok"); got != `{type="a\"b",outcome="ok"}` {
+		t.Errorf("labelSet() = %s", got)
+	}
+}
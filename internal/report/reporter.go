@@ -0,0 +1,229 @@
+// Package report renders statistics snapshots. Rendering is kept apart from
+// collection: every Reporter only ever sees immutable stats.Snapshot values.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Reporter renders statistics snapshots
+type Reporter interface {
+	// Update renders a live update while the load test is running
+	Update(snap stats.Snapshot) error
+	// Final renders the summary once the load test has finished
+	Final(snap stats.Snapshot) error
+}
+
+// Format identifies an output format
+type Format string
+
+const (
+	// Dashboard is the colorful live terminal dashboard
+	Dashboard Format = "dashboard"
+	// Text prints one plain status line per update and a plain summary
+	Text Format = "text"
+	// JSON writes the final summary as a JSON document
+	JSON Format = "json"
+	// CSV writes one row per update, suitable for plotting over time
+	CSV Format = "csv"
+	// Metrics writes Prometheus text exposition metrics
+	Metrics Format = "metrics"
+)
+
+// Formats lists every supported output format
+var Formats = []Format{Dashboard, Text, JSON, CSV, Metrics}
+
+// Stdout is the output path that refers to standard output
+const Stdout = "-"
+
+// Output is a single requested output: a format and where to write it
+type Output struct {
+	Format Format
+	Path   string // Stdout or a file path
+}
+
+// String returns the output in the same "format[=path]" form it is parsed from
+func (o Output) String() string {
+	if o.Path == Stdout {
+		return string(o.Format)
+	}
+	return string(o.Format) + "=" + o.Path
+}
+
+// ParseOutputs parses a comma-separated list of outputs in the form
+// "format[=path]", e.g. "dashboard,json=report.json,csv=series.csv".
+// Outputs without a path write to standard output; each destination may only
+// be used once so outputs never interleave.
+func ParseOutputs(value string) ([]Output, error) {
+	var outputs []Output
+	destinations := make(map[string]Format)
+
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, path, _ := strings.Cut(spec, "=")
+		format, err := parseFormat(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+
+		path = strings.TrimSpace(path)
+		if path == "" {
+			path = Stdout
+		}
+		if format == Dashboard && path != Stdout {
+			return nil, fmt.Errorf("output %q: the dashboard can only be written to the terminal", spec)
+		}
+		if other, taken := destinations[path]; taken {
+			if path == Stdout {
+				return nil, fmt.Errorf("outputs %q and %q both write to stdout; give one of them a file path", other, format)
+			}
+			return nil, fmt.Errorf("outputs %q and %q both write to %s", other, format, path)
+		}
+		destinations[path] = format
+
+		outputs = append(outputs, Output{Format: format, Path: path})
+	}
+
+	if len(outputs) == 0 {
+		return nil, errors.New("at least one output is required")
+	}
+	return outputs, nil
+}
+
+// parseFormat validates an output format name
+func parseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("unknown output format %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Headless replaces a dashboard written to stdout with plain text output.
+// It is used when stdout is not a terminal, where cursor movement and
+// screen clearing would only produce garbage.
+func Headless(outputs []Output) []Output {
+	headless := make([]Output, len(outputs))
+	for i, output := range outputs {
+		if output.Format == Dashboard && output.Path == Stdout {
+			output.Format = Text
+		}
+		headless[i] = output
+	}
+	return headless
+}
+
+// Options configures the reporters built by Open
+type Options struct {
+	Config         *types.Config  // shown in the dashboard header when ShowMessages is set
+	ShowMessages   bool           // render the latest message per subscription type
+	StatusInterval time.Duration  // minimum time between plain-text status lines
+	Headers        []string       // upgrade response headers to show, in canonical form
+	ConnectionSort ConnectionSort // column the per-connection table is sorted by
+}
+
+// Multi fans every snapshot out to several reporters
+type Multi struct {
+	reporters []Reporter
+	closers   []io.Closer
+}
+
+// NewMulti combines reporters into one
+func NewMulti(reporters ...Reporter) *Multi {
+	return &Multi{reporters: reporters}
+}
+
+// Open builds a reporter for each output, creating any output files
+func Open(outputs []Output, opts Options) (*Multi, error) {
+	multi := &Multi{}
+	for _, output := range outputs {
+		reporter, closer, err := open(output, opts)
+		if err != nil {
+			_ = multi.Close()
+			return nil, fmt.Errorf("output %s: %w", output, err)
+		}
+		multi.reporters = append(multi.reporters, reporter)
+		if closer != nil {
+			multi.closers = append(multi.closers, closer)
+		}
+	}
+	return multi, nil
+}
+
+// open builds the reporter for a single output
+func open(output Output, opts Options) (Reporter, io.Closer, error) {
+	// Metrics files are rewritten on every update rather than appended to
+	if output.Format == Metrics && output.Path != Stdout {
+		return NewMetricsFile(output.Path), nil, nil
+	}
+
+	var w io.Writer = os.Stdout
+	var closer io.Closer
+	if output.Path != Stdout {
+		file, err := os.Create(output.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closer = file, file
+	}
+
+	switch output.Format {
+	case Dashboard:
+		return NewDashboard(w, opts), closer, nil
+	case Text:
+		return NewText(w, opts.StatusInterval, opts.Headers, opts.ConnectionSort), closer, nil
+	case JSON:
+		return NewJSON(w, opts.Headers), closer, nil
+	case CSV:
+		return NewCSV(w), closer, nil
+	case Metrics:
+		return NewMetrics(w), closer, nil
+	}
+	return nil, closer, fmt.Errorf("unknown output format %q", output.Format)
+}
+
+// Update sends the snapshot to every reporter
+func (m *Multi) Update(snap stats.Snapshot) error {
+	var errs []error
+	for _, reporter := range m.reporters {
+		errs = append(errs, reporter.Update(snap))
+	}
+	return errors.Join(errs...)
+}
+
+// Final sends the final snapshot to every reporter
+func (m *Multi) Final(snap stats.Snapshot) error {
+	var errs []error
+	for _, reporter := range m.reporters {
+		errs = append(errs, reporter.Final(snap))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes any files opened for the outputs
+func (m *Multi) Close() error {
+	var errs []error
+	for _, closer := range m.closers {
+		errs = append(errs, closer.Close())
+	}
+	m.closers = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,256 @@
+package report
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// testSnapshot builds a snapshot with one closed and one open connection
+func testSnapshot(t *testing.T) stats.Snapshot {
+	t.Helper()
+
+	manager := stats.NewManager()
+	manager.EnableLogging()
+	collector := manager.NewCollector()
+
+	collector.IncrementConnectionAttempts()
+	collector.RecordHandshake(types.HandshakeTiming{Time: time.Now(), Connect: time.Millisecond, Upgrade: 2 * time.Millisecond, Total: 3 * time.Millisecond})
+	collector.StartNewConnection()
+	collector.RecordUpgradeResponse(types.UpgradeResponse{Time: time.Now(), Status: 101, Headers: map[string]string{"X-Request-Id": "req-1", "Server": "nginx", "Upgrade": "websocket",
+		"Sec-Websocket-Extensions": "permessage-deflate; server_no_context_takeover; client_no_context_takeover"},
+		TLSVersion: "TLS 1.3", TLSCipher: "TLS_AES_128_GCM_SHA256"})
+	collector.SetSubscriptionMapping("0xa", "newHeads")
+	collector.HandleResponse(types.JSONRPCResponse{ID: float64(1), Result: "0xa"})
+	collector.RecordLatency(types.LatencySubscribeConfirm, 5*time.Millisecond)
+	collector.HandleResponse(types.JSONRPCResponse{
+		Method: "eth_subscription",
+		Params: map[string]interface{}{"subscription": "0xa", "result": map[string]interface{}{}},
+	})
+	collector.RecordTransactionPayload("newPendingTransactions:full", 900, false, nil)
+	collector.RecordWireMessage(types.RPCMessageType, 40, 40, 0)
+	collector.RecordWireMessage("newHeads", 250, 1000, time.Millisecond)
+	collector.RecordSentMessage("newHeads", 80)
+	collector.RecordBytesReceived(2048)
+	collector.RecordBytesSent(512)
+	collector.RecordPingSent()
+	collector.RecordPong(2 * time.Millisecond)
+	collector.RecordCallSent()
+	collector.RecordCallSent()
+	collector.RecordCallResponse(false, 4*time.Millisecond, 10*time.Millisecond)
+	collector.RecordCallsUnanswered(1)
+	collector.RecordCallsMissed([]time.Duration{10 * time.Millisecond})
+	for _, slot := range []uint64{100, 101, 104, 103} {
+		collector.RecordSequence("7", "slot", slot)
+	}
+	collector.RecordHead(200, false)
+	collector.RecordHead(197, true)
+	collector.RecordHead(201, false)
+	collector.RecordLatency(types.LatencyFinalityLag, 6*time.Second)
+	collector.RecordOperation("blocks", types.OperationResult)
+	collector.RecordOperation("blocks", types.OperationResult)
+	collector.RecordOperation("blocks", types.OperationError)
+	collector.RecordOperation("blocks", types.OperationComplete)
+	time.Sleep(time.Millisecond)
+	collector.CheckStalls(map[string]time.Duration{"newHeads": time.Nanosecond})
+	collector.EndConnection("closed")
+	collector.IncrementReconnections()
+	collector.IncrementConnectionAttempts()
+	collector.RecordHandshake(types.HandshakeTiming{Time: time.Now(), Connect: time.Millisecond, Upgrade: time.Millisecond, Total: 2 * time.Millisecond, Failed: true})
+	collector.RecordUpgradeResponse(types.UpgradeResponse{Time: time.Now(), Status: 503, Headers: map[string]string{"Retry-After": "5"}})
+	collector.RecordDialFailure(types.Failure{Class: types.FailureHTTP5xx, Reason: "upgrade rejected: HTTP 503 Service Unavailable"})
+	collector.IncrementConnectionAttempts()
+	collector.StartNewConnection()
+
+	// newHeads is stalled on the open connection
+	collector.SetSubscriptionMapping("0xb", "newHeads")
+	time.Sleep(time.Millisecond)
+	collector.CheckStalls(map[string]time.Duration{"newHeads": time.Nanosecond})
+
+	return manager.Snapshot()
+}
+
+// testHeaders are the upgrade response headers shown by reporters under test
+var testHeaders = []string{"X-Request-Id", "Retry-After"}
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Output
+		wantErr string
+	}{
+		{
+			name:  "single format",
+			value: "dashboard",
+			want:  []Output{{Format: Dashboard, Path: Stdout}},
+		},
+		{
+			name:  "combined outputs with files",
+			value: "dashboard, json=summary.json,csv=series.csv",
+			want: []Output{
+				{Format: Dashboard, Path: Stdout},
+				{Format: JSON, Path: "summary.json"},
+				{Format: CSV, Path: "series.csv"},
+			},
+		},
+		{
+			name:  "explicit stdout",
+			value: "text=-",
+			want:  []Output{{Format: Text, Path: Stdout}},
+		},
+		{
+			name:    "unknown format",
+			value:   "xml",
+			wantErr: "unknown output format",
+		},
+		{
+			name:    "two stdout outputs",
+			value:   "dashboard,json",
+			wantErr: "both write to stdout",
+		},
+		{
+			name:    "two outputs to the same file",
+			value:   "json=out.txt,csv=out.txt",
+			wantErr: "both write to out.txt",
+		},
+		{
+			name:    "dashboard to a file",
+			value:   "dashboard=dash.txt",
+			wantErr: "only be written to the terminal",
+		},
+		{
+			name:    "empty",
+			value:   " , ",
+			wantErr: "at least one output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutputs(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseOutputs(%q) error = %v, want it to contain %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOutputs(%q) unexpected error: %v", tt.value, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseOutputs(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("output %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOutput_String(t *testing.T) {
+	if got := (Output{Format: JSON, Path: Stdout}).String(); got != "json" {
+		t.Errorf("String() = %q, want %q", got, "json")
+	}
+	if got := (Output{Format: CSV, Path: "a.csv"}).String(); got != "csv=a.csv" {
+		t.Errorf("String() = %q, want %q", got, "csv=a.csv")
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	outputs := []Output{
+		{Format: Dashboard, Path: Stdout},
+		{Format: CSV, Path: "series.csv"},
+	}
+
+	got := Headless(outputs)
+	if got[0] != (Output{Format: Text, Path: Stdout}) {
+		t.Errorf("stdout dashboard became %v, want text", got[0])
+	}
+	if got[1] != outputs[1] {
+		t.Errorf("file output changed to %v", got[1])
+	}
+	if outputs[0].Format != Dashboard {
+		t.Error("Headless() must not modify its argument")
+	}
+}
+
+// recordingReporter counts calls and returns a fixed error
+type recordingReporter struct {
+	updates, finals int
+	err             error
+}
+
+func (r *recordingReporter) Update(stats.Snapshot) error { r.updates++; return r.err }
+func (r *recordingReporter) Final(stats.Snapshot) error  { r.finals++; return r.err }
+
+func TestMulti(t *testing.T) {
+	failure := errors.New("disk full")
+	ok := &recordingReporter{}
+	failing := &recordingReporter{err: failure}
+	multi := NewMulti(failing, ok)
+
+	snap := testSnapshot(t)
+	if err := multi.Update(snap); !errors.Is(err, failure) {
+		t.Errorf("Update() error = %v, want %v", err, failure)
+	}
+	if err := multi.Final(snap); !errors.Is(err, failure) {
+		t.Errorf("Final() error = %v, want %v", err, failure)
+	}
+
+	// A failing reporter must not stop the others
+	if ok.updates != 1 || ok.finals != 1 {
+		t.Errorf("healthy reporter got %d updates and %d finals, want 1 and 1", ok.updates, ok.finals)
+	}
+}
+
+func TestOpen_WritesFiles(t *testing.T) {
+	dir := t.TempDir()
+	outputs := []Output{
+		{Format: JSON, Path: filepath.Join(dir, "summary.json")},
+		{Format: CSV, Path: filepath.Join(dir, "series.csv")},
+		{Format: Text, Path: filepath.Join(dir, "log.txt")},
+		{Format: Metrics, Path: filepath.Join(dir, "wsload.prom")},
+	}
+
+	reporter, err := Open(outputs, Options{})
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+
+	snap := testSnapshot(t)
+	if err := reporter.Update(snap); err != nil {
+		t.Fatalf("Update() unexpected error: %v", err)
+	}
+	if err := reporter.Final(snap); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+	if err := reporter.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	for _, output := range outputs {
+		data, err := os.ReadFile(output.Path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", output, err)
+		}
+		if len(data) == 0 {
+			t.Errorf("%s is empty", output)
+		}
+	}
+}
+
+func TestOpen_InvalidPath(t *testing.T) {
+	outputs := []Output{{Format: JSON, Path: filepath.Join(t.TempDir(), "missing", "summary.json")}}
+	if _, err := Open(outputs, Options{}); err == nil {
+		t.Error("Open() should fail when the output file cannot be created")
+	}
+}
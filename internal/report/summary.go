@@ -0,0 +1,584 @@
+package report
+
+import (
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/histogram"
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Summary is the machine-readable form of a snapshot used by the JSON output.
+// Durations are expressed in seconds.
+type Summary struct {
+	GeneratedAt    time.Time                     `json:"generated_at"`
+	StartedAt      time.Time                     `json:"started_at"`
+	RuntimeSeconds float64                       `json:"runtime_seconds"`
+	Connections    ConnectionSummary             `json:"connections"`
+	PerConnection  []ConnectionRowRecord         `json:"per_connection"`
+	Recovery       RecoverySummary               `json:"recovery"`
+	Heartbeat      HeartbeatSummary              `json:"heartbeat"`
+	Calls          CallSummary                   `json:"calls"`
+	Stalls         StallSummary                  `json:"stalls"`
+	Failures       FailureSummary                `json:"failures"`
+	Handshakes     HandshakeSummary              `json:"handshakes"`
+	Latencies      map[string]LatencyMetrics     `json:"latencies"`
+	Responses      []UpgradeResponseRecord       `json:"upgrade_responses"`
+	Subscriptions  SubscriptionSummary           `json:"subscriptions"`
+	Messages       MessageSummary                `json:"messages"`
+	Performance    PerformanceSummary            `json:"performance"`
+	Transactions   map[string]TransactionSummary `json:"transactions,omitempty"`
+	Sequences      map[string]SequenceSummary    `json:"sequences,omitempty"`
+	Finality       *FinalitySummary              `json:"finality,omitempty"`
+	Operations     map[string]OperationSummary   `json:"operations,omitempty"`
+	Wire           WireSummary                   `json:"wire"`
+	History        []ConnectionRecord            `json:"connection_history"`
+}
+
+// ConnectionSummary describes connection counts and durations
+type ConnectionSummary struct {
+	Connected                bool    `json:"connected"`
+	Total                    int     `json:"total"`
+	Reconnections            int     `json:"reconnections"`
+	Attempts                 int     `json:"attempts"`
+	UptimeSeconds            float64 `json:"uptime_seconds"`
+	LongestSeconds           float64 `json:"longest_seconds"`
+	ShortestSeconds          float64 `json:"shortest_seconds"`
+	AverageSeconds           float64 `json:"average_seconds"`
+	CurrentConnectionSeconds float64 `json:"current_connection_seconds"`
+	BytesReceived            int64   `json:"bytes_received"`
+	BytesSent                int64   `json:"bytes_sent"`
+	ReceiveBytesPerSecond    float64 `json:"receive_bytes_per_second"`
+	SendBytesPerSecond       float64 `json:"send_bytes_per_second"`
+}
+
+// RecoverySummary describes outages between connections
+type RecoverySummary struct {
+	Outages                   int     `json:"outages"`
+	UnresolvedOutages         int     `json:"unresolved_outages"`
+	DowntimeSeconds           float64 `json:"downtime_seconds"`
+	MeanTimeToRecoverySeconds float64 `json:"mean_time_to_recovery_seconds"`
+	LongestOutageSeconds      float64 `json:"longest_outage_seconds"`
+}
+
+// HeartbeatSummary describes ping/pong heartbeats. RTTs are in milliseconds.
+type HeartbeatSummary struct {
+	PingsSent     int             `json:"pings_sent"`
+	PongsReceived int             `json:"pongs_received"`
+	PongTimeouts  int             `json:"pong_timeouts"`
+	MinRTTMs      float64         `json:"min_rtt_ms"`
+	AverageRTTMs  float64         `json:"average_rtt_ms"`
+	MaxRTTMs      float64         `json:"max_rtt_ms"`
+	Series        []LatencyRecord `json:"rtt_series"`
+}
+
+// LatencyRecord is a single latency sample
+type LatencyRecord struct {
+	Connection int       `json:"connection"`
+	Time       time.Time `json:"time"`
+	LatencyMs  float64   `json:"latency_ms"`
+}
+
+// StallSummary describes subscriptions that went silent for longer than expected
+type StallSummary struct {
+	Total          int            `json:"total"`
+	Active         int            `json:"active"`
+	StalledSeconds float64        `json:"stalled_seconds"`
+	ByType         map[string]int `json:"by_type"`
+	Periods        []StallRecord  `json:"periods"`
+}
+
+// StallRecord describes a single stall; ongoing stalls have no end time or resolution
+type StallRecord struct {
+	Connection       int        `json:"connection"`
+	SubscriptionType string     `json:"subscription_type"`
+	ThresholdSeconds float64    `json:"threshold_seconds"`
+	StartTime        time.Time  `json:"start_time"`
+	DetectedAt       time.Time  `json:"detected_at"`
+	EndTime          *time.Time `json:"end_time,omitempty"`
+	DurationSeconds  float64    `json:"duration_seconds"`
+	Resolution       string     `json:"resolution,omitempty"`
+}
+
+// FailureSummary describes classified dial and connection failures
+type FailureSummary struct {
+	Total   int            `json:"total"`
+	ByClass map[string]int `json:"by_class"`
+	Last    *FailureRecord `json:"last,omitempty"`
+}
+
+// FailureRecord describes a single failure
+type FailureRecord struct {
+	Class  string    `json:"class"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// HandshakeSummary describes the phase timings of every connection attempt.
+// Phases that never took place, such as TLS for ws:// URLs, are omitted.
+type HandshakeSummary struct {
+	Attempts int                       `json:"attempts"`
+	Failed   int                       `json:"failed"`
+	Phases   map[string]LatencyMetrics `json:"phases"`
+}
+
+// CallSummary describes the calls of the call workload. Their latencies are
+// under Summary.Latencies, both from when each call was sent and from when
+// it was due.
+type CallSummary struct {
+	Sent           int     `json:"sent"`
+	Answered       int     `json:"answered"`
+	Errors         int     `json:"errors"`
+	Unanswered     int     `json:"unanswered"`
+	Missed         int     `json:"missed"`
+	CallsPerSecond float64 `json:"calls_per_second"`
+}
+
+// LatencyMetrics summarises a latency distribution in milliseconds. The full
+// histogram is included so distributions from several runs can be merged.
+type LatencyMetrics struct {
+	Count     int64                `json:"count"`
+	MinMs     float64              `json:"min_ms"`
+	MeanMs    float64              `json:"mean_ms"`
+	P50Ms     float64              `json:"p50_ms"`
+	P90Ms     float64              `json:"p90_ms"`
+	P99Ms     float64              `json:"p99_ms"`
+	P999Ms    float64              `json:"p99_9_ms"`
+	MaxMs     float64              `json:"max_ms"`
+	Histogram *histogram.Histogram `json:"histogram"`
+}
+
+// UpgradeResponseRecord describes the response to an upgrade request with the
+// selected headers; rejected upgrades have no connection number
+type UpgradeResponseRecord struct {
+	Connection int               `json:"connection,omitempty"`
+	Time       time.Time         `json:"time"`
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers"`
+	TLSVersion string            `json:"tls_version,omitempty"`
+	TLSCipher  string            `json:"tls_cipher,omitempty"`
+}
+
+// SubscriptionSummary describes subscription lifecycle counts
+type SubscriptionSummary struct {
+	Requested       int `json:"requested"`
+	Created         int `json:"created"`
+	Resubscriptions int `json:"resubscriptions"`
+	Active          int `json:"active"`
+}
+
+// MessageSummary describes received message counts
+type MessageSummary struct {
+	Total              int            `json:"total"`
+	SubscriptionEvents int            `json:"subscription_events"`
+	Confirmations      int            `json:"confirmations"`
+	Errors             int            `json:"errors"`
+	ByType             map[string]int `json:"by_type"`
+	LastEventAt        *time.Time     `json:"last_event_at,omitempty"`
+
+	// Per-second rates over the windows that ended with the run, keyed by
+	// window, e.g. "10s"
+	Rates       map[string]float64            `json:"rates"`
+	RatesByType map[string]map[string]float64 `json:"rates_by_type"`
+}
+
+// PerformanceSummary describes derived rates and ratios
+type PerformanceSummary struct {
+	OverallRate           float64 `json:"overall_events_per_second"`
+	ConnectionEventRate   float64 `json:"connection_events_per_second"`
+	ReliabilityPercent    float64 `json:"reliability_percent"`
+	SuccessRatePercent    float64 `json:"success_rate_percent"`
+	EventsPerSubscription float64 `json:"events_per_subscription"`
+}
+
+// SequenceSummary describes the numbering of a subscription type's
+// notifications, such as Solana slots, and the gaps found in it
+type SequenceSummary struct {
+	Received int    `json:"received"`
+	Highest  uint64 `json:"highest"`
+	Gaps     int    `json:"gaps"`
+	Missed   uint64 `json:"missed"`
+	Repeated int    `json:"repeated"`
+}
+
+// FinalitySummary describes the best and finalized heads, such as those of
+// Substrate chains, and how many blocks finality trailed by
+type FinalitySummary struct {
+	BestHead      uint64  `json:"best_head"`
+	FinalizedHead uint64  `json:"finalized_head"`
+	Samples       int     `json:"samples"`
+	LastLag       uint64  `json:"last_lag_blocks"`
+	MeanLag       float64 `json:"mean_lag_blocks"`
+	MaxLag        uint64  `json:"max_lag_blocks"`
+}
+
+// OperationSummary describes the messages about the GraphQL operations of
+// one subscription type
+type OperationSummary struct {
+	Results   int `json:"results"`
+	Errors    int `json:"errors"`
+	Completed int `json:"completed"`
+}
+
+// TransactionSummary describes a full transaction stream
+type TransactionSummary struct {
+	Count          int            `json:"count"`
+	Decoded        int            `json:"decoded"`
+	DecodeErrors   int            `json:"decode_errors"`
+	HashOnly       int            `json:"hash_only"`
+	TotalBytes     int64          `json:"total_bytes"`
+	MinBytes       int            `json:"min_bytes"`
+	MaxBytes       int            `json:"max_bytes"`
+	AverageBytes   float64        `json:"average_bytes"`
+	BytesPerSecond float64        `json:"bytes_per_second"`
+	SizeBuckets    map[string]int `json:"size_buckets"`
+}
+
+// WireSummary compares the bytes received on the wire with the decompressed
+// payloads, in total and by message type
+type WireSummary struct {
+	CompressedConnections int                    `json:"compressed_connections"`
+	Total                 WireMetrics            `json:"total"`
+	ByType                map[string]WireMetrics `json:"by_type"`
+}
+
+// WireMetrics describes the bytes of a message stream: received payloads and
+// their size distribution, the same messages on the wire, and requests sent
+type WireMetrics struct {
+	Messages         int            `json:"messages"`
+	WireBytes        int64          `json:"wire_bytes"`
+	PayloadBytes     int64          `json:"payload_bytes"`
+	SavingPercent    float64        `json:"saving_percent"`
+	ReadMs           float64        `json:"read_ms"`
+	ReadMsPerMessage float64        `json:"read_ms_per_message"`
+	MinBytes         int            `json:"min_bytes"`
+	MaxBytes         int            `json:"max_bytes"`
+	AverageBytes     float64        `json:"average_bytes"`
+	BytesPerSecond   float64        `json:"bytes_per_second"`
+	SizeBuckets      map[string]int `json:"size_buckets"`
+	SentMessages     int            `json:"sent_messages"`
+	SentBytes        int64          `json:"sent_bytes"`
+}
+
+// ConnectionRowRecord describes one connection slot, in slot order
+type ConnectionRowRecord struct {
+	Index               int      `json:"index"`
+	State               string   `json:"state"`
+	UptimeSeconds       float64  `json:"uptime_seconds"`
+	Reconnects          int      `json:"reconnects"`
+	ActiveSubscriptions int      `json:"active_subscriptions"`
+	Events              int      `json:"events"`
+	EventsPerSecond     float64  `json:"events_per_second"`
+	LastEventAgeSeconds *float64 `json:"last_event_age_seconds"` // null before the first event
+	LastError           string   `json:"last_error,omitempty"`
+}
+
+// ConnectionRecord describes a single closed connection
+type ConnectionRecord struct {
+	Slot            int       `json:"slot"`
+	Number          int       `json:"number"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Messages        int       `json:"messages"`
+	BytesReceived   int64     `json:"bytes_received"`
+	BytesSent       int64     `json:"bytes_sent"`
+	Reason          string    `json:"reason,omitempty"`
+	Class           string    `json:"class,omitempty"`
+}
+
+// NewSummary builds a Summary from a snapshot, including the named upgrade response headers
+func NewSummary(snap stats.Snapshot, headers []string) Summary {
+	s := snap.Stats
+	summary := Summary{
+		GeneratedAt:    snap.Time,
+		StartedAt:      s.ClientStartTime,
+		RuntimeSeconds: snap.Runtime().Seconds(),
+		Connections: ConnectionSummary{
+			Connected:                snap.Connected(),
+			Total:                    s.TotalConnections,
+			Reconnections:            s.TotalReconnections,
+			Attempts:                 s.ConnectionAttempts,
+			UptimeSeconds:            s.TotalUptime.Seconds(),
+			LongestSeconds:           s.LongestConnection.Seconds(),
+			ShortestSeconds:          s.ShortestConnection.Seconds(),
+			AverageSeconds:           snap.AverageUptimePerConnection().Seconds(),
+			CurrentConnectionSeconds: snap.CurrentConnDuration().Seconds(),
+			BytesReceived:            s.BytesReceived,
+			BytesSent:                s.BytesSent,
+			ReceiveBytesPerSecond:    snap.ReceiveRate(),
+			SendBytesPerSecond:       snap.SendRate(),
+		},
+		Recovery: RecoverySummary{
+			Outages:                   len(snap.Outages),
+			UnresolvedOutages:         len(snap.CurrentOutages),
+			DowntimeSeconds:           snap.TotalDowntime().Seconds(),
+			MeanTimeToRecoverySeconds: snap.MeanTimeToRecovery().Seconds(),
+			LongestOutageSeconds:      snap.LongestOutage().Seconds(),
+		},
+		Heartbeat: HeartbeatSummary{
+			PingsSent:     snap.Ping.Sent,
+			PongsReceived: snap.Ping.Received,
+			PongTimeouts:  snap.Ping.Timeouts,
+			MinRTTMs:      milliseconds(snap.Ping.MinRTT),
+			AverageRTTMs:  milliseconds(snap.Ping.AverageRTT()),
+			MaxRTTMs:      milliseconds(snap.Ping.MaxRTT),
+			Series:        make([]LatencyRecord, len(snap.PingRTTs)),
+		},
+		Calls: CallSummary{
+			Sent:           snap.Calls.Sent,
+			Answered:       snap.Calls.Answered,
+			Errors:         snap.Calls.Errors,
+			Unanswered:     snap.Calls.Unanswered,
+			Missed:         snap.Calls.Missed,
+			CallsPerSecond: snap.CallRate(),
+		},
+		Stalls: StallSummary{
+			Total:          snap.StallCount(),
+			Active:         len(snap.ActiveStalls),
+			StalledSeconds: snap.StalledTime().Seconds(),
+			ByType:         snap.StallsByType(),
+			Periods:        make([]StallRecord, 0, snap.StallCount()),
+		},
+		Failures: FailureSummary{
+			Total:   snap.FailureCount(),
+			ByClass: make(map[string]int, len(snap.Failures)),
+		},
+		Handshakes: HandshakeSummary{
+			Attempts: snap.HandshakeAttempts(),
+			Failed:   snap.HandshakesFailed,
+			Phases:   make(map[string]LatencyMetrics, len(types.HandshakePhases)),
+		},
+		Latencies: make(map[string]LatencyMetrics, len(types.Latencies)),
+		Subscriptions: SubscriptionSummary{
+			Requested:       s.SubscriptionRequests,
+			Created:         s.SubscriptionsCreated,
+			Resubscriptions: s.Resubscriptions,
+			Active:          s.ActiveSubscriptions,
+		},
+		Messages: MessageSummary{
+			Total:              s.EventsReceived,
+			SubscriptionEvents: s.SubscriptionEvents,
+			Confirmations:      s.ConfirmationEvents,
+			Errors:             s.ErrorEvents,
+			ByType:             snap.MessagesByType,
+			Rates:              windowRates(snap.EventRates()),
+			RatesByType:        make(map[string]map[string]float64, len(snap.MessagesByType)),
+		},
+		Performance: PerformanceSummary{
+			OverallRate:           snap.OverallRate(),
+			ConnectionEventRate:   snap.ConnectionEventRate(),
+			ReliabilityPercent:    snap.Reliability(),
+			SuccessRatePercent:    snap.SuccessRate(),
+			EventsPerSubscription: snap.EventsPerSubscription(),
+		},
+		Wire: WireSummary{
+			CompressedConnections: snap.CompressedConnections(),
+			Total:                 newWireMetrics(snap.TotalWireStats(), snap.Time),
+			ByType:                make(map[string]WireMetrics, len(snap.WireStats)),
+		},
+		PerConnection: make([]ConnectionRowRecord, 0, len(snap.Connections)),
+		Responses:     make([]UpgradeResponseRecord, len(snap.UpgradeResponses)),
+		History:       make([]ConnectionRecord, len(snap.ConnectionHistory)),
+	}
+
+	if !s.LastEventTime.IsZero() {
+		lastEvent := s.LastEventTime
+		summary.Messages.LastEventAt = &lastEvent
+	}
+
+	for _, row := range snap.ConnectionRows() {
+		record := ConnectionRowRecord{
+			Index:               row.Index,
+			State:               string(row.State),
+			UptimeSeconds:       row.Uptime.Seconds(),
+			Reconnects:          row.Reconnects,
+			ActiveSubscriptions: row.ActiveSubscriptions,
+			Events:              row.Events,
+			EventsPerSecond:     row.EventsPerSecond,
+			LastError:           row.LastError,
+		}
+		if row.LastEventAge > 0 {
+			age := row.LastEventAge.Seconds()
+			record.LastEventAgeSeconds = &age
+		}
+		summary.PerConnection = append(summary.PerConnection, record)
+	}
+
+	for subType := range snap.MessagesByType {
+		summary.Messages.RatesByType[subType] = windowRates(snap.TypeRates(subType))
+	}
+
+	for i, conn := range snap.ConnectionHistory {
+		summary.History[i] = ConnectionRecord{
+			Slot:            conn.Slot,
+			Number:          conn.ConnectionNum,
+			StartTime:       conn.StartTime,
+			EndTime:         conn.EndTime,
+			DurationSeconds: conn.Duration.Seconds(),
+			Messages:        conn.Messages,
+			BytesReceived:   conn.BytesReceived,
+			BytesSent:       conn.BytesSent,
+			Reason:          conn.Reason,
+			Class:           string(conn.Class),
+		}
+	}
+
+	for i, sample := range snap.PingRTTs {
+		summary.Heartbeat.Series[i] = LatencyRecord{
+			Connection: sample.ConnectionNum,
+			Time:       sample.Time,
+			LatencyMs:  milliseconds(sample.Latency),
+		}
+	}
+
+	for class, count := range snap.Failures {
+		summary.Failures.ByClass[string(class)] = count
+	}
+	if last := snap.LastFailure; last.Class != "" {
+		summary.Failures.Last = &FailureRecord{Class: string(last.Class), Reason: last.Reason, Time: last.Time}
+	}
+
+	for i, response := range snap.UpgradeResponses {
+		summary.Responses[i] = UpgradeResponseRecord{
+			Connection: response.ConnectionNum,
+			Time:       response.Time,
+			Status:     response.Status,
+			Headers:    selectHeaders(response, headers),
+			TLSVersion: response.TLSVersion,
+			TLSCipher:  response.TLSCipher,
+		}
+	}
+
+	for messageType, wire := range snap.WireStats {
+		summary.Wire.ByType[messageType] = newWireMetrics(wire, snap.Time)
+	}
+
+	for _, phase := range types.HandshakePhases {
+		if latency := snap.HandshakeLatency(phase); latency.Count() > 0 {
+			summary.Handshakes.Phases[phase] = newLatencyMetrics(latency)
+		}
+	}
+	for _, name := range types.Latencies {
+		if latency := snap.Latency(name); latency.Count() > 0 {
+			summary.Latencies[name] = newLatencyMetrics(latency)
+		}
+	}
+
+	for _, stall := range snap.Stalls {
+		end := stall.EndTime
+		summary.Stalls.Periods = append(summary.Stalls.Periods, StallRecord{
+			Connection:       stall.ConnectionNum,
+			SubscriptionType: stall.SubscriptionType,
+			ThresholdSeconds: stall.Threshold.Seconds(),
+			StartTime:        stall.StartTime,
+			DetectedAt:       stall.DetectedAt,
+			EndTime:          &end,
+			DurationSeconds:  stall.Duration.Seconds(),
+			Resolution:       stall.Resolution,
+		})
+	}
+	for _, stall := range snap.ActiveStalls {
+		summary.Stalls.Periods = append(summary.Stalls.Periods, StallRecord{
+			Connection:       stall.ConnectionNum,
+			SubscriptionType: stall.SubscriptionType,
+			ThresholdSeconds: stall.Threshold.Seconds(),
+			StartTime:        stall.StartTime,
+			DetectedAt:       stall.DetectedAt,
+			DurationSeconds:  snap.Time.Sub(stall.StartTime).Seconds(),
+		})
+	}
+
+	if len(snap.TransactionStats) > 0 {
+		summary.Transactions = make(map[string]TransactionSummary, len(snap.TransactionStats))
+		for subType, txStats := range snap.TransactionStats {
+			payload := txStats.Payload
+			summary.Transactions[subType] = TransactionSummary{
+				Count:          payload.Count,
+				Decoded:        txStats.Decoded,
+				DecodeErrors:   txStats.DecodeErrors,
+				HashOnly:       txStats.HashOnly,
+				TotalBytes:     payload.TotalBytes,
+				MinBytes:       payload.MinBytes,
+				MaxBytes:       payload.MaxBytes,
+				AverageBytes:   stats.AveragePayloadSize(payload),
+				BytesPerSecond: stats.PayloadBytesPerSecond(payload, snap.Time),
+				SizeBuckets:    sizeBuckets(payload),
+			}
+		}
+	}
+
+	if len(snap.Sequences) > 0 {
+		summary.Sequences = make(map[string]SequenceSummary, len(snap.Sequences))
+		for subType, sequence := range snap.Sequences {
+			summary.Sequences[subType] = SequenceSummary(sequence)
+		}
+	}
+
+	if finality := snap.Finality; finality.BestHead > 0 || finality.Finalized > 0 {
+		summary.Finality = &FinalitySummary{
+			BestHead:      finality.BestHead,
+			FinalizedHead: finality.Finalized,
+			Samples:       finality.Samples,
+			LastLag:       finality.LastLag,
+			MeanLag:       finality.MeanLag(),
+			MaxLag:        finality.MaxLag,
+		}
+	}
+
+	if len(snap.Operations) > 0 {
+		summary.Operations = make(map[string]OperationSummary, len(snap.Operations))
+		for subType, operation := range snap.Operations {
+			summary.Operations[subType] = OperationSummary(operation)
+		}
+	}
+	return summary
+}
+
+// newLatencyMetrics converts a latency distribution to milliseconds
+func newLatencyMetrics(h *histogram.Histogram) LatencyMetrics {
+	return LatencyMetrics{
+		Count:     h.Count(),
+		MinMs:     milliseconds(h.Min()),
+		MeanMs:    milliseconds(h.Mean()),
+		P50Ms:     milliseconds(h.Percentile(50)),
+		P90Ms:     milliseconds(h.Percentile(90)),
+		P99Ms:     milliseconds(h.Percentile(99)),
+		P999Ms:    milliseconds(h.Percentile(99.9)),
+		MaxMs:     milliseconds(h.Max()),
+		Histogram: h,
+	}
+}
+
+// newWireMetrics converts wire statistics for the JSON output
+func newWireMetrics(w types.WireStats, now time.Time) WireMetrics {
+	return WireMetrics{
+		Messages:         w.Payload.Count,
+		WireBytes:        w.WireBytes,
+		PayloadBytes:     w.Payload.TotalBytes,
+		SavingPercent:    w.SavingPercent(),
+		ReadMs:           milliseconds(w.ReadTime),
+		ReadMsPerMessage: milliseconds(w.ReadTimePerMessage()),
+		MinBytes:         w.Payload.MinBytes,
+		MaxBytes:         w.Payload.MaxBytes,
+		AverageBytes:     stats.AveragePayloadSize(w.Payload),
+		BytesPerSecond:   stats.PayloadBytesPerSecond(w.Payload, now),
+		SizeBuckets:      sizeBuckets(w.Payload),
+		SentMessages:     w.SentMessages,
+		SentBytes:        w.SentBytes,
+	}
+}
+
+// sizeBuckets labels the payload size distribution for the JSON output
+func sizeBuckets(p types.PayloadStats) map[string]int {
+	buckets := make(map[string]int, len(p.SizeBuckets))
+	for i, count := range p.SizeBuckets {
+		buckets[sizeBucketLabel(i)] = count
+	}
+	return buckets
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,305 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/stats"
+	"github.com/commoddity/websocket-load-test/internal/terminal"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// TextReporter writes plain, append-only output: periodic status lines and a
+// plain summary at the end. It never emits colors, emojis or cursor movement,
+// so it is safe for CI logs, nohup and files.
+type TextReporter struct {
+	w        io.Writer
+	interval time.Duration
+	headers  []string
+	sort     ConnectionSort
+	last     time.Time
+}
+
+// NewText creates a plain-text reporter writing to w.
+// At most one status line is written per interval; 0 writes one per update.
+// The final summary lists the named upgrade response headers and the
+// per-connection table sorted by the given column, by index when empty.
+func NewText(w io.Writer, interval time.Duration, headers []string, sort ConnectionSort) *TextReporter {
+	if sort == "" {
+		sort = SortIndex
+	}
+	return &TextReporter{w: w, interval: interval, headers: headers, sort: sort}
+}
+
+// Update writes a single status line unless one was written less than an interval ago
+func (t *TextReporter) Update(snap stats.Snapshot) error {
+	if !t.last.IsZero() && snap.Time.Sub(t.last) < t.interval {
+		return nil
+	}
+	t.last = snap.Time
+
+	_, err := fmt.Fprintln(t.w, statusLine(snap))
+	return err
+}
+
+// statusLine summarises a snapshot on one line
+func statusLine(snap stats.Snapshot) string {
+	s := snap.Stats
+
+	state := "disconnected"
+	if snap.Connected() {
+		state = "connected"
+	}
+
+	line := fmt.Sprintf("%s %s conns=%d reconnects=%d attempts=%d subs=%d events=%d rate=%.2f/s errors=%d",
+		snap.Time.Format("15:04:05"), state, s.TotalConnections, s.TotalReconnections, s.ConnectionAttempts,
+		s.ActiveSubscriptions, s.EventsReceived, snap.OverallRate(), s.ErrorEvents)
+	for i, rate := range snap.EventRates() {
+		line += fmt.Sprintf(" rate_%s=%.2f/s", windowLabel(types.RateWindows[i]), rate)
+	}
+	if !s.LastEventTime.IsZero() {
+		line += fmt.Sprintf(" last_event=%v", snap.SinceLastEvent().Round(time.Second))
+	}
+	if len(snap.Connections) > 1 {
+		counts := snap.ConnectionStates()
+		var states []string
+		for _, state := range types.ConnectionStates {
+			if count := counts[state]; count > 0 {
+				states = append(states, fmt.Sprintf("%s:%d", state, count))
+			}
+		}
+		line += " states=" + strings.Join(states, ",")
+	}
+	if failures := snap.FailureCount(); failures > 0 {
+		line += fmt.Sprintf(" failures=%d last_failure=%s", failures, snap.LastFailure.Class)
+	}
+	if snap.Ping.Received > 0 {
+		line += fmt.Sprintf(" rtt=%v", snap.Ping.LastRTT.Round(time.Microsecond))
+	}
+	if snap.Calls.Sent > 0 {
+		line += fmt.Sprintf(" calls=%d call_p99=%v", snap.Calls.Sent, snap.Latency(types.LatencyCallRTTCorrected).Percentile(99).Round(time.Microsecond))
+	}
+	for _, stall := range snap.ActiveStalls {
+		line += fmt.Sprintf(" stalled=%s(%v)", stall.SubscriptionType, snap.Time.Sub(stall.StartTime).Round(time.Second))
+	}
+	for _, outage := range snap.CurrentOutages {
+		line += fmt.Sprintf(" down_for=%v", snap.Time.Sub(outage.StartTime).Round(time.Second))
+	}
+	return line
+}
+
+// Final writes the plain session summary
+func (t *TextReporter) Final(snap stats.Snapshot) error {
+	s := snap.Stats
+	var b strings.Builder
+
+	section := func(title string) {
+		fmt.Fprintf(&b, "\n%s\n", title)
+	}
+	field := func(name string, format string, args ...interface{}) {
+		fmt.Fprintf(&b, "  %-24s %s\n", name+":", fmt.Sprintf(format, args...))
+	}
+
+	b.WriteString("FINAL SESSION SUMMARY\n")
+	b.WriteString(strings.Repeat("=", 60) + "\n")
+
+	section("CONNECTIONS")
+	field("Total Connections", "%d", s.TotalConnections)
+	field("Reconnections", "%d", s.TotalReconnections)
+	field("Connection Attempts", "%d", s.ConnectionAttempts)
+	field("Subscriptions Created", "%d (%d requested)", s.SubscriptionsCreated, s.SubscriptionRequests)
+	field("Resubscriptions", "%d", s.Resubscriptions)
+	field("Active At Exit", "%d", s.ActiveSubscriptions)
+	field("Total Uptime", "%v", s.TotalUptime.Round(time.Second))
+	field("Total Runtime", "%v", snap.Runtime().Round(time.Second))
+
+	if len(snap.Connections) > 0 {
+		section(fmt.Sprintf("CONNECTION TABLE (sorted by %s)", t.sort))
+		field("States", "%s", formatConnectionStates(snap))
+		writeConnectionTable(&b, snap, t.sort, 0)
+	}
+
+	if len(snap.Outages) > 0 || len(snap.CurrentOutages) > 0 {
+		section("RECOVERY")
+		field("Outages", "%d", len(snap.Outages))
+		field("Total Downtime", "%v", snap.TotalDowntime().Round(time.Millisecond))
+		if len(snap.Outages) > 0 {
+			field("Mean Time To Recovery", "%v", snap.MeanTimeToRecovery().Round(time.Millisecond))
+			field("Longest Outage", "%v", snap.LongestOutage().Round(time.Millisecond))
+		}
+		for _, outage := range snap.CurrentOutages {
+			field("Unresolved Outage", "%v (%d attempts)", snap.Time.Sub(outage.StartTime).Round(time.Millisecond), outage.Attempts)
+		}
+	}
+
+	if ping := snap.Ping; ping.Sent > 0 {
+		section("HEARTBEAT")
+		field("Pings / Pongs", "%d / %d", ping.Sent, ping.Received)
+		if ping.Received > 0 {
+			field("Ping RTT", "%v avg, %v min, %v max", ping.AverageRTT().Round(time.Microsecond),
+				ping.MinRTT.Round(time.Microsecond), ping.MaxRTT.Round(time.Microsecond))
+		}
+		field("Pong Timeouts", "%d", ping.Timeouts)
+	}
+
+	if calls := snap.Calls; calls.Sent > 0 {
+		section("CALLS")
+		field("Calls Sent", "%d (%.2f/s)", calls.Sent, snap.CallRate())
+		field("Answered / Errors", "%d / %d", calls.Answered, calls.Errors)
+		field("Unanswered", "%d", calls.Unanswered)
+		field("Missed", "%d", calls.Missed)
+	}
+
+	if len(snap.Sequences) > 0 {
+		section("SEQUENCES")
+		for _, subType := range sortedKeys(snap.Sequences) {
+			sequence := snap.Sequences[subType]
+			field(subType, "highest %d, %d gaps (%d missed), %d repeated", sequence.Highest, sequence.Gaps, sequence.Missed, sequence.Repeated)
+		}
+	}
+
+	if finality := snap.Finality; finality.BestHead > 0 || finality.Finalized > 0 {
+		section("FINALITY")
+		field("Best Head", "#%d", finality.BestHead)
+		field("Finalized Head", "#%d", finality.Finalized)
+		if finality.Samples > 0 {
+			field("Finality Lag", "%d blocks (avg %.1f, max %d)", finality.LastLag, finality.MeanLag(), finality.MaxLag)
+		}
+	}
+
+	if len(snap.Operations) > 0 {
+		section("OPERATIONS")
+		for _, subType := range sortedKeys(snap.Operations) {
+			operation := snap.Operations[subType]
+			field(subType, "%d results, %d errors, %d completed", operation.Results, operation.Errors, operation.Completed)
+		}
+	}
+
+	if snap.FailureCount() > 0 {
+		section("FAILURES")
+		for _, class := range sortedKeys(snap.Failures) {
+			field(string(class), "%d", snap.Failures[class])
+		}
+		field("Last Failure", "%s at %s", snap.LastFailure.Reason, snap.LastFailure.Time.Format("15:04:05"))
+	}
+
+	if len(snap.Handshakes) > 0 {
+		section("HANDSHAKES")
+		field("Attempts", "%d", snap.HandshakeAttempts())
+		for _, phase := range types.HandshakePhases {
+			if latency := snap.HandshakeLatency(phase); latency.Count() > 0 {
+				field(phase, "%s", formatLatency(latency))
+			}
+		}
+	}
+
+	if slices.ContainsFunc(types.Latencies, func(name string) bool { return snap.Latency(name).Count() > 0 }) {
+		section("LATENCY")
+		for _, name := range types.Latencies {
+			if latency := snap.Latency(name); latency.Count() > 0 {
+				field(name, "%s (%d)", formatLatency(latency), latency.Count())
+			}
+		}
+	}
+
+	if sessions := snap.TLSSessions(); len(sessions) > 0 {
+		section("TLS SESSIONS")
+		for _, session := range sortedKeys(sessions) {
+			field(session, "%d", sessions[session])
+		}
+	}
+
+	if len(t.headers) > 0 && len(snap.UpgradeResponses) > 0 {
+		section("RESPONSE HEADERS")
+		for _, response := range snap.UpgradeResponses[max(len(snap.UpgradeResponses)-maxUpgradeResponses, 0):] {
+			fmt.Fprintf(&b, "  %s %s: %s\n", response.Time.Format("15:04:05"), responseLabel(response), formatHeaders(response, t.headers))
+		}
+	}
+
+	if snap.StallCount() > 0 {
+		section("STALLS")
+		field("Stalls Detected", "%d", snap.StallCount())
+		field("Time Stalled", "%v", snap.StalledTime().Round(time.Second))
+		byType := snap.StallsByType()
+		for _, subType := range sortedKeys(byType) {
+			field("  "+subType, "%d", byType[subType])
+		}
+		for _, stall := range snap.Stalls {
+			fmt.Fprintf(&b, "  %s on connection #%d: %v (%s to %s) %s\n", stall.SubscriptionType, stall.ConnectionNum,
+				stall.Duration.Round(time.Second), stall.StartTime.Format("15:04:05"), stall.EndTime.Format("15:04:05"), stall.Resolution)
+		}
+		for _, stall := range snap.ActiveStalls {
+			fmt.Fprintf(&b, "  %s on connection #%d: ongoing for %v\n", stall.SubscriptionType, stall.ConnectionNum,
+				snap.Time.Sub(stall.StartTime).Round(time.Second))
+		}
+	}
+
+	section("MESSAGES")
+	field("Total Messages", "%d", s.EventsReceived)
+	field("Subscription Events", "%d", s.SubscriptionEvents)
+	field("Confirmations", "%d", s.ConfirmationEvents)
+	field("Error Events", "%d", s.ErrorEvents)
+	field("Recent Rate", "%s", formatRates(snap.EventRates()))
+	field("Overall Rate", "%.2f/s", snap.OverallRate())
+	for _, subType := range sortedKeys(snap.MessagesByType) {
+		field(subType, "%d (%s, avg %.2f/s)", snap.MessagesByType[subType], formatRates(snap.TypeRates(subType)), snap.TypeRate(subType))
+	}
+
+	for _, subType := range sortedKeys(snap.TransactionStats) {
+		txStats := snap.TransactionStats[subType]
+		payload := txStats.Payload
+		section("TRANSACTIONS " + subType)
+		field("Transactions", "%d (%d decoded, %d failed, %d hash-only)", payload.Count, txStats.Decoded, txStats.DecodeErrors, txStats.HashOnly)
+		field("Bytes Received", "%s", terminal.FormatBytes(payload.TotalBytes))
+		field("Payload Size", "avg %s, min %s, max %s",
+			terminal.FormatBytes(int64(stats.AveragePayloadSize(payload))),
+			terminal.FormatBytes(int64(payload.MinBytes)),
+			terminal.FormatBytes(int64(payload.MaxBytes)))
+		field("Size Distribution", "%s", formatSizeDistribution(payload))
+	}
+
+	if s.BytesReceived > 0 || s.BytesSent > 0 {
+		section("THROUGHPUT")
+		field("Received", "%s (%s/sec)", terminal.FormatBytes(s.BytesReceived), terminal.FormatBytes(int64(snap.ReceiveRate())))
+		field("Sent", "%s (%s/sec)", terminal.FormatBytes(s.BytesSent), terminal.FormatBytes(int64(snap.SendRate())))
+		for _, messageType := range sortedKeys(snap.WireStats) {
+			wire := snap.WireStats[messageType]
+			payload := wire.Payload
+			field(messageType, "%s in %d msgs (%s/sec), avg %s, max %s, %d sent (%s)", terminal.FormatBytes(payload.TotalBytes),
+				payload.Count, terminal.FormatBytes(int64(stats.PayloadBytesPerSecond(payload, snap.Time))),
+				terminal.FormatBytes(int64(stats.AveragePayloadSize(payload))), terminal.FormatBytes(int64(payload.MaxBytes)),
+				wire.SentMessages, terminal.FormatBytes(wire.SentBytes))
+			field("  Size Distribution", "%s", formatSizeDistribution(payload))
+		}
+	}
+
+	if len(snap.WireStats) > 0 {
+		section("WIRE BYTES")
+		field("Compression", "%s", compressionLabel(snap))
+		total := snap.TotalWireStats()
+		field("Total", "%s wire, %s payload, %.1f%% saved", terminal.FormatBytes(total.WireBytes),
+			terminal.FormatBytes(total.Payload.TotalBytes), total.SavingPercent())
+		for _, messageType := range sortedKeys(snap.WireStats) {
+			wire := snap.WireStats[messageType]
+			field(messageType, "%s wire, %s payload, %.1f%% saved, %v read/msg", terminal.FormatBytes(wire.WireBytes),
+				terminal.FormatBytes(wire.Payload.TotalBytes), wire.SavingPercent(), wire.ReadTimePerMessage().Round(time.Microsecond))
+		}
+	}
+
+	section("PERFORMANCE")
+	field("Connection Event Rate", "%.2f events/sec", snap.ConnectionEventRate())
+	field("Overall Event Rate", "%.2f events/sec", snap.OverallRate())
+	field("Connection Reliability", "%.1f%%", snap.Reliability())
+	if s.EventsReceived > 0 {
+		field("Success Rate", "%.1f%%", snap.SuccessRate())
+	}
+	if s.TotalConnections > 1 {
+		field("Avg Connection Time", "%v", snap.AverageUptimePerConnection().Round(time.Second))
+	}
+
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
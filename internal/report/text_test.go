@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestText_Update(t *testing.T) {
+	var buf bytes.Buffer
+	text := NewText(&buf, 0, nil, "")
+	snap := testSnapshot(t)
+
+	for i := 0; i < 2; i++ {
+		if err := text.Update(snap); err != nil {
+			t.Fatalf("Update() unexpected error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want one per update: %q", len(lines), buf.String())
+	}
+	for _, want := range []string{"connected", "conns=2", "reconnects=1", "events=2", "failures=1 last_failure=http_5xx", "rtt=2ms", "stalled=newHeads(", "last_event=", "rate_10s=0.00/s rate_1m=0.00/s rate_5m=0.00/s", "calls=2 call_p99=10ms"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("status line %q missing %q", lines[0], want)
+		}
+	}
+}
+
+func TestText_Final(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewText(&buf, 0, testHeaders, SortIndex).Final(testSnapshot(t)); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Error("plain summary must not contain ANSI escape sequences")
+	}
+	for _, want := range []string{"FINAL SESSION SUMMARY", "Total Connections:", "Recent Rate:             10s 0.00/s", "newHeads:                1 (10s 0.00/s", "CONNECTION TABLE (sorted by index)", "1 stalled", "upgrade rejected: HTTP 503 Service Unavailable", "RECOVERY", "HEARTBEAT", "Pong Timeouts:", "STALLS", "FAILURES", "Last Failure:", "HANDSHAKES", "tcp_connect:", "LATENCY", "CALLS", "Answered / Errors:       1 / 0", "SEQUENCES", "slot:                    highest 104, 1 gaps (2 missed), 1 repeated", "FINALITY", "Best Head:               #201", "Finality Lag:            4 blocks (avg 3.5, max 4)", "OPERATIONS", "blocks:                  2 results, 1 errors, 1 completed", "call_rtt:                4ms p50, 4ms p90", "ping_rtt:                2ms p50, 2ms p90, 2ms p99, 2ms p99.9, 2ms max (1)", "finality_lag:            6s p50", "TLS SESSIONS", "TLS 1.3 TLS_AES_128_GCM_SHA256: 1", "RESPONSE HEADERS", "connection #1 HTTP 101: X-Request-Id=req-1", "rejected HTTP 503: Retry-After=5", "ongoing for", "TRANSACTIONS newPendingTransactions:full", "THROUGHPUT", "Received:                2.0 KB", "newHeads:                1000 B in 1 msgs", "Size Distribution:", "WIRE BYTES", "rpc:                     40 B wire, 40 B payload, 0.0% saved", "Success Rate:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plain summary missing %q", want)
+		}
+	}
+}
+
+func TestText_Final_StallsInSection(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewText(&buf, 0, nil, "").Final(testSnapshot(t)); err != nil {
+		t.Fatalf("Final() unexpected error: %v", err)
+	}
+
+	// Closed and active stall periods are listed inside the STALLS section
+	out := buf.String()
+	header := strings.Index(out, "FINAL SESSION SUMMARY")
+	stalls := strings.Index(out, "STALLS")
+	messages := strings.Index(out, "MESSAGES")
+	for _, want := range []string{"newHeads on connection #1: ", "newHeads on connection #2: ongoing for"} {
+		at := strings.Index(out, want)
+		if at < 0 || at < header || at < stalls || at > messages {
+			t.Errorf("stall line %q at %d, want it between STALLS (%d) and MESSAGES (%d) after the header (%d):\n%s",
+				want, at, stalls, messages, header, out)
+		}
+	}
+}
+
+func TestText_UpdateInterval(t *testing.T) {
+	var buf bytes.Buffer
+	text := NewText(&buf, 10*time.Second, nil, "")
+	snap := testSnapshot(t)
+
+	for _, offset := range []time.Duration{0, time.Second, 9 * time.Second, 10 * time.Second, 15 * time.Second, 21 * time.Second} {
+		at := snap
+		at.Time = snap.Time.Add(offset)
+		if err := text.Update(at); err != nil {
+			t.Fatalf("Update() unexpected error: %v", err)
+		}
+	}
+
+	// Lines at 0s, 10s and 21s
+	if got := strings.Count(buf.String(), "\n"); got != 3 {
+		t.Errorf("got %d status lines, want 3:\n%s", got, buf.String())
+	}
+}
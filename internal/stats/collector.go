@@ -0,0 +1,847 @@
+package stats
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/histogram"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// Collector records the statistics of a single connection slot.
+// Hot-path counters are atomics; the remaining state, including the rate
+// windows and per-type counts updated by HandleResponse on every message, is
+// guarded by a mutex. It is taken by the goroutines of the owning client,
+// such as its reader, heartbeat and call workload, and by Manager.Snapshot.
+// Each client owns one Collector so clients never contend with each other.
+type Collector struct {
+	manager *Manager
+	index   int
+
+	// notificationMethods are the methods of subscription notifications,
+	// set before any response is recorded; nil means eth_subscription
+	notificationMethods map[string]bool
+
+	// Hot-path counters
+	connectionAttempts  atomic.Int64
+	totalConnections    atomic.Int64
+	totalReconnections  atomic.Int64
+	eventsReceived      atomic.Int64
+	subscriptionEvents  atomic.Int64
+	confirmationEvents  atomic.Int64
+	errorEvents         atomic.Int64
+	currentConnMessages atomic.Int64
+	lastEventTime       atomic.Int64 // unix nanoseconds, 0 until the first event
+	pingsSent           atomic.Int64
+	bytesReceived       atomic.Int64
+	bytesSent           atomic.Int64
+
+	mu                   sync.Mutex
+	connected            bool
+	closed               bool // the client gave up and will not reconnect
+	currentConnStart     time.Time
+	connBytesReceived    int64 // bytesReceived when the current connection started
+	connBytesSent        int64 // bytesSent when the current connection started
+	totalUptime          time.Duration
+	longestConnection    time.Duration
+	shortestConnection   time.Duration
+	connectionHistory    []types.ConnectionHistory
+	outages              []types.Outage
+	currentOutage        *types.Outage
+	subIDToType          map[string]string
+	messagesByType       map[string]int
+	eventWindow          rateWindow             // every message
+	typeWindows          map[string]*rateWindow // notifications per subscription type
+	latestMessages       map[string]*types.LatestMessage
+	transactionStats     map[string]*types.TransactionStats
+	wireStats            map[string]*types.WireStats
+	subscriptionRequests int
+	subscriptionsCreated int
+	resubscriptions      int
+	ping                 types.PingStats
+	calls                types.CallStats
+	pingRTTs             []types.LatencySample // most recent maxLatencySamples round trips
+	lastNotification     map[string]time.Time  // per subscription type, on the current connection
+	activeStalls         map[string]*types.Stall
+	stalls               []types.Stall // resolved stalls
+	failures             map[types.FailureClass]int
+	lastFailure          types.Failure
+	handshakes           []types.HandshakeTiming          // most recent maxLatencySamples attempts
+	handshakesFailed     int                              // failed attempts over the whole run
+	upgradeResponses     []types.UpgradeResponse          // most recent maxLatencySamples responses
+	latencies            map[string]*histogram.Histogram  // every latency recorded, by name
+	sequences            map[string]*types.SequenceStats  // by subscription type
+	lastSequence         map[string]uint64                // by subscription ID, on the current connection
+	operations           map[string]*types.OperationStats // by subscription type
+	finality             types.FinalityStats
+	bestHead             uint64               // highest best head on the current connection, 0 until one arrives
+	finalizedHead        uint64               // highest finalized head on the current connection, 0 until one arrives
+	unfinalized          map[uint64]time.Time // best heads awaiting finality on the current connection, by number
+}
+
+// maxLatencySamples bounds each latency series kept per collector
+const maxLatencySamples = 1024
+
+// maxUnfinalizedHeads bounds the best heads awaiting finality per
+// connection, in case finalized heads never arrive
+const maxUnfinalizedHeads = 1024
+
+// newCollector creates a collector for the given connection slot
+func newCollector(manager *Manager, index int) *Collector {
+	return &Collector{
+		manager:          manager,
+		index:            index,
+		subIDToType:      make(map[string]string),
+		messagesByType:   make(map[string]int),
+		typeWindows:      make(map[string]*rateWindow),
+		latestMessages:   make(map[string]*types.LatestMessage),
+		transactionStats: make(map[string]*types.TransactionStats),
+		wireStats:        make(map[string]*types.WireStats),
+		lastNotification: make(map[string]time.Time),
+		activeStalls:     make(map[string]*types.Stall),
+		failures:         make(map[types.FailureClass]int),
+		latencies:        make(map[string]*histogram.Histogram),
+		sequences:        make(map[string]*types.SequenceStats),
+		lastSequence:     make(map[string]uint64),
+		operations:       make(map[string]*types.OperationStats),
+		unfinalized:      make(map[uint64]time.Time),
+	}
+}
+
+// Index returns the connection slot this collector records
+func (c *Collector) Index() int {
+	return c.index
+}
+
+// SetNotificationMethods sets the methods of the subscription notifications
+// the collector counts. It must be called before any response is recorded.
+func (c *Collector) SetNotificationMethods(methods []string) {
+	c.notificationMethods = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		c.notificationMethods[method] = true
+	}
+}
+
+// IsNotification reports whether method is the method of subscription notifications
+func (c *Collector) IsNotification(method string) bool {
+	if c.notificationMethods == nil {
+		return method == defaultNotificationMethod
+	}
+	return c.notificationMethods[method]
+}
+
+// defaultNotificationMethod is the method of eth_subscribe notifications
+const defaultNotificationMethod = "eth_subscription"
+
+// IncrementConnectionAttempts increments the connection attempts counter
+func (c *Collector) IncrementConnectionAttempts() {
+	c.connectionAttempts.Add(1)
+
+	c.mu.Lock()
+	if c.currentOutage != nil {
+		c.currentOutage.Attempts++
+	}
+	c.mu.Unlock()
+}
+
+// StartNewConnection starts tracking a new connection
+func (c *Collector) StartNewConnection() {
+	now := time.Now()
+
+	c.mu.Lock()
+	// A new connection resolves any ongoing outage
+	if c.currentOutage != nil {
+		c.currentOutage.EndTime = now
+		c.currentOutage.Duration = now.Sub(c.currentOutage.StartTime)
+		c.outages = append(c.outages, *c.currentOutage)
+		c.currentOutage = nil
+	}
+	c.connected = true
+	c.currentConnStart = now
+	c.connBytesReceived = c.bytesReceived.Load()
+	c.connBytesSent = c.bytesSent.Load()
+	c.currentConnMessages.Store(0)
+	c.totalConnections.Add(1)
+	c.mu.Unlock()
+}
+
+// IncrementReconnections increments the reconnection counter
+func (c *Collector) IncrementReconnections() {
+	if c.totalConnections.Load() > 0 {
+		c.totalReconnections.Add(1)
+	}
+}
+
+// EndConnection records the end of a connection and why it ended
+func (c *Collector) EndConnection(reason string) {
+	c.endConnection(types.Failure{Reason: reason})
+}
+
+// EndConnectionWithFailure records the end of a connection that failed
+func (c *Collector) EndConnectionWithFailure(failure types.Failure) {
+	c.endConnection(failure)
+}
+
+// MarkClosed records that the client of this slot gave up and will not reconnect
+func (c *Collector) MarkClosed() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+}
+
+// RecordDialFailure records a connection attempt that failed
+func (c *Collector) RecordDialFailure(failure types.Failure) {
+	c.mu.Lock()
+	c.recordFailure(&failure, time.Now())
+	c.mu.Unlock()
+}
+
+// recordFailure counts a classified failure. Callers must hold c.mu.
+func (c *Collector) recordFailure(failure *types.Failure, now time.Time) {
+	if failure.Class == "" {
+		return
+	}
+	failure.Time = now
+	c.failures[failure.Class]++
+	c.lastFailure = *failure
+}
+
+// endConnection implements EndConnection and EndConnectionWithFailure
+func (c *Collector) endConnection(failure types.Failure) {
+	c.mu.Lock()
+	if !c.connected {
+		c.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	c.recordFailure(&failure, now)
+	connectionDuration := now.Sub(c.currentConnStart)
+	c.totalUptime += connectionDuration
+	c.connected = false
+
+	// Record connection history
+	c.connectionHistory = append(c.connectionHistory, types.ConnectionHistory{
+		Slot:          c.index,
+		ConnectionNum: int(c.totalConnections.Load()),
+		StartTime:     c.currentConnStart,
+		EndTime:       now,
+		Duration:      connectionDuration,
+		Messages:      int(c.currentConnMessages.Load()),
+		BytesReceived: c.bytesReceived.Load() - c.connBytesReceived,
+		BytesSent:     c.bytesSent.Load() - c.connBytesSent,
+		Reason:        failure.Reason,
+		Class:         failure.Class,
+	})
+
+	// Update longest/shortest connection times
+	if c.longestConnection == 0 || connectionDuration > c.longestConnection {
+		c.longestConnection = connectionDuration
+	}
+	if c.shortestConnection == 0 || connectionDuration < c.shortestConnection {
+		c.shortestConnection = connectionDuration
+	}
+
+	// Stalls cannot outlive their connection
+	for subType := range c.activeStalls {
+		c.endStall(subType, now, types.StallConnectionClosed)
+	}
+	clear(c.lastNotification)
+	clear(c.lastSequence)
+	c.bestHead, c.finalizedHead = 0, 0
+	clear(c.unfinalized)
+
+	// The client is disconnected until the next connection succeeds
+	c.currentOutage = &types.Outage{StartTime: now}
+	c.mu.Unlock()
+}
+
+// HandleResponse processes a WebSocket response and updates statistics
+func (c *Collector) HandleResponse(response types.JSONRPCResponse) {
+	now := time.Now()
+	c.eventsReceived.Add(1)
+	c.currentConnMessages.Add(1)
+	c.lastEventTime.Store(now.UnixNano())
+
+	isSubscription := c.IsNotification(response.Method)
+	_, isCall := response.ID.(string) // workload calls use string IDs, subscribe requests numbers
+	switch {
+	case isSubscription:
+		c.subscriptionEvents.Add(1)
+	case response.Result != nil:
+		// Check if this is a subscription confirmation response
+		if _, ok := response.ID.(float64); ok {
+			c.confirmationEvents.Add(1)
+		}
+	case response.Error != nil:
+		c.errorEvents.Add(1)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.eventWindow.add(now)
+	logging := c.manager.loggingEnabled()
+	if !isSubscription && !logging {
+		return
+	}
+
+	// Extract subscription type from the subscription event
+	subscriptionType := ""
+	if isSubscription {
+		if params, ok := response.Params.(map[string]interface{}); ok {
+			if subscription, exists := params["subscription"]; exists {
+				subscriptionID, _ := types.SubscriptionID(subscription)
+				subscriptionType = c.subIDToType[subscriptionID]
+				if subscriptionType != "" {
+					c.countNotification(subscriptionType, now)
+					c.lastNotification[subscriptionType] = now
+					if _, stalled := c.activeStalls[subscriptionType]; stalled {
+						c.endStall(subscriptionType, now, types.StallResumed)
+					}
+				} else {
+					c.countNotification("unknown", now)
+				}
+			}
+		}
+	}
+
+	// Store latest message if logging is enabled
+	if logging {
+		messageType := "unknown"
+		logType := "general"
+
+		switch {
+		case isSubscription:
+			messageType = "subscription"
+			if subscriptionType != "" {
+				logType = subscriptionType
+			}
+		case response.Result != nil && isCall:
+			messageType = "response"
+			logType = "responses"
+		case response.Result != nil:
+			messageType = "confirmation"
+			logType = "confirmations"
+		case response.Error != nil:
+			messageType = "error"
+			logType = "errors"
+		}
+
+		c.latestMessages[logType] = &types.LatestMessage{
+			Content:     response,
+			ReceivedAt:  now,
+			MessageType: messageType,
+		}
+	}
+}
+
+// countNotification counts a notification of a subscription type. Callers must hold c.mu.
+func (c *Collector) countNotification(subType string, now time.Time) {
+	c.messagesByType[subType]++
+	window, exists := c.typeWindows[subType]
+	if !exists {
+		window = &rateWindow{}
+		c.typeWindows[subType] = window
+	}
+	window.add(now)
+}
+
+// RecordTransactionPayload records the size and decode outcome of a full transaction notification
+func (c *Collector) RecordTransactionPayload(subscriptionType string, size int, hashOnly bool, decodeErr error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	txStats, exists := c.transactionStats[subscriptionType]
+	if !exists {
+		txStats = &types.TransactionStats{}
+		c.transactionStats[subscriptionType] = txStats
+	}
+
+	recordPayload(&txStats.Payload, size, time.Now())
+
+	switch {
+	case decodeErr != nil:
+		txStats.DecodeErrors++
+	case hashOnly:
+		txStats.HashOnly++
+	default:
+		txStats.Decoded++
+	}
+}
+
+// RecordSequence records the number carried by a notification of the
+// subscription, counting jumps past the next number as gaps
+func (c *Collector) RecordSequence(subscriptionID, subscriptionType string, number uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sequence, exists := c.sequences[subscriptionType]
+	if !exists {
+		sequence = &types.SequenceStats{}
+		c.sequences[subscriptionType] = sequence
+	}
+	sequence.Received++
+	sequence.Highest = max(sequence.Highest, number)
+
+	last, seen := c.lastSequence[subscriptionID]
+	switch {
+	case !seen:
+	case number <= last:
+		sequence.Repeated++
+		return
+	case number > last+1:
+		sequence.Gaps++
+		sequence.Missed += number - last - 1
+	}
+	c.lastSequence[subscriptionID] = number
+}
+
+// RecordHead records a new best or finalized head and, once the connection
+// has seen both, how many blocks the finalized head trails the best one by.
+// A finalized head also records how long ago every best head it finalized
+// arrived as the finality lag latency.
+func (c *Collector) RecordHead(number uint64, finalized bool) {
+	c.recordHead(number, finalized, time.Now())
+}
+
+// recordHead implements RecordHead at the given instant
+func (c *Collector) recordHead(number uint64, finalized bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trackFinalization(number, finalized, now)
+	if finalized {
+		c.finalizedHead = max(c.finalizedHead, number)
+		c.finality.Finalized = max(c.finality.Finalized, number)
+	} else {
+		c.bestHead = max(c.bestHead, number)
+		c.finality.BestHead = max(c.finality.BestHead, number)
+	}
+	if c.bestHead == 0 || c.finalizedHead == 0 {
+		return
+	}
+
+	// A best head subscription running behind cannot make the lag negative
+	lag := uint64(0)
+	if c.bestHead > c.finalizedHead {
+		lag = c.bestHead - c.finalizedHead
+	}
+	c.finality.Samples++
+	c.finality.LastLag = lag
+	c.finality.MaxLag = max(c.finality.MaxLag, lag)
+	c.finality.TotalLag += lag
+}
+
+// trackFinalization remembers when best heads arrive until a finalized head
+// covers them. Callers must hold c.mu.
+func (c *Collector) trackFinalization(number uint64, finalized bool, now time.Time) {
+	if finalized {
+		for head, arrived := range c.unfinalized {
+			if head <= number {
+				c.recordLatency(types.LatencyFinalityLag, now.Sub(arrived))
+				delete(c.unfinalized, head)
+			}
+		}
+		return
+	}
+
+	// A repeated head, e.g. after a reorganisation, keeps its first arrival
+	if _, seen := c.unfinalized[number]; seen || number <= c.finalizedHead {
+		return
+	}
+	if len(c.unfinalized) == maxUnfinalizedHeads {
+		delete(c.unfinalized, slices.Min(slices.Collect(maps.Keys(c.unfinalized))))
+	}
+	c.unfinalized[number] = now
+}
+
+// RecordOperation counts a message about a GraphQL operation of the
+// subscription type
+func (c *Collector) RecordOperation(subscriptionType string, event types.OperationEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	operation, exists := c.operations[subscriptionType]
+	if !exists {
+		operation = &types.OperationStats{}
+		c.operations[subscriptionType] = operation
+	}
+	switch event {
+	case types.OperationResult:
+		operation.Results++
+	case types.OperationError:
+		operation.Errors++
+	case types.OperationComplete:
+		operation.Completed++
+	}
+}
+
+// RecordBytesReceived counts bytes read from the connection
+func (c *Collector) RecordBytesReceived(n int) {
+	c.bytesReceived.Add(int64(n))
+}
+
+// RecordBytesSent counts bytes written to the connection
+func (c *Collector) RecordBytesSent(n int) {
+	c.bytesSent.Add(int64(n))
+}
+
+// RecordWireMessage records the wire and decompressed payload size of a
+// received message and how long its payload took to read
+func (c *Collector) RecordWireMessage(messageType string, wireBytes, payloadBytes int, readTime time.Duration) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wire := c.wireStatsFor(messageType)
+	recordPayload(&wire.Payload, payloadBytes, now)
+	wire.WireBytes += int64(wireBytes)
+	wire.ReadTime += readTime
+}
+
+// RecordSentMessage records the payload size of a request sent for a message type
+func (c *Collector) RecordSentMessage(messageType string, payloadBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wire := c.wireStatsFor(messageType)
+	wire.SentMessages++
+	wire.SentBytes += int64(payloadBytes)
+}
+
+// wireStatsFor returns the wire statistics of a message type, creating them
+// if needed. Callers must hold c.mu.
+func (c *Collector) wireStatsFor(messageType string) *types.WireStats {
+	wire, exists := c.wireStats[messageType]
+	if !exists {
+		wire = &types.WireStats{}
+		c.wireStats[messageType] = wire
+	}
+	return wire
+}
+
+// RecordHandshake records the phase timings of a connection attempt
+func (c *Collector) RecordHandshake(timing types.HandshakeTiming) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.handshakes) == maxLatencySamples {
+		c.handshakes = append(c.handshakes[:0], c.handshakes[1:]...)
+	}
+	c.handshakes = append(c.handshakes, timing)
+	if timing.Failed {
+		c.handshakesFailed++
+	}
+	for _, phase := range types.HandshakePhases {
+		if d := timing.Phase(phase); d > 0 {
+			c.recordLatency(types.HandshakeLatency(phase), d)
+		}
+	}
+}
+
+// RecordUpgradeResponse records the response to an upgrade request. Responses
+// that arrive while connected belong to the current connection.
+func (c *Collector) RecordUpgradeResponse(response types.UpgradeResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connected {
+		response.ConnectionNum = int(c.totalConnections.Load())
+	}
+	if len(c.upgradeResponses) == maxLatencySamples {
+		c.upgradeResponses = append(c.upgradeResponses[:0], c.upgradeResponses[1:]...)
+	}
+	c.upgradeResponses = append(c.upgradeResponses, response)
+}
+
+// RecordPingSent counts a ping sent to the server
+func (c *Collector) RecordPingSent() {
+	c.pingsSent.Add(1)
+}
+
+// RecordPong records the round-trip time of a pong received from the server
+func (c *Collector) RecordPong(rtt time.Duration) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ping.Received == 0 || rtt < c.ping.MinRTT {
+		c.ping.MinRTT = rtt
+	}
+	c.ping.MaxRTT = max(c.ping.MaxRTT, rtt)
+	c.ping.Received++
+	c.ping.TotalRTT += rtt
+	c.ping.LastRTT = rtt
+
+	if len(c.pingRTTs) == maxLatencySamples {
+		c.pingRTTs = append(c.pingRTTs[:0], c.pingRTTs[1:]...)
+	}
+	c.pingRTTs = append(c.pingRTTs, types.LatencySample{
+		ConnectionNum: int(c.totalConnections.Load()),
+		Time:          now,
+		Latency:       rtt,
+	})
+	c.recordLatency(types.LatencyPingRTT, rtt)
+}
+
+// RecordCallSent counts a workload call sent to the server
+func (c *Collector) RecordCallSent() {
+	c.mu.Lock()
+	c.calls.Sent++
+	c.mu.Unlock()
+}
+
+// RecordCallResponse records the response to a workload call. rtt is measured
+// from when the call was sent and corrected from when it was due to be sent.
+func (c *Collector) RecordCallResponse(failed bool, rtt, corrected time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if failed {
+		c.calls.Errors++
+	} else {
+		c.calls.Answered++
+	}
+	c.recordLatency(types.LatencyCallRTT, rtt)
+	c.recordLatency(types.LatencyCallRTTCorrected, corrected)
+}
+
+// RecordCallsUnanswered counts workload calls that were still awaiting a
+// response when their connection closed
+func (c *Collector) RecordCallsUnanswered(n int) {
+	c.mu.Lock()
+	c.calls.Unanswered += n
+	c.mu.Unlock()
+}
+
+// RecordCallsMissed counts workload calls that fell due while the client was
+// disconnected and were skipped. How long each had been due when the next
+// connection came up is recorded as its corrected latency, so an outage
+// still shows in the tail.
+func (c *Collector) RecordCallsMissed(overdue []time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls.Missed += len(overdue)
+	for _, d := range overdue {
+		c.recordLatency(types.LatencyCallRTTCorrected, d)
+	}
+}
+
+// RecordLatency records a latency into the named distribution, such as types.LatencySubscribeConfirm
+func (c *Collector) RecordLatency(name string, d time.Duration) {
+	c.mu.Lock()
+	c.recordLatency(name, d)
+	c.mu.Unlock()
+}
+
+// recordLatency implements RecordLatency. Callers must hold c.mu.
+func (c *Collector) recordLatency(name string, d time.Duration) {
+	latency, exists := c.latencies[name]
+	if !exists {
+		latency = histogram.New()
+		c.latencies[name] = latency
+	}
+	latency.Record(d)
+}
+
+// RecordPongTimeout counts a connection dropped because a pong did not arrive in time
+func (c *Collector) RecordPongTimeout() {
+	c.mu.Lock()
+	c.ping.Timeouts++
+	c.mu.Unlock()
+}
+
+// SetSubscriptionMapping sets the mapping between subscription ID and type.
+// The first subscription of a type on a connection starts its stall clock.
+func (c *Collector) SetSubscriptionMapping(subscriptionID, subscriptionType string) {
+	c.mu.Lock()
+	c.subIDToType[subscriptionID] = subscriptionType
+	if _, ok := c.lastNotification[subscriptionType]; !ok && c.connected {
+		c.lastNotification[subscriptionType] = time.Now()
+	}
+	c.mu.Unlock()
+}
+
+// CheckStalls flags subscription types on the current connection that have
+// been silent for longer than their threshold and returns the new stalls
+func (c *Collector) CheckStalls(thresholds map[string]time.Duration) []types.Stall {
+	return c.checkStalls(time.Now(), thresholds)
+}
+
+// checkStalls implements CheckStalls at the given instant
+func (c *Collector) checkStalls(now time.Time, thresholds map[string]time.Duration) []types.Stall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var detected []types.Stall
+	for subType, last := range c.lastNotification {
+		threshold, ok := thresholds[subType]
+		if !ok || now.Sub(last) <= threshold {
+			continue
+		}
+		if _, stalled := c.activeStalls[subType]; stalled {
+			continue
+		}
+
+		stall := &types.Stall{
+			ConnectionNum:    int(c.totalConnections.Load()),
+			SubscriptionType: subType,
+			Threshold:        threshold,
+			StartTime:        last,
+			DetectedAt:       now,
+		}
+		c.activeStalls[subType] = stall
+		detected = append(detected, *stall)
+	}
+
+	sort.Slice(detected, func(i, j int) bool {
+		return detected[i].SubscriptionType < detected[j].SubscriptionType
+	})
+	return detected
+}
+
+// endStall resolves the active stall of a subscription type. Callers must hold c.mu.
+func (c *Collector) endStall(subscriptionType string, now time.Time, resolution string) {
+	stall := c.activeStalls[subscriptionType]
+	stall.EndTime = now
+	stall.Duration = now.Sub(stall.StartTime)
+	stall.Resolution = resolution
+	c.stalls = append(c.stalls, *stall)
+	delete(c.activeStalls, subscriptionType)
+}
+
+// RetireSubscription forgets a subscription ID that is no longer active,
+// typically because the connection that created it has closed
+func (c *Collector) RetireSubscription(subscriptionID string) {
+	c.mu.Lock()
+	delete(c.subIDToType, subscriptionID)
+	c.mu.Unlock()
+}
+
+// RecordSubscriptionRequest counts a subscribe request sent to the server
+func (c *Collector) RecordSubscriptionRequest() {
+	c.mu.Lock()
+	c.subscriptionRequests++
+	c.mu.Unlock()
+}
+
+// RecordSubscriptionCreated counts a subscription confirmed by the server.
+// resubscription marks subscriptions re-created after a reconnect.
+func (c *Collector) RecordSubscriptionCreated(resubscription bool) {
+	c.mu.Lock()
+	c.subscriptionsCreated++
+	if resubscription {
+		c.resubscriptions++
+	}
+	c.mu.Unlock()
+}
+
+// snapshot copies the collector state at the given instant
+func (c *Collector) snapshot(now time.Time) ConnectionSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snap := ConnectionSnapshot{
+		Index:     c.index,
+		Connected: c.connected,
+		Closed:    c.closed,
+		Stats: types.Stats{
+			TotalConnections:     int(c.totalConnections.Load()),
+			TotalReconnections:   int(c.totalReconnections.Load()),
+			CurrentConnStart:     c.currentConnStart,
+			TotalUptime:          c.totalUptime,
+			EventsReceived:       int(c.eventsReceived.Load()),
+			SubscriptionEvents:   int(c.subscriptionEvents.Load()),
+			ConfirmationEvents:   int(c.confirmationEvents.Load()),
+			ErrorEvents:          int(c.errorEvents.Load()),
+			ConnectionAttempts:   int(c.connectionAttempts.Load()),
+			CurrentConnMessages:  int(c.currentConnMessages.Load()),
+			LongestConnection:    c.longestConnection,
+			ShortestConnection:   c.shortestConnection,
+			SubscriptionRequests: c.subscriptionRequests,
+			ActiveSubscriptions:  len(c.subIDToType),
+			SubscriptionsCreated: c.subscriptionsCreated,
+			Resubscriptions:      c.resubscriptions,
+			BytesReceived:        c.bytesReceived.Load(),
+			BytesSent:            c.bytesSent.Load(),
+		},
+		ConnectionHistory: append([]types.ConnectionHistory(nil), c.connectionHistory...),
+		Outages:           append([]types.Outage(nil), c.outages...),
+		Ping:              c.ping,
+		Calls:             c.calls,
+		Finality:          c.finality,
+		PingRTTs:          append([]types.LatencySample(nil), c.pingRTTs...),
+		Stalls:            append([]types.Stall(nil), c.stalls...),
+		Failures:          make(map[types.FailureClass]int, len(c.failures)),
+		LastFailure:       c.lastFailure,
+		Handshakes:        append([]types.HandshakeTiming(nil), c.handshakes...),
+		HandshakesFailed:  c.handshakesFailed,
+		UpgradeResponses:  append([]types.UpgradeResponse(nil), c.upgradeResponses...),
+		MessagesByType:    make(map[string]int, len(c.messagesByType)),
+		EventWindows:      c.eventWindow.windowCounts(now),
+		TypeWindows:       make(map[string][]int, len(c.typeWindows)),
+		LatestMessages:    make(map[string]types.LatestMessage, len(c.latestMessages)),
+		TransactionStats:  make(map[string]types.TransactionStats, len(c.transactionStats)),
+		WireStats:         make(map[string]types.WireStats, len(c.wireStats)),
+		Latencies:         make(map[string]*histogram.Histogram, len(c.latencies)),
+		Sequences:         make(map[string]types.SequenceStats, len(c.sequences)),
+		Operations:        make(map[string]types.OperationStats, len(c.operations)),
+	}
+
+	snap.Ping.Sent = int(c.pingsSent.Load())
+
+	// Uptime includes the connection that is still open
+	if c.connected {
+		snap.Stats.TotalUptime += now.Sub(c.currentConnStart)
+	}
+	if nanos := c.lastEventTime.Load(); nanos != 0 {
+		snap.Stats.LastEventTime = time.Unix(0, nanos)
+	}
+	if c.currentOutage != nil {
+		outage := *c.currentOutage
+		snap.CurrentOutage = &outage
+	}
+	for class, count := range c.failures {
+		snap.Failures[class] = count
+	}
+	for _, stall := range c.activeStalls {
+		snap.ActiveStalls = append(snap.ActiveStalls, *stall)
+	}
+	for subType, count := range c.messagesByType {
+		snap.MessagesByType[subType] = count
+	}
+	for subType, window := range c.typeWindows {
+		snap.TypeWindows[subType] = window.windowCounts(now)
+	}
+	for subType, msg := range c.latestMessages {
+		snap.LatestMessages[subType] = *msg
+	}
+	for subType, txStats := range c.transactionStats {
+		copied := *txStats
+		copied.Payload.SizeBuckets = append([]int(nil), txStats.Payload.SizeBuckets...)
+		snap.TransactionStats[subType] = copied
+	}
+	for messageType, wire := range c.wireStats {
+		copied := *wire
+		copied.Payload.SizeBuckets = append([]int(nil), wire.Payload.SizeBuckets...)
+		snap.WireStats[messageType] = copied
+	}
+	for name, latency := range c.latencies {
+		snap.Latencies[name] = latency.Clone()
+	}
+	for subType, sequence := range c.sequences {
+		snap.Sequences[subType] = *sequence
+	}
+	for subType, operation := range c.operations {
+		snap.Operations[subType] = *operation
+	}
+	return snap
+}
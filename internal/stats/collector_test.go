@@ -0,0 +1,722 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// subscriptionEvent builds an eth_subscription notification for subID
+func subscriptionEvent(subID string) types.JSONRPCResponse {
+	return types.JSONRPCResponse{
+		Method: "eth_subscription",
+		Params: map[string]interface{}{
+			"subscription": subID,
+			"result":       map[string]interface{}{"number": "0x1"},
+		},
+	}
+}
+
+func TestManager_ConcurrentCollectors(t *testing.T) {
+	const (
+		connections   = 8
+		eventsPerConn = 20000
+	)
+
+	manager := NewManager()
+	manager.EnableLogging()
+
+	// A reader keeps taking snapshots while writers record events
+	stop := make(chan struct{})
+	var readerWG sync.WaitGroup
+	readerWG.Add(1)
+	go func() {
+		defer readerWG.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				snap := manager.Snapshot()
+				_ = snap.TotalDowntime()
+				_ = snap.MeanTimeToRecovery()
+			}
+		}
+	}()
+
+	var writerWG sync.WaitGroup
+	for i := 0; i < connections; i++ {
+		collector := manager.NewCollector()
+		writerWG.Add(1)
+		go func(collector *Collector) {
+			defer writerWG.Done()
+
+			subID := fmt.Sprintf("0x%d", collector.Index())
+			collector.IncrementConnectionAttempts()
+			collector.StartNewConnection()
+			collector.RecordSubscriptionRequest()
+			collector.HandleResponse(types.JSONRPCResponse{ID: float64(1), Result: subID})
+			collector.SetSubscriptionMapping(subID, "newHeads")
+			collector.RecordSubscriptionCreated(false)
+
+			for j := 0; j < eventsPerConn; j++ {
+				collector.HandleResponse(subscriptionEvent(subID))
+				if j%1000 == 0 {
+					collector.RecordTransactionPayload("newPendingTransactions:full", 1024, false, nil)
+				}
+			}
+
+			collector.EndConnection("closed")
+			collector.RetireSubscription(subID)
+		}(collector)
+	}
+
+	writerWG.Wait()
+	close(stop)
+	readerWG.Wait()
+
+	snap := manager.Snapshot()
+	stats := snap.Stats
+	if stats.TotalConnections != connections {
+		t.Errorf("TotalConnections = %d, want %d", stats.TotalConnections, connections)
+	}
+	if stats.SubscriptionEvents != connections*eventsPerConn {
+		t.Errorf("SubscriptionEvents = %d, want %d", stats.SubscriptionEvents, connections*eventsPerConn)
+	}
+	if stats.EventsReceived != connections*(eventsPerConn+1) {
+		t.Errorf("EventsReceived = %d, want %d", stats.EventsReceived, connections*(eventsPerConn+1))
+	}
+	if stats.ConfirmationEvents != connections {
+		t.Errorf("ConfirmationEvents = %d, want %d", stats.ConfirmationEvents, connections)
+	}
+	if stats.SubscriptionsCreated != connections || stats.ActiveSubscriptions != 0 {
+		t.Errorf("SubscriptionsCreated/Active = %d/%d, want %d/0",
+			stats.SubscriptionsCreated, stats.ActiveSubscriptions, connections)
+	}
+	if got := snap.MessagesByType["newHeads"]; got != connections*eventsPerConn {
+		t.Errorf("MessagesByType[newHeads] = %d, want %d", got, connections*eventsPerConn)
+	}
+	if got := snap.TransactionStats["newPendingTransactions:full"].Payload.Count; got != connections*eventsPerConn/1000 {
+		t.Errorf("TransactionStats payload count = %d, want %d", got, connections*eventsPerConn/1000)
+	}
+	if len(snap.ConnectionHistory) != connections || len(snap.Connections) != connections {
+		t.Errorf("ConnectionHistory/Connections = %d/%d, want %d", len(snap.ConnectionHistory), len(snap.Connections), connections)
+	}
+	if len(snap.CurrentOutages) != connections {
+		t.Errorf("CurrentOutages = %d, want %d", len(snap.CurrentOutages), connections)
+	}
+}
+
+func TestManager_SnapshotMergesCollectors(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	if first.Index() != 0 || second.Index() != 1 {
+		t.Fatalf("collector indexes = %d/%d, want 0/1", first.Index(), second.Index())
+	}
+
+	first.StartNewConnection()
+	time.Sleep(2 * time.Millisecond)
+	second.StartNewConnection()
+
+	first.SetSubscriptionMapping("0xa", "newHeads")
+	second.SetSubscriptionMapping("0xb", "logs")
+	first.HandleResponse(subscriptionEvent("0xa"))
+	second.HandleResponse(subscriptionEvent("0xb"))
+	second.HandleResponse(subscriptionEvent("0xb"))
+
+	time.Sleep(time.Millisecond)
+	second.EndConnection("closed")
+
+	snap := manager.Snapshot()
+	if len(snap.Connections) != 2 {
+		t.Fatalf("Connections = %d, want 2", len(snap.Connections))
+	}
+	if !snap.Connected() {
+		t.Error("Connected() should be true while one connection is open")
+	}
+	if !snap.Connections[0].Connected || snap.Connections[1].Connected {
+		t.Error("per-connection Connected flags are wrong")
+	}
+	if snap.Stats.TotalConnections != 2 || snap.Stats.SubscriptionEvents != 3 {
+		t.Errorf("TotalConnections/SubscriptionEvents = %d/%d, want 2/3",
+			snap.Stats.TotalConnections, snap.Stats.SubscriptionEvents)
+	}
+	if snap.Stats.CurrentConnStart != snap.Connections[0].Stats.CurrentConnStart {
+		t.Error("aggregate CurrentConnStart should be the oldest open connection")
+	}
+	if snap.Stats.LastEventTime != snap.Connections[1].Stats.LastEventTime {
+		t.Error("aggregate LastEventTime should be the most recent event")
+	}
+	if snap.MessagesByType["newHeads"] != 1 || snap.MessagesByType["logs"] != 2 {
+		t.Errorf("MessagesByType = %v", snap.MessagesByType)
+	}
+	if snap.Stats.ActiveSubscriptions != 2 {
+		t.Errorf("ActiveSubscriptions = %d, want 2", snap.Stats.ActiveSubscriptions)
+	}
+
+	// Uptime of the open connection is included up to the snapshot time
+	if snap.Stats.TotalUptime < snap.Connections[0].Stats.TotalUptime {
+		t.Error("TotalUptime should include the open connection")
+	}
+}
+
+func TestSnapshot_Immutable(t *testing.T) {
+	manager := NewManager()
+	manager.EnableLogging()
+	collector := manager.NewCollector()
+
+	collector.StartNewConnection()
+	collector.SetSubscriptionMapping("0xa", "newHeads")
+	collector.HandleResponse(subscriptionEvent("0xa"))
+	collector.RecordTransactionPayload("newPendingTransactions:full", 700, false, nil)
+
+	snap := manager.Snapshot()
+
+	// Later events must not leak into an existing snapshot
+	collector.HandleResponse(subscriptionEvent("0xa"))
+	collector.RecordTransactionPayload("newPendingTransactions:full", 700, false, nil)
+	collector.EndConnection("closed")
+
+	if snap.Stats.SubscriptionEvents != 1 || snap.MessagesByType["newHeads"] != 1 {
+		t.Errorf("snapshot changed after later events: %d events, %v", snap.Stats.SubscriptionEvents, snap.MessagesByType)
+	}
+	if snap.TransactionStats["newPendingTransactions:full"].Payload.Count != 1 {
+		t.Error("snapshot transaction stats changed after later payloads")
+	}
+	if len(snap.ConnectionHistory) != 0 || !snap.Connected() {
+		t.Error("snapshot connection state changed after EndConnection")
+	}
+
+	// Mutating a snapshot must not affect the collector
+	snap.MessagesByType["newHeads"] = 100
+	snap.TransactionStats["newPendingTransactions:full"].Payload.SizeBuckets[1] = 100
+	next := manager.Snapshot()
+	if next.MessagesByType["newHeads"] != 2 {
+		t.Errorf("MessagesByType[newHeads] = %d, want 2", next.MessagesByType["newHeads"])
+	}
+	if next.TransactionStats["newPendingTransactions:full"].Payload.SizeBuckets[1] != 2 {
+		t.Error("snapshot shares payload buckets with the collector")
+	}
+	if _, ok := next.LatestMessages["newHeads"]; !ok {
+		t.Error("LatestMessages should hold the latest newHeads message")
+	}
+}
+
+func TestCollector_EndConnectionWithoutStart(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+
+	collector.EndConnection("closed")
+
+	snap := manager.Snapshot()
+	if len(snap.ConnectionHistory) != 0 || len(snap.CurrentOutages) != 0 {
+		t.Error("EndConnection() before StartNewConnection() should be a no-op")
+	}
+}
+
+// BenchmarkCollector_HandleResponseParallel measures event throughput with one
+// collector per goroutine, as used by concurrent connections
+func BenchmarkCollector_HandleResponseParallel(b *testing.B) {
+	manager := NewManager()
+	response := subscriptionEvent("0xa")
+
+	start := time.Now()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		collector := manager.NewCollector()
+		collector.SetSubscriptionMapping("0xa", "newHeads")
+		for pb.Next() {
+			collector.HandleResponse(response)
+		}
+	})
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "events/sec")
+}
+
+// BenchmarkCollector_HandleResponseWithSnapshots measures event throughput
+// while a renderer takes a snapshot every millisecond
+func BenchmarkCollector_HandleResponseWithSnapshots(b *testing.B) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+	collector.SetSubscriptionMapping("0xa", "newHeads")
+	response := subscriptionEvent("0xa")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				manager.Snapshot()
+			}
+		}
+	}()
+
+	start := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		collector.HandleResponse(response)
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "events/sec")
+
+	close(stop)
+	wg.Wait()
+}
+
+func BenchmarkManager_Snapshot(b *testing.B) {
+	manager := NewManager()
+	for i := 0; i < 64; i++ {
+		collector := manager.NewCollector()
+		collector.StartNewConnection()
+		collector.SetSubscriptionMapping("0xa", "newHeads")
+		collector.HandleResponse(subscriptionEvent("0xa"))
+		collector.EndConnection("closed")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager.Snapshot()
+	}
+}
+
+func TestCollector_PingStats(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.StartNewConnection()
+	second.StartNewConnection()
+
+	for _, rtt := range []time.Duration{30 * time.Millisecond, 10 * time.Millisecond} {
+		first.RecordPingSent()
+		first.RecordPong(rtt)
+	}
+	second.RecordPingSent()
+	second.RecordPingSent()
+	second.RecordPong(50 * time.Millisecond)
+	second.RecordPongTimeout()
+	second.EndConnection("pong timeout")
+
+	snap := manager.Snapshot()
+	want := types.PingStats{
+		Sent:     4,
+		Received: 3,
+		Timeouts: 1,
+		MinRTT:   10 * time.Millisecond,
+		MaxRTT:   50 * time.Millisecond,
+		TotalRTT: 90 * time.Millisecond,
+		LastRTT:  50 * time.Millisecond,
+	}
+	if snap.Ping != want {
+		t.Errorf("Ping = %+v, want %+v", snap.Ping, want)
+	}
+	if snap.Connections[0].Ping.LastRTT != 10*time.Millisecond {
+		t.Errorf("first connection LastRTT = %v, want 10ms", snap.Connections[0].Ping.LastRTT)
+	}
+	if len(snap.PingRTTs) != 3 {
+		t.Errorf("PingRTTs has %d samples, want 3", len(snap.PingRTTs))
+	}
+	if got := snap.ConnectionHistory[0].Reason; got != "pong timeout" {
+		t.Errorf("Reason = %q, want %q", got, "pong timeout")
+	}
+}
+
+func TestCollector_PingSeriesIsBounded(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+	collector.StartNewConnection()
+
+	for i := 0; i < maxLatencySamples+10; i++ {
+		collector.RecordPong(time.Duration(i))
+	}
+
+	snap := manager.Snapshot()
+	if len(snap.PingRTTs) != maxLatencySamples {
+		t.Fatalf("PingRTTs has %d samples, want %d", len(snap.PingRTTs), maxLatencySamples)
+	}
+	if snap.PingRTTs[0].Latency != 10 {
+		t.Errorf("oldest sample = %v, want the oldest samples dropped first", snap.PingRTTs[0].Latency)
+	}
+	if snap.Ping.Received != maxLatencySamples+10 {
+		t.Errorf("Ping.Received = %d, want every pong counted", snap.Ping.Received)
+	}
+}
+
+func TestCollector_Stalls(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+	thresholds := map[string]time.Duration{"newHeads": 10 * time.Second}
+
+	collector.StartNewConnection()
+	collector.SetSubscriptionMapping("0xa", "newHeads")
+	collector.SetSubscriptionMapping("0xb", "logs")
+	start := time.Now()
+
+	if got := collector.checkStalls(start.Add(5*time.Second), thresholds); len(got) != 0 {
+		t.Fatalf("checkStalls() before the threshold = %+v, want none", got)
+	}
+
+	detected := collector.checkStalls(start.Add(11*time.Second), thresholds)
+	if len(detected) != 1 || detected[0].SubscriptionType != "newHeads" || detected[0].Threshold != 10*time.Second {
+		t.Fatalf("checkStalls() = %+v, want one newHeads stall", detected)
+	}
+	if got := collector.checkStalls(start.Add(12*time.Second), thresholds); len(got) != 0 {
+		t.Errorf("an ongoing stall was reported again: %+v", got)
+	}
+	if snap := manager.Snapshot(); len(snap.ActiveStalls) != 1 || snap.StallCount() != 1 {
+		t.Errorf("ActiveStalls = %+v, want the ongoing stall", snap.ActiveStalls)
+	}
+
+	// A notification resolves the stall
+	collector.HandleResponse(subscriptionEvent("0xa"))
+	snap := manager.Snapshot()
+	if len(snap.ActiveStalls) != 0 || len(snap.Stalls) != 1 || snap.Stalls[0].Resolution != types.StallResumed {
+		t.Fatalf("Stalls = %+v, ActiveStalls = %+v, want one resumed stall", snap.Stalls, snap.ActiveStalls)
+	}
+
+	// Closing the connection resolves an ongoing stall
+	collector.checkStalls(time.Now().Add(time.Minute), thresholds)
+	collector.EndConnection("closed")
+	snap = manager.Snapshot()
+	if len(snap.ActiveStalls) != 0 || len(snap.Stalls) != 2 || snap.Stalls[1].Resolution != types.StallConnectionClosed {
+		t.Fatalf("Stalls = %+v, want a second stall ended by the closed connection", snap.Stalls)
+	}
+	if got := snap.StallsByType(); got["newHeads"] != 2 || len(got) != 1 {
+		t.Errorf("StallsByType() = %v, want newHeads: 2", got)
+	}
+
+	// Disconnected subscriptions do not stall
+	if got := collector.checkStalls(time.Now().Add(time.Hour), thresholds); len(got) != 0 {
+		t.Errorf("checkStalls() while disconnected = %+v, want none", got)
+	}
+}
+
+func TestCollector_Failures(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.RecordDialFailure(types.Failure{Class: types.FailureTCPRefused, Reason: "connection refused"})
+	first.StartNewConnection()
+	first.EndConnectionWithFailure(types.Failure{Class: types.FailureCloseAbnormal, Reason: "closed by server (1006 )"})
+	second.StartNewConnection()
+	second.EndConnection("closed")
+	time.Sleep(time.Millisecond)
+	second.RecordDialFailure(types.Failure{Class: types.FailureTCPRefused, Reason: "connection refused again"})
+
+	snap := manager.Snapshot()
+	if snap.Failures[types.FailureTCPRefused] != 2 || snap.Failures[types.FailureCloseAbnormal] != 1 || snap.FailureCount() != 3 {
+		t.Errorf("Failures = %v, want 2 refused and 1 abnormal closure", snap.Failures)
+	}
+	if snap.LastFailure.Reason != "connection refused again" || snap.LastFailure.Time.IsZero() {
+		t.Errorf("LastFailure = %+v, want the most recent failure across connections", snap.LastFailure)
+	}
+
+	classes := map[types.FailureClass]bool{}
+	for _, conn := range snap.ConnectionHistory {
+		classes[conn.Class] = true
+	}
+	if !classes[types.FailureCloseAbnormal] || !classes[""] {
+		t.Errorf("ConnectionHistory = %+v, want one classified and one clean close", snap.ConnectionHistory)
+	}
+}
+
+func TestCollector_HandshakeAttemptsOutgrowSamples(t *testing.T) {
+	collector := NewManager().NewCollector()
+	for i := 0; i < maxLatencySamples+10; i++ {
+		// The first ten attempts fail and fall out of the kept samples
+		collector.RecordHandshake(types.HandshakeTiming{Time: time.Now(), Total: time.Millisecond, Failed: i < 10})
+	}
+
+	snap := collector.manager.Snapshot()
+	if len(snap.Handshakes) != maxLatencySamples || snap.HandshakeAttempts() != maxLatencySamples+10 {
+		t.Errorf("kept %d handshakes of %d attempts, want %d of %d",
+			len(snap.Handshakes), snap.HandshakeAttempts(), maxLatencySamples, maxLatencySamples+10)
+	}
+	if snap.HandshakesFailed != 10 {
+		t.Errorf("HandshakesFailed = %d, want the 10 failures no longer kept", snap.HandshakesFailed)
+	}
+}
+
+func TestCollector_Handshakes(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	start := time.Now()
+	first.RecordHandshake(types.HandshakeTiming{Time: start, DNS: 4 * time.Millisecond, Connect: 10 * time.Millisecond, Total: 30 * time.Millisecond})
+	second.RecordHandshake(types.HandshakeTiming{Time: start.Add(-time.Second), Connect: 20 * time.Millisecond, Total: 10 * time.Millisecond, Failed: true})
+
+	snap := manager.Snapshot()
+	if len(snap.Handshakes) != 2 || !snap.Handshakes[0].Failed {
+		t.Fatalf("Handshakes = %+v, want both attempts sorted by time", snap.Handshakes)
+	}
+	if snap.HandshakesFailed != 1 {
+		t.Errorf("HandshakesFailed = %d, want 1", snap.HandshakesFailed)
+	}
+
+	type latency struct {
+		count         int64
+		min, p50, max time.Duration
+	}
+	tests := []struct {
+		phase string
+		want  latency
+	}{
+		// Only attempts that looked up a name count towards DNS
+		{types.PhaseDNS, latency{count: 1, min: 4 * time.Millisecond, p50: 4 * time.Millisecond, max: 4 * time.Millisecond}},
+		// Percentiles are reported to the precision of their histogram bucket
+		{types.PhaseConnect, latency{count: 2, min: 10 * time.Millisecond, p50: 10007 * time.Microsecond, max: 20 * time.Millisecond}},
+		{types.PhaseTLS, latency{}},
+	}
+	for _, tt := range tests {
+		h := snap.HandshakeLatency(tt.phase)
+		if got := (latency{h.Count(), h.Min(), h.Percentile(50), h.Max()}); got != tt.want {
+			t.Errorf("HandshakeLatency(%q) = %+v, want %+v", tt.phase, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_Latencies(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.RecordPong(2 * time.Millisecond)
+	second.RecordPong(4 * time.Millisecond)
+	second.RecordPong(6 * time.Millisecond)
+	first.RecordLatency(types.LatencySubscribeConfirm, 30*time.Millisecond)
+
+	snap := manager.Snapshot()
+	rtt := snap.Latency(types.LatencyPingRTT)
+	if rtt.Count() != 3 || rtt.Min() != 2*time.Millisecond || rtt.Mean() != 4*time.Millisecond || rtt.Max() != 6*time.Millisecond {
+		t.Errorf("ping RTT = (%d, %v, %v, %v), want every pong merged across connections", rtt.Count(), rtt.Min(), rtt.Mean(), rtt.Max())
+	}
+	if got := snap.Latency(types.LatencySubscribeConfirm).Count(); got != 1 {
+		t.Errorf("subscribe confirm count = %d, want 1", got)
+	}
+	if got := snap.Connections[1].Latencies[types.LatencyPingRTT].Count(); got != 2 {
+		t.Errorf("second connection ping RTT count = %d, want 2", got)
+	}
+
+	// Snapshots must not share histograms with the collector
+	first.RecordPong(time.Second)
+	if rtt.Count() != 3 || snap.Connections[0].Latencies[types.LatencyPingRTT].Count() != 1 {
+		t.Error("recording after a snapshot changed the snapshot")
+	}
+	if snap.Latency("unknown") != nil {
+		t.Error("Latency() of a name never recorded should be nil")
+	}
+}
+
+func TestCollector_WireStats(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.StartNewConnection()
+	first.RecordUpgradeResponse(types.UpgradeResponse{Time: time.Now(), Status: 101,
+		Headers: map[string]string{"Sec-Websocket-Extensions": "permessage-deflate; server_no_context_takeover; client_no_context_takeover"}})
+	first.RecordWireMessage("newPendingTransactions", 300, 1000, 2*time.Millisecond)
+	first.RecordWireMessage(types.RPCMessageType, 40, 40, time.Millisecond)
+	second.StartNewConnection()
+	second.RecordUpgradeResponse(types.UpgradeResponse{Time: time.Now(), Status: 101, Headers: map[string]string{}})
+	second.RecordWireMessage("newPendingTransactions", 100, 1000, 4*time.Millisecond)
+
+	second.RecordSentMessage("newPendingTransactions", 80)
+
+	snap := manager.Snapshot()
+	if got := snap.CompressedConnections(); got != 1 {
+		t.Errorf("CompressedConnections() = %d, want 1", got)
+	}
+
+	tests := []struct {
+		name          string
+		got           types.WireStats
+		messages      int
+		wire, payload int64
+		readTime      time.Duration
+	}{
+		{"newPendingTransactions", snap.WireStats["newPendingTransactions"], 2, 400, 2000, 6 * time.Millisecond},
+		{"total", snap.TotalWireStats(), 3, 440, 2040, 7 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if tt.got.Payload.Count != tt.messages || tt.got.Payload.TotalBytes != tt.payload || tt.got.WireBytes != tt.wire || tt.got.ReadTime != tt.readTime {
+			t.Errorf("%s = %+v, want %d messages, %d wire and %d payload bytes in %v", tt.name, tt.got, tt.messages, tt.wire, tt.payload, tt.readTime)
+		}
+	}
+
+	// Payload sizes keep their distribution when merged
+	if buckets := snap.TotalWireStats().Payload.SizeBuckets; buckets[0] != 1 || buckets[1] != 2 {
+		t.Errorf("size buckets = %v, want the rpc message below 512 B and both transactions below 1 KB", buckets)
+	}
+	if got := snap.WireStats["newPendingTransactions"]; got.SentMessages != 1 || got.SentBytes != 80 {
+		t.Errorf("WireStats[newPendingTransactions] = %+v, want one request sent", got)
+	}
+}
+
+func TestCollector_Bytes(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	// The upgrade exchange counts towards the totals but not the connection
+	first.RecordBytesSent(200)
+	first.RecordBytesReceived(150)
+	first.StartNewConnection()
+	first.RecordBytesReceived(1000)
+	first.RecordBytesSent(50)
+	first.EndConnection("closed")
+	second.RecordBytesReceived(500)
+
+	snap := manager.Snapshot()
+	if snap.Stats.BytesReceived != 1650 || snap.Stats.BytesSent != 250 {
+		t.Errorf("bytes received/sent = %d/%d, want 1650/250", snap.Stats.BytesReceived, snap.Stats.BytesSent)
+	}
+	if history := snap.ConnectionHistory; len(history) != 1 || history[0].BytesReceived != 1000 || history[0].BytesSent != 50 {
+		t.Errorf("ConnectionHistory = %+v, want the bytes of the connection", history)
+	}
+	if conn := snap.Connections[1]; conn.Stats.BytesReceived != 500 {
+		t.Errorf("second connection received %d bytes, want 500", conn.Stats.BytesReceived)
+	}
+}
+
+func TestCollector_NotificationMethods(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+	collector.SetNotificationMethods([]string{"slotNotification", "logsNotification"})
+	collector.StartNewConnection()
+	collector.SetSubscriptionMapping("42", "slot")
+
+	// Numeric subscription IDs match their string form
+	collector.HandleResponse(types.JSONRPCResponse{
+		Method: "slotNotification",
+		Params: map[string]interface{}{"subscription": float64(42), "result": map[string]interface{}{"slot": 1}},
+	})
+	collector.HandleResponse(subscriptionEvent("42"))
+
+	snap := manager.Snapshot()
+	if snap.Stats.SubscriptionEvents != 1 || snap.MessagesByType["slot"] != 1 {
+		t.Errorf("SubscriptionEvents = %d, MessagesByType = %v, want only the slotNotification counted",
+			snap.Stats.SubscriptionEvents, snap.MessagesByType)
+	}
+	if !NewManager().NewCollector().IsNotification("eth_subscription") {
+		t.Error("collectors should count eth_subscription notifications by default")
+	}
+}
+
+func TestCollector_Sequences(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.StartNewConnection()
+	for _, slot := range []uint64{10, 11, 14, 13, 15} {
+		first.RecordSequence("1", "slot", slot)
+	}
+	// Numbering starts over on a new connection, so the outage is not a gap
+	first.EndConnection("closed")
+	first.StartNewConnection()
+	first.RecordSequence("1", "slot", 40)
+	first.RecordSequence("1", "slot", 41)
+
+	second.StartNewConnection()
+	second.RecordSequence("1", "slot", 50)
+	second.RecordSequence("1", "slot", 52)
+
+	want := types.SequenceStats{Received: 9, Highest: 52, Gaps: 2, Missed: 3, Repeated: 1}
+	if got := manager.Snapshot().Sequences["slot"]; got != want {
+		t.Errorf("Sequences[slot] = %+v, want %+v", got, want)
+	}
+}
+
+func TestCollector_Finality(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.StartNewConnection()
+	first.RecordHead(100, false) // no finalized head yet
+	first.RecordHead(98, true)   // lag 2
+	first.RecordHead(101, false) // lag 3
+	first.RecordHead(101, true)  // lag 0
+	// Heads are followed per connection, so the next one starts without any
+	first.EndConnection("closed")
+	first.StartNewConnection()
+	first.RecordHead(103, true)
+
+	second.StartNewConnection()
+	second.RecordHead(105, false)
+	second.RecordHead(99, true) // lag 6
+
+	got := manager.Snapshot().Finality
+	want := types.FinalityStats{Samples: 4, BestHead: 105, Finalized: 103, LastLag: 6, MaxLag: 6, TotalLag: 11}
+	if got != want {
+		t.Errorf("Finality = %+v, want %+v", got, want)
+	}
+	if mean := got.MeanLag(); mean != 2.75 {
+		t.Errorf("MeanLag() = %v, want 2.75", mean)
+	}
+}
+
+func TestCollector_FinalityLatency(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+	start := time.Now()
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	first.StartNewConnection()
+	first.recordHead(10, false, at(0))
+	first.recordHead(11, false, at(6))
+	first.recordHead(11, false, at(7)) // a repeated head keeps its first arrival
+	first.recordHead(9, true, at(8))   // finalizes neither
+	first.recordHead(11, true, at(18)) // finalizes 10 after 18s and 11 after 12s
+	first.recordHead(11, false, at(19))
+	// Heads awaiting finality are forgotten with their connection
+	first.recordHead(12, false, at(20))
+	first.EndConnection("closed")
+	first.StartNewConnection()
+	first.recordHead(12, true, at(30))
+
+	second.StartNewConnection()
+	second.recordHead(50, false, at(0))
+	second.recordHead(50, true, at(24))
+
+	latency := manager.Snapshot().Latency(types.LatencyFinalityLag)
+	if latency.Count() != 3 || latency.Min() != 12*time.Second || latency.Max() != 24*time.Second {
+		t.Errorf("finality lag = %d samples, min %v, max %v, want 3 samples from 12s to 24s", latency.Count(), latency.Min(), latency.Max())
+	}
+}
+
+func TestCollector_Operations(t *testing.T) {
+	manager := NewManager()
+	first := manager.NewCollector()
+	second := manager.NewCollector()
+
+	first.RecordOperation("blocks", types.OperationResult)
+	first.RecordOperation("blocks", types.OperationResult)
+	first.RecordOperation("blocks", types.OperationError)
+	second.RecordOperation("blocks", types.OperationResult)
+	second.RecordOperation("blocks", types.OperationComplete)
+	second.RecordOperation("transfers", types.OperationError)
+
+	snap := manager.Snapshot()
+	if got, want := snap.Operations["blocks"], (types.OperationStats{Results: 3, Errors: 1, Completed: 1}); got != want {
+		t.Errorf("Operations[blocks] = %+v, want %+v", got, want)
+	}
+	if got, want := snap.Operations["transfers"], (types.OperationStats{Errors: 1}); got != want {
+		t.Errorf("Operations[transfers] = %+v, want %+v", got, want)
+	}
+}
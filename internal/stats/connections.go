@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// ConnectionRow summarises one connection slot for the per-connection table
+type ConnectionRow struct {
+	Index               int
+	State               types.ConnectionState
+	Uptime              time.Duration // time connected, including the open connection
+	Reconnects          int
+	ActiveSubscriptions int
+	Events              int
+	EventsPerSecond     float64       // over the runtime
+	LastEventAge        time.Duration // zero before the first event
+	LastError           string        // reason of the most recent classified failure
+}
+
+// State returns the state of the connection slot
+func (c ConnectionSnapshot) State() types.ConnectionState {
+	switch {
+	case c.Closed:
+		return types.ConnectionClosed
+	case !c.Connected:
+		return types.ConnectionConnecting
+	case len(c.ActiveStalls) > 0:
+		return types.ConnectionStalled
+	case c.Stats.ActiveSubscriptions > 0:
+		return types.ConnectionSubscribed
+	}
+	return types.ConnectionConnecting
+}
+
+// ConnectionRows returns one row per connection slot, in slot order
+func (s Snapshot) ConnectionRows() []ConnectionRow {
+	runtime := s.Runtime()
+	rows := make([]ConnectionRow, len(s.Connections))
+	for i, conn := range s.Connections {
+		rows[i] = ConnectionRow{
+			Index:               conn.Index,
+			State:               conn.State(),
+			Uptime:              conn.Stats.TotalUptime,
+			Reconnects:          conn.Stats.TotalReconnections,
+			ActiveSubscriptions: conn.Stats.ActiveSubscriptions,
+			Events:              conn.Stats.EventsReceived,
+			EventsPerSecond:     perSecond(conn.Stats.EventsReceived, runtime),
+			LastError:           conn.LastFailure.Reason,
+		}
+		if !conn.Stats.LastEventTime.IsZero() {
+			rows[i].LastEventAge = s.Time.Sub(conn.Stats.LastEventTime)
+		}
+	}
+	return rows
+}
+
+// ConnectionStates counts connection slots by state
+func (s Snapshot) ConnectionStates() map[types.ConnectionState]int {
+	states := make(map[types.ConnectionState]int)
+	for _, conn := range s.Connections {
+		states[conn.State()]++
+	}
+	return states
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestConnectionSnapshot_State(t *testing.T) {
+	manager := NewManager()
+
+	// Dialing, then connected while waiting for confirmations
+	dialing := manager.NewCollector()
+	dialing.IncrementConnectionAttempts()
+	waiting := manager.NewCollector()
+	waiting.StartNewConnection()
+
+	subscribed := manager.NewCollector()
+	subscribed.StartNewConnection()
+	subscribed.SetSubscriptionMapping("0xa", "newHeads")
+
+	stalled := manager.NewCollector()
+	stalled.StartNewConnection()
+	stalled.SetSubscriptionMapping("0xb", "newHeads")
+	stalled.checkStalls(time.Now().Add(time.Minute), map[string]time.Duration{"newHeads": time.Second})
+
+	closed := manager.NewCollector()
+	closed.StartNewConnection()
+	closed.EndConnectionWithFailure(types.Failure{Class: types.FailureHTTP5xx, Reason: "upgrade rejected: HTTP 503 Service Unavailable"})
+	closed.MarkClosed()
+
+	snap := manager.Snapshot()
+	want := []types.ConnectionState{types.ConnectionConnecting, types.ConnectionConnecting, types.ConnectionSubscribed, types.ConnectionStalled, types.ConnectionClosed}
+	for i, conn := range snap.Connections {
+		if got := conn.State(); got != want[i] {
+			t.Errorf("Connections[%d].State() = %q, want %q", i, got, want[i])
+		}
+	}
+
+	states := snap.ConnectionStates()
+	if states[types.ConnectionConnecting] != 2 || states[types.ConnectionSubscribed] != 1 || states[types.ConnectionStalled] != 1 || states[types.ConnectionClosed] != 1 {
+		t.Errorf("ConnectionStates() = %v", states)
+	}
+}
+
+func TestSnapshot_ConnectionRows(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	snap := Snapshot{
+		Time:  start.Add(100 * time.Second),
+		Stats: types.Stats{ClientStartTime: start},
+		Connections: []ConnectionSnapshot{
+			{
+				Index:     0,
+				Connected: true,
+				Stats: types.Stats{
+					TotalUptime:         90 * time.Second,
+					TotalReconnections:  2,
+					ActiveSubscriptions: 3,
+					EventsReceived:      500,
+					LastEventTime:       start.Add(99 * time.Second),
+				},
+				LastFailure: types.Failure{Class: types.FailureCloseAbnormal, Reason: "read error: unexpected EOF"},
+			},
+			{Index: 1, Closed: true},
+		},
+	}
+
+	rows := snap.ConnectionRows()
+	if len(rows) != 2 {
+		t.Fatalf("ConnectionRows() returned %d rows, want 2", len(rows))
+	}
+	want := ConnectionRow{
+		Index:               0,
+		State:               types.ConnectionSubscribed,
+		Uptime:              90 * time.Second,
+		Reconnects:          2,
+		ActiveSubscriptions: 3,
+		Events:              500,
+		EventsPerSecond:     5,
+		LastEventAge:        time.Second,
+		LastError:           "read error: unexpected EOF",
+	}
+	if rows[0] != want {
+		t.Errorf("ConnectionRows()[0] = %+v, want %+v", rows[0], want)
+	}
+	if want := (ConnectionRow{Index: 1, State: types.ConnectionClosed}); rows[1] != want {
+		t.Errorf("ConnectionRows()[1] = %+v, want %+v", rows[1], want)
+	}
+}
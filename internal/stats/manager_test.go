@@ -14,10 +14,7 @@ func TestNewManager(t *testing.T) {
 		t.Fatal("NewManager() returned nil")
 	}
 
-	stats := manager.GetStats()
-	if stats == nil {
-		t.Fatal("GetStats() returned nil")
-	}
+	stats := manager.Snapshot().Stats
 
 	if stats.ClientStartTime.IsZero() {
 		t.Error("ClientStartTime should be set")
@@ -54,12 +51,13 @@ func TestManager_IncrementConnectionAttempts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			manager := NewManager()
+			collector := manager.NewCollector()
 			for i := 0; i < tt.increments; i++ {
-				manager.IncrementConnectionAttempts()
+				collector.IncrementConnectionAttempts()
 			}
 
-			if manager.GetStats().ConnectionAttempts != tt.want {
-				t.Errorf("ConnectionAttempts = %d, want %d", manager.GetStats().ConnectionAttempts, tt.want)
+			if got := manager.Snapshot().Stats.ConnectionAttempts; got != tt.want {
+				t.Errorf("ConnectionAttempts = %d, want %d", got, tt.want)
 			}
 		})
 	}
@@ -67,13 +65,14 @@ func TestManager_IncrementConnectionAttempts(t *testing.T) {
 
 func TestManager_StartNewConnection(t *testing.T) {
 	manager := NewManager()
+	collector := manager.NewCollector()
 
 	// Record time before starting connection
 	beforeStart := time.Now()
 
-	manager.StartNewConnection()
+	collector.StartNewConnection()
 
-	stats := manager.GetStats()
+	stats := manager.Snapshot().Stats
 
 	if stats.TotalConnections != 1 {
 		t.Errorf("TotalConnections = %d, want 1", stats.TotalConnections)
@@ -139,9 +138,10 @@ func TestManager_HandleResponse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			manager := NewManager()
-			manager.HandleResponse(tt.response)
+			collector := manager.NewCollector()
+			collector.HandleResponse(tt.response)
 
-			stats := manager.GetStats()
+			stats := manager.Snapshot().Stats
 
 			if stats.SubscriptionEvents != tt.wantSubscriptionEvents {
 				t.Errorf("SubscriptionEvents = %d, want %d", stats.SubscriptionEvents, tt.wantSubscriptionEvents)
@@ -172,17 +172,27 @@ func TestManager_HandleResponse(t *testing.T) {
 
 func TestManager_EndConnection(t *testing.T) {
 	manager := NewManager()
+	collector := manager.NewCollector()
 
 	// Start a connection first
-	manager.StartNewConnection()
+	collector.StartNewConnection()
 
 	// Wait a bit to ensure duration > 0
 	time.Sleep(1 * time.Millisecond)
 
 	// End the connection
-	manager.EndConnection()
+	collector.EndConnection("closed")
+
+	snap := manager.Snapshot()
+	stats := snap.Stats
 
-	stats := manager.GetStats()
+	if len(snap.ConnectionHistory) != 1 {
+		t.Errorf("ConnectionHistory = %d entries, want 1", len(snap.ConnectionHistory))
+	}
+
+	if snap.Connected() {
+		t.Error("Connected() should be false after EndConnection")
+	}
 
 	if stats.TotalUptime == 0 {
 		t.Error("TotalUptime should be greater than 0")
@@ -197,41 +207,58 @@ func TestManager_EndConnection(t *testing.T) {
 	}
 }
 
-func TestManager_SetSubscriptionMapping(t *testing.T) {
-	tests := []struct {
-		name             string
-		subscriptionID   string
-		subscriptionType string
-	}{
-		{
-			name:             "newHeads mapping",
-			subscriptionID:   "0x123abc",
-			subscriptionType: "newHeads",
-		},
-		{
-			name:             "logs mapping",
-			subscriptionID:   "0x456def",
-			subscriptionType: "logs",
-		},
-		{
-			name:             "empty subscription ID",
-			subscriptionID:   "",
-			subscriptionType: "newHeads",
-		},
+func TestManager_SubscriptionLifecycle(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+
+	collector.RecordSubscriptionRequest()
+	collector.RecordSubscriptionRequest()
+	collector.SetSubscriptionMapping("0xa", "newHeads")
+	collector.RecordSubscriptionCreated(false)
+	collector.SetSubscriptionMapping("0xb", "logs")
+	collector.RecordSubscriptionCreated(false)
+
+	stats := manager.Snapshot().Stats
+	if stats.ActiveSubscriptions != 2 || stats.SubscriptionsCreated != 2 || stats.SubscriptionRequests != 2 {
+		t.Errorf("Active/Created/Requests = %d/%d/%d, want 2/2/2",
+			stats.ActiveSubscriptions, stats.SubscriptionsCreated, stats.SubscriptionRequests)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			manager := NewManager()
-			manager.SetSubscriptionMapping(tt.subscriptionID, tt.subscriptionType)
+	// A notification is counted under the type its subscription ID maps to
+	collector.SetNotificationMethods([]string{"eth_subscription"})
+	notification := types.JSONRPCResponse{
+		Method: "eth_subscription",
+		Params: map[string]interface{}{"subscription": "0xa", "result": "0x1"},
+	}
+	collector.HandleResponse(notification)
+
+	// Connection closes: IDs are retired
+	collector.RetireSubscription("0xa")
+	collector.RetireSubscription("0xb")
+	collector.RetireSubscription("0xunknown")
+	stats = manager.Snapshot().Stats
+	if stats.ActiveSubscriptions != 0 {
+		t.Errorf("ActiveSubscriptions after retire = %d, want 0", stats.ActiveSubscriptions)
+	}
+	collector.HandleResponse(notification)
+	if got := manager.Snapshot().MessagesByType["newHeads"]; got != 1 {
+		t.Errorf("MessagesByType[newHeads] = %d, want notifications of a retired subscription ID left untyped", got)
+	}
 
-			// Test the mapping by checking if it's retrievable
-			retrievedType := manager.getSubscriptionTypeFromID(tt.subscriptionID)
-			if retrievedType != tt.subscriptionType {
-				t.Errorf("getSubscriptionTypeFromID(%q) = %q, want %q",
-					tt.subscriptionID, retrievedType, tt.subscriptionType)
-			}
-		})
+	// Reconnect re-creates the subscription under a new ID
+	collector.RecordSubscriptionRequest()
+	collector.SetSubscriptionMapping("0xc", "newHeads")
+	collector.RecordSubscriptionCreated(true)
+	stats = manager.Snapshot().Stats
+
+	if stats.ActiveSubscriptions != 1 {
+		t.Errorf("ActiveSubscriptions = %d, want 1", stats.ActiveSubscriptions)
+	}
+	if stats.SubscriptionsCreated != 3 {
+		t.Errorf("SubscriptionsCreated = %d, want 3", stats.SubscriptionsCreated)
+	}
+	if stats.Resubscriptions != 1 {
+		t.Errorf("Resubscriptions = %d, want 1", stats.Resubscriptions)
 	}
 }
 
@@ -256,24 +283,99 @@ func TestManager_IncrementReconnections(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			manager := NewManager()
+			collector := manager.NewCollector()
 
 			// Set up the total connections
 			for i := 0; i < tt.totalConnections; i++ {
-				manager.StartNewConnection()
+				collector.StartNewConnection()
 			}
 
-			manager.IncrementReconnections()
+			collector.IncrementReconnections()
 
-			if manager.GetStats().TotalReconnections != tt.expectedReconnections {
-				t.Errorf("TotalReconnections = %d, want %d",
-					manager.GetStats().TotalReconnections, tt.expectedReconnections)
+			if got := manager.Snapshot().Stats.TotalReconnections; got != tt.expectedReconnections {
+				t.Errorf("TotalReconnections = %d, want %d", got, tt.expectedReconnections)
 			}
 		})
 	}
 }
 
-func BenchmarkHandleResponse(b *testing.B) {
+func TestManager_Outages(t *testing.T) {
 	manager := NewManager()
+	collector := manager.NewCollector()
+
+	if len(manager.Snapshot().CurrentOutages) != 0 {
+		t.Error("CurrentOutages should be empty before the first connection")
+	}
+
+	// Failed attempts before the first connection are not an outage
+	collector.IncrementConnectionAttempts()
+	collector.StartNewConnection()
+	if got := len(manager.Snapshot().Outages); got != 0 {
+		t.Errorf("Outages = %d entries, want 0", got)
+	}
+
+	// Two outages with a reconnect in between
+	for i := 0; i < 2; i++ {
+		collector.EndConnection("closed")
+		if len(manager.Snapshot().CurrentOutages) != 1 {
+			t.Fatal("CurrentOutages should hold one outage after EndConnection")
+		}
+		collector.IncrementConnectionAttempts()
+		collector.IncrementConnectionAttempts()
+		time.Sleep(2 * time.Millisecond)
+		collector.StartNewConnection()
+	}
+
+	snap := manager.Snapshot()
+	outages := snap.Outages
+	if len(outages) != 2 {
+		t.Fatalf("Outages = %d entries, want 2", len(outages))
+	}
+	for i, outage := range outages {
+		if outage.Attempts != 2 {
+			t.Errorf("outage %d Attempts = %d, want 2", i, outage.Attempts)
+		}
+		if outage.Duration < 2*time.Millisecond || !outage.EndTime.After(outage.StartTime) {
+			t.Errorf("outage %d has invalid timing: %+v", i, outage)
+		}
+	}
+	if len(snap.CurrentOutages) != 0 {
+		t.Error("CurrentOutages should be empty after reconnecting")
+	}
+
+	mttr := snap.MeanTimeToRecovery()
+	wantMTTR := (outages[0].Duration + outages[1].Duration) / 2
+	if mttr != wantMTTR {
+		t.Errorf("MeanTimeToRecovery() = %v, want %v", mttr, wantMTTR)
+	}
+	if snap.LongestOutage() != max(outages[0].Duration, outages[1].Duration) {
+		t.Errorf("LongestOutage() = %v", snap.LongestOutage())
+	}
+
+	// An unresolved outage counts toward downtime but not MTTR
+	collector.EndConnection("closed")
+	time.Sleep(2 * time.Millisecond)
+	snap = manager.Snapshot()
+	if snap.TotalDowntime() < outages[0].Duration+outages[1].Duration+2*time.Millisecond {
+		t.Errorf("TotalDowntime() = %v should include the ongoing outage", snap.TotalDowntime())
+	}
+	if snap.MeanTimeToRecovery() != mttr {
+		t.Error("MeanTimeToRecovery() should ignore the ongoing outage")
+	}
+}
+
+func TestManager_MeanTimeToRecovery_NoOutages(t *testing.T) {
+	snap := NewManager().Snapshot()
+	if got := snap.MeanTimeToRecovery(); got != 0 {
+		t.Errorf("MeanTimeToRecovery() = %v, want 0", got)
+	}
+	if got := snap.TotalDowntime(); got != 0 {
+		t.Errorf("TotalDowntime() = %v, want 0", got)
+	}
+}
+
+func BenchmarkHandleResponse(b *testing.B) {
+	collector := NewManager().NewCollector()
 	response := types.JSONRPCResponse{
 		Method: "eth_subscription",
 		Params: map[string]interface{}{
@@ -286,15 +388,15 @@ func BenchmarkHandleResponse(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		manager.HandleResponse(response)
+		collector.HandleResponse(response)
 	}
 }
 
 func BenchmarkStartNewConnection(b *testing.B) {
-	manager := NewManager()
+	collector := NewManager().NewCollector()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		manager.StartNewConnection()
+		collector.StartNewConnection()
 	}
 }
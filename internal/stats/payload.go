@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// recordPayload adds a message of the given size to the payload statistics
+func recordPayload(p *types.PayloadStats, size int, at time.Time) {
+	if p.SizeBuckets == nil {
+		p.SizeBuckets = make([]int, len(types.PayloadSizeBuckets)+1)
+	}
+
+	if p.Count == 0 {
+		p.FirstReceived = at
+		p.MinBytes = size
+	}
+	p.Count++
+	p.TotalBytes += int64(size)
+	p.LastReceived = at
+	if size < p.MinBytes {
+		p.MinBytes = size
+	}
+	if size > p.MaxBytes {
+		p.MaxBytes = size
+	}
+
+	bucket := sort.SearchInts(types.PayloadSizeBuckets, size+1)
+	p.SizeBuckets[bucket]++
+}
+
+// AveragePayloadSize returns the mean payload size in bytes
+func AveragePayloadSize(p types.PayloadStats) float64 {
+	if p.Count == 0 {
+		return 0
+	}
+	return float64(p.TotalBytes) / float64(p.Count)
+}
+
+// PayloadBytesPerSecond returns the byte throughput since the first payload was received
+func PayloadBytesPerSecond(p types.PayloadStats, now time.Time) float64 {
+	elapsed := now.Sub(p.FirstReceived).Seconds()
+	if p.Count == 0 || elapsed <= 0 {
+		return 0
+	}
+	return float64(p.TotalBytes) / elapsed
+}
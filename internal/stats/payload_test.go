@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestRecordPayload(t *testing.T) {
+	var payload types.PayloadStats
+	start := time.Now()
+
+	sizes := []int{300, 800, 2000, 50000, 120000}
+	for i, size := range sizes {
+		recordPayload(&payload, size, start.Add(time.Duration(i)*time.Second))
+	}
+
+	if payload.Count != len(sizes) {
+		t.Errorf("Count = %d, want %d", payload.Count, len(sizes))
+	}
+	if payload.TotalBytes != 173100 {
+		t.Errorf("TotalBytes = %d, want 173100", payload.TotalBytes)
+	}
+	if payload.MinBytes != 300 || payload.MaxBytes != 120000 {
+		t.Errorf("Min/Max = %d/%d, want 300/120000", payload.MinBytes, payload.MaxBytes)
+	}
+
+	wantBuckets := []int{1, 1, 1, 0, 1, 1}
+	for i, want := range wantBuckets {
+		if payload.SizeBuckets[i] != want {
+			t.Errorf("SizeBuckets[%d] = %d, want %d", i, payload.SizeBuckets[i], want)
+		}
+	}
+
+	if got := AveragePayloadSize(payload); got != 34620 {
+		t.Errorf("AveragePayloadSize() = %v, want 34620", got)
+	}
+
+	if got := PayloadBytesPerSecond(payload, start.Add(10*time.Second)); got != 17310 {
+		t.Errorf("PayloadBytesPerSecond() = %v, want 17310", got)
+	}
+}
+
+func TestRecordPayload_BucketBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		wantBucket int
+	}{
+		{name: "empty payload", size: 0, wantBucket: 0},
+		{name: "just below 512B", size: 511, wantBucket: 0},
+		{name: "exactly 512B", size: 512, wantBucket: 1},
+		{name: "exactly 64KB", size: 65536, wantBucket: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload types.PayloadStats
+			recordPayload(&payload, tt.size, time.Now())
+			if payload.SizeBuckets[tt.wantBucket] != 1 {
+				t.Errorf("size %d landed in buckets %v, want bucket %d", tt.size, payload.SizeBuckets, tt.wantBucket)
+			}
+		})
+	}
+}
+
+func TestManager_RecordTransactionPayload(t *testing.T) {
+	manager := NewManager()
+	collector := manager.NewCollector()
+
+	collector.RecordTransactionPayload("newPendingTransactions:full", 1200, false, nil)
+	collector.RecordTransactionPayload("newPendingTransactions:full", 70, true, nil)
+	collector.RecordTransactionPayload("newPendingTransactions:full", 900, false, errors.New("bad payload"))
+
+	snap := manager.Snapshot()
+	txStats, ok := snap.TransactionStats["newPendingTransactions:full"]
+	if !ok {
+		t.Fatal("TransactionStats missing newPendingTransactions:full")
+	}
+	if txStats.Payload.Count != 3 {
+		t.Errorf("Payload.Count = %d, want 3", txStats.Payload.Count)
+	}
+	if txStats.Decoded != 1 || txStats.HashOnly != 1 || txStats.DecodeErrors != 1 {
+		t.Errorf("Decoded/HashOnly/DecodeErrors = %d/%d/%d, want 1/1/1",
+			txStats.Decoded, txStats.HashOnly, txStats.DecodeErrors)
+	}
+
+	if _, ok := snap.TransactionStats["newHeads"]; ok {
+		t.Error("TransactionStats should not track newHeads")
+	}
+}
+
+func TestMergePayload(t *testing.T) {
+	start := time.Now()
+	var a, b types.PayloadStats
+	recordPayload(&a, 100, start.Add(time.Second))
+	recordPayload(&a, 2000, start.Add(2*time.Second))
+	recordPayload(&b, 70000, start)
+
+	merged := mergePayload(a, b)
+	if merged.Count != 3 || merged.TotalBytes != 72100 {
+		t.Errorf("Count/TotalBytes = %d/%d, want 3/72100", merged.Count, merged.TotalBytes)
+	}
+	if merged.MinBytes != 100 || merged.MaxBytes != 70000 {
+		t.Errorf("Min/Max = %d/%d, want 100/70000", merged.MinBytes, merged.MaxBytes)
+	}
+	if !merged.FirstReceived.Equal(start) || !merged.LastReceived.Equal(start.Add(2*time.Second)) {
+		t.Errorf("First/Last = %v/%v", merged.FirstReceived, merged.LastReceived)
+	}
+	if merged.SizeBuckets[0] != 1 || merged.SizeBuckets[2] != 1 || merged.SizeBuckets[5] != 1 {
+		t.Errorf("SizeBuckets = %v", merged.SizeBuckets)
+	}
+
+	// Merging must not alias the inputs' buckets
+	merged.SizeBuckets[0] = 99
+	if a.SizeBuckets[0] != 1 {
+		t.Error("mergePayload() aliased its input")
+	}
+	if got := mergePayload(types.PayloadStats{}, a); got.Count != a.Count {
+		t.Errorf("mergePayload(empty, a).Count = %d, want %d", got.Count, a.Count)
+	}
+}
@@ -0,0 +1,555 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/histogram"
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// ConnectionSnapshot is a point-in-time copy of a single collector
+type ConnectionSnapshot struct {
+	Index             int
+	Connected         bool
+	Closed            bool // the client gave up and will not reconnect
+	Stats             types.Stats
+	ConnectionHistory []types.ConnectionHistory
+	Outages           []types.Outage
+	CurrentOutage     *types.Outage
+	Ping              types.PingStats
+	Calls             types.CallStats
+	Finality          types.FinalityStats
+	PingRTTs          []types.LatencySample
+	Stalls            []types.Stall
+	ActiveStalls      []types.Stall
+	Failures          map[types.FailureClass]int
+	LastFailure       types.Failure // zero until the first classified failure
+	Handshakes        []types.HandshakeTiming
+	HandshakesFailed  int
+	UpgradeResponses  []types.UpgradeResponse
+	MessagesByType    map[string]int
+	EventWindows      []int            // messages over each of types.RateWindows
+	TypeWindows       map[string][]int // notifications over each of types.RateWindows, by subscription type
+	LatestMessages    map[string]types.LatestMessage
+	TransactionStats  map[string]types.TransactionStats
+	WireStats         map[string]types.WireStats
+	Latencies         map[string]*histogram.Histogram // by latency name, see types.Latencies
+	Sequences         map[string]types.SequenceStats  // by subscription type
+	Operations        map[string]types.OperationStats // by subscription type
+}
+
+// Snapshot is an immutable, point-in-time view of all statistics.
+// The aggregate fields merge every connection; Connections keeps the
+// per-connection breakdown. Renderers must treat a Snapshot as read-only.
+type Snapshot struct {
+	Time              time.Time
+	Stats             types.Stats
+	ConnectionHistory []types.ConnectionHistory // sorted by start time
+	Outages           []types.Outage            // sorted by start time
+	CurrentOutages    []types.Outage            // outages still ongoing
+	Ping              types.PingStats
+	Calls             types.CallStats
+	Finality          types.FinalityStats
+	PingRTTs          []types.LatencySample // sorted by time
+	Stalls            []types.Stall         // resolved stalls, sorted by start time
+	ActiveStalls      []types.Stall         // stalls still ongoing, sorted by subscription type
+	Failures          map[types.FailureClass]int
+	LastFailure       types.Failure           // the most recent failure on any connection
+	Handshakes        []types.HandshakeTiming // sorted by time
+	HandshakesFailed  int                     // failed attempts over the whole run; Handshakes keeps only the most recent
+	UpgradeResponses  []types.UpgradeResponse // sorted by time
+	MessagesByType    map[string]int
+	EventWindows      []int            // messages over each of types.RateWindows
+	TypeWindows       map[string][]int // notifications over each of types.RateWindows, by subscription type
+	LatestMessages    map[string]types.LatestMessage
+	TransactionStats  map[string]types.TransactionStats
+	WireStats         map[string]types.WireStats      // by subscription type, plus types.RPCMessageType
+	Latencies         map[string]*histogram.Histogram // by latency name, see types.Latencies
+	Sequences         map[string]types.SequenceStats  // by subscription type
+	Operations        map[string]types.OperationStats // by subscription type
+	Connections       []ConnectionSnapshot
+}
+
+// mergeSnapshots aggregates per-connection snapshots into a single Snapshot
+func mergeSnapshots(now time.Time, clientStart time.Time, connections []ConnectionSnapshot) Snapshot {
+	snap := Snapshot{
+		Time:             now,
+		Stats:            types.Stats{ClientStartTime: clientStart},
+		Failures:         make(map[types.FailureClass]int),
+		MessagesByType:   make(map[string]int),
+		EventWindows:     make([]int, len(types.RateWindows)),
+		TypeWindows:      make(map[string][]int),
+		LatestMessages:   make(map[string]types.LatestMessage),
+		TransactionStats: make(map[string]types.TransactionStats),
+		WireStats:        make(map[string]types.WireStats),
+		Latencies:        make(map[string]*histogram.Histogram),
+		Sequences:        make(map[string]types.SequenceStats),
+		Operations:       make(map[string]types.OperationStats),
+		Connections:      connections,
+	}
+
+	for _, conn := range connections {
+		s := conn.Stats
+		snap.Stats.TotalConnections += s.TotalConnections
+		snap.Stats.TotalReconnections += s.TotalReconnections
+		snap.Stats.TotalUptime += s.TotalUptime
+		snap.Stats.EventsReceived += s.EventsReceived
+		snap.Stats.SubscriptionEvents += s.SubscriptionEvents
+		snap.Stats.ConfirmationEvents += s.ConfirmationEvents
+		snap.Stats.ErrorEvents += s.ErrorEvents
+		snap.Stats.ConnectionAttempts += s.ConnectionAttempts
+		snap.Stats.CurrentConnMessages += s.CurrentConnMessages
+		snap.Stats.SubscriptionRequests += s.SubscriptionRequests
+		snap.Stats.ActiveSubscriptions += s.ActiveSubscriptions
+		snap.Stats.SubscriptionsCreated += s.SubscriptionsCreated
+		snap.Stats.Resubscriptions += s.Resubscriptions
+		snap.Stats.BytesReceived += s.BytesReceived
+		snap.Stats.BytesSent += s.BytesSent
+
+		// The aggregate current connection is the oldest one still open
+		if conn.Connected && (snap.Stats.CurrentConnStart.IsZero() || s.CurrentConnStart.Before(snap.Stats.CurrentConnStart)) {
+			snap.Stats.CurrentConnStart = s.CurrentConnStart
+		}
+		if s.LastEventTime.After(snap.Stats.LastEventTime) {
+			snap.Stats.LastEventTime = s.LastEventTime
+		}
+		if s.LongestConnection > snap.Stats.LongestConnection {
+			snap.Stats.LongestConnection = s.LongestConnection
+		}
+		if s.ShortestConnection > 0 && (snap.Stats.ShortestConnection == 0 || s.ShortestConnection < snap.Stats.ShortestConnection) {
+			snap.Stats.ShortestConnection = s.ShortestConnection
+		}
+
+		snap.Ping = mergePingStats(snap.Ping, conn.Ping)
+		snap.Calls.Sent += conn.Calls.Sent
+		snap.Calls.Answered += conn.Calls.Answered
+		snap.Calls.Errors += conn.Calls.Errors
+		snap.Calls.Unanswered += conn.Calls.Unanswered
+		snap.Calls.Missed += conn.Calls.Missed
+		snap.Finality = mergeFinalityStats(snap.Finality, conn.Finality)
+		snap.PingRTTs = append(snap.PingRTTs, conn.PingRTTs...)
+		snap.Stalls = append(snap.Stalls, conn.Stalls...)
+		snap.ActiveStalls = append(snap.ActiveStalls, conn.ActiveStalls...)
+		snap.Handshakes = append(snap.Handshakes, conn.Handshakes...)
+		snap.HandshakesFailed += conn.HandshakesFailed
+		snap.UpgradeResponses = append(snap.UpgradeResponses, conn.UpgradeResponses...)
+		for class, count := range conn.Failures {
+			snap.Failures[class] += count
+		}
+		if conn.LastFailure.Time.After(snap.LastFailure.Time) {
+			snap.LastFailure = conn.LastFailure
+		}
+
+		snap.ConnectionHistory = append(snap.ConnectionHistory, conn.ConnectionHistory...)
+		snap.Outages = append(snap.Outages, conn.Outages...)
+		if conn.CurrentOutage != nil {
+			snap.CurrentOutages = append(snap.CurrentOutages, *conn.CurrentOutage)
+		}
+
+		for subType, count := range conn.MessagesByType {
+			snap.MessagesByType[subType] += count
+		}
+		snap.EventWindows = mergeWindowCounts(snap.EventWindows, conn.EventWindows)
+		for subType, counts := range conn.TypeWindows {
+			snap.TypeWindows[subType] = mergeWindowCounts(snap.TypeWindows[subType], counts)
+		}
+		for subType, msg := range conn.LatestMessages {
+			if existing, ok := snap.LatestMessages[subType]; !ok || msg.ReceivedAt.After(existing.ReceivedAt) {
+				snap.LatestMessages[subType] = msg
+			}
+		}
+		for subType, txStats := range conn.TransactionStats {
+			snap.TransactionStats[subType] = mergeTransactionStats(snap.TransactionStats[subType], txStats)
+		}
+		for messageType, wire := range conn.WireStats {
+			snap.WireStats[messageType] = mergeWireStats(snap.WireStats[messageType], wire)
+		}
+		for name, latency := range conn.Latencies {
+			if _, exists := snap.Latencies[name]; !exists {
+				snap.Latencies[name] = histogram.New()
+			}
+			snap.Latencies[name].Merge(latency)
+		}
+		for subType, sequence := range conn.Sequences {
+			snap.Sequences[subType] = mergeSequenceStats(snap.Sequences[subType], sequence)
+		}
+		for subType, operation := range conn.Operations {
+			merged := snap.Operations[subType]
+			merged.Results += operation.Results
+			merged.Errors += operation.Errors
+			merged.Completed += operation.Completed
+			snap.Operations[subType] = merged
+		}
+	}
+
+	sort.Slice(snap.ConnectionHistory, func(i, j int) bool {
+		return snap.ConnectionHistory[i].StartTime.Before(snap.ConnectionHistory[j].StartTime)
+	})
+	sort.Slice(snap.Outages, func(i, j int) bool {
+		return snap.Outages[i].StartTime.Before(snap.Outages[j].StartTime)
+	})
+	sort.Slice(snap.PingRTTs, func(i, j int) bool {
+		return snap.PingRTTs[i].Time.Before(snap.PingRTTs[j].Time)
+	})
+	sort.Slice(snap.Handshakes, func(i, j int) bool {
+		return snap.Handshakes[i].Time.Before(snap.Handshakes[j].Time)
+	})
+	sort.Slice(snap.UpgradeResponses, func(i, j int) bool {
+		return snap.UpgradeResponses[i].Time.Before(snap.UpgradeResponses[j].Time)
+	})
+	sort.Slice(snap.Stalls, func(i, j int) bool {
+		return snap.Stalls[i].StartTime.Before(snap.Stalls[j].StartTime)
+	})
+	sort.SliceStable(snap.ActiveStalls, func(i, j int) bool {
+		return snap.ActiveStalls[i].SubscriptionType < snap.ActiveStalls[j].SubscriptionType
+	})
+	return snap
+}
+
+// mergePingStats combines the heartbeat statistics of two connections.
+// LastRTT is taken from b, the connection merged last.
+func mergePingStats(a, b types.PingStats) types.PingStats {
+	if b.Received > 0 {
+		if a.Received == 0 || b.MinRTT < a.MinRTT {
+			a.MinRTT = b.MinRTT
+		}
+		a.MaxRTT = max(a.MaxRTT, b.MaxRTT)
+		a.LastRTT = b.LastRTT
+	}
+	a.Sent += b.Sent
+	a.Received += b.Received
+	a.Timeouts += b.Timeouts
+	a.TotalRTT += b.TotalRTT
+	return a
+}
+
+// mergeTransactionStats combines two transaction stream statistics
+func mergeTransactionStats(a, b types.TransactionStats) types.TransactionStats {
+	a.Decoded += b.Decoded
+	a.DecodeErrors += b.DecodeErrors
+	a.HashOnly += b.HashOnly
+	a.Payload = mergePayload(a.Payload, b.Payload)
+	return a
+}
+
+// mergeWireStats combines two wire statistics
+func mergeWireStats(a, b types.WireStats) types.WireStats {
+	a.Payload = mergePayload(a.Payload, b.Payload)
+	a.WireBytes += b.WireBytes
+	a.ReadTime += b.ReadTime
+	a.SentMessages += b.SentMessages
+	a.SentBytes += b.SentBytes
+	return a
+}
+
+// mergeSequenceStats combines the sequence statistics of two connections
+func mergeSequenceStats(a, b types.SequenceStats) types.SequenceStats {
+	a.Received += b.Received
+	a.Highest = max(a.Highest, b.Highest)
+	a.Gaps += b.Gaps
+	a.Missed += b.Missed
+	a.Repeated += b.Repeated
+	return a
+}
+
+// mergeFinalityStats combines the finality statistics of two connections.
+// LastLag is the larger of the two, so the aggregate shows the worst lag.
+func mergeFinalityStats(a, b types.FinalityStats) types.FinalityStats {
+	a.Samples += b.Samples
+	a.BestHead = max(a.BestHead, b.BestHead)
+	a.Finalized = max(a.Finalized, b.Finalized)
+	a.LastLag = max(a.LastLag, b.LastLag)
+	a.MaxLag = max(a.MaxLag, b.MaxLag)
+	a.TotalLag += b.TotalLag
+	return a
+}
+
+// mergePayload combines two payload statistics without modifying either
+func mergePayload(a, b types.PayloadStats) types.PayloadStats {
+	if b.Count == 0 {
+		return a
+	}
+	if a.Count == 0 {
+		b.SizeBuckets = append([]int(nil), b.SizeBuckets...)
+		return b
+	}
+
+	merged := types.PayloadStats{
+		Count:         a.Count + b.Count,
+		TotalBytes:    a.TotalBytes + b.TotalBytes,
+		MinBytes:      min(a.MinBytes, b.MinBytes),
+		MaxBytes:      max(a.MaxBytes, b.MaxBytes),
+		FirstReceived: a.FirstReceived,
+		LastReceived:  a.LastReceived,
+		SizeBuckets:   make([]int, len(types.PayloadSizeBuckets)+1),
+	}
+	if b.FirstReceived.Before(merged.FirstReceived) {
+		merged.FirstReceived = b.FirstReceived
+	}
+	if b.LastReceived.After(merged.LastReceived) {
+		merged.LastReceived = b.LastReceived
+	}
+	for i := range merged.SizeBuckets {
+		if i < len(a.SizeBuckets) {
+			merged.SizeBuckets[i] += a.SizeBuckets[i]
+		}
+		if i < len(b.SizeBuckets) {
+			merged.SizeBuckets[i] += b.SizeBuckets[i]
+		}
+	}
+	return merged
+}
+
+// Connected reports whether at least one connection is currently open
+func (s Snapshot) Connected() bool {
+	for _, conn := range s.Connections {
+		if conn.Connected {
+			return true
+		}
+	}
+	return false
+}
+
+// MeanTimeToRecovery returns the average duration of resolved outages
+func (s Snapshot) MeanTimeToRecovery() time.Duration {
+	if len(s.Outages) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, outage := range s.Outages {
+		total += outage.Duration
+	}
+	return total / time.Duration(len(s.Outages))
+}
+
+// LongestOutage returns the longest resolved outage duration
+func (s Snapshot) LongestOutage() time.Duration {
+	var longest time.Duration
+	for _, outage := range s.Outages {
+		longest = max(longest, outage.Duration)
+	}
+	return longest
+}
+
+// TotalDowntime returns the time spent disconnected, including ongoing outages
+func (s Snapshot) TotalDowntime() time.Duration {
+	var total time.Duration
+	for _, outage := range s.Outages {
+		total += outage.Duration
+	}
+	for _, outage := range s.CurrentOutages {
+		total += s.Time.Sub(outage.StartTime)
+	}
+	return total
+}
+
+// StallCount returns the number of stalls detected, including ongoing ones
+func (s Snapshot) StallCount() int {
+	return len(s.Stalls) + len(s.ActiveStalls)
+}
+
+// StallsByType counts detected stalls per subscription type, including ongoing ones
+func (s Snapshot) StallsByType() map[string]int {
+	counts := make(map[string]int)
+	for _, stall := range s.Stalls {
+		counts[stall.SubscriptionType]++
+	}
+	for _, stall := range s.ActiveStalls {
+		counts[stall.SubscriptionType]++
+	}
+	return counts
+}
+
+// StalledTime returns the total time subscriptions spent stalled, including ongoing stalls
+func (s Snapshot) StalledTime() time.Duration {
+	var total time.Duration
+	for _, stall := range s.Stalls {
+		total += stall.Duration
+	}
+	for _, stall := range s.ActiveStalls {
+		total += s.Time.Sub(stall.StartTime)
+	}
+	return total
+}
+
+// FailureCount returns the number of classified dial and connection failures
+func (s Snapshot) FailureCount() int {
+	total := 0
+	for _, count := range s.Failures {
+		total += count
+	}
+	return total
+}
+
+// TLSSessions counts established connections by negotiated TLS version and cipher suite
+func (s Snapshot) TLSSessions() map[string]int {
+	sessions := make(map[string]int)
+	for _, response := range s.UpgradeResponses {
+		if session := response.TLSSession(); session != "" && response.ConnectionNum > 0 {
+			sessions[session]++
+		}
+	}
+	return sessions
+}
+
+// CurrentTLSSession returns the TLS session of the most recent connection,
+// or an empty string for ws:// URLs and before the first connection
+func (s Snapshot) CurrentTLSSession() string {
+	for i := len(s.UpgradeResponses) - 1; i >= 0; i-- {
+		if s.UpgradeResponses[i].ConnectionNum > 0 {
+			return s.UpgradeResponses[i].TLSSession()
+		}
+	}
+	return ""
+}
+
+// CompressedConnections counts established connections on which the server
+// accepted permessage-deflate
+func (s Snapshot) CompressedConnections() int {
+	compressed := 0
+	for _, response := range s.UpgradeResponses {
+		if response.ConnectionNum > 0 && response.Compressed() {
+			compressed++
+		}
+	}
+	return compressed
+}
+
+// TotalWireStats combines the wire statistics of every message type
+func (s Snapshot) TotalWireStats() types.WireStats {
+	var total types.WireStats
+	for _, wire := range s.WireStats {
+		total = mergeWireStats(total, wire)
+	}
+	return total
+}
+
+// Latency returns the named latency distribution across every connection.
+// It is nil, which reports zero for every statistic, when nothing was recorded.
+func (s Snapshot) Latency(name string) *histogram.Histogram {
+	return s.Latencies[name]
+}
+
+// HandshakeLatency returns the distribution of one handshake phase across
+// every connection attempt in which that phase took place
+func (s Snapshot) HandshakeLatency(phase string) *histogram.Histogram {
+	return s.Latency(types.HandshakeLatency(phase))
+}
+
+// HandshakeAttempts returns the number of handshakes timed over the whole
+// run. Handshakes only keeps the most recent attempts of each collector.
+func (s Snapshot) HandshakeAttempts() int {
+	return int(s.HandshakeLatency(types.PhaseTotal).Count())
+}
+
+// Runtime returns the time elapsed since the client started
+func (s Snapshot) Runtime() time.Duration {
+	return s.Time.Sub(s.Stats.ClientStartTime)
+}
+
+// CurrentConnDuration returns how long the oldest open connection has been up
+func (s Snapshot) CurrentConnDuration() time.Duration {
+	if !s.Connected() {
+		return 0
+	}
+	return s.Time.Sub(s.Stats.CurrentConnStart)
+}
+
+// SinceLastEvent returns the time elapsed since the most recent event
+func (s Snapshot) SinceLastEvent() time.Duration {
+	if s.Stats.LastEventTime.IsZero() {
+		return 0
+	}
+	return s.Time.Sub(s.Stats.LastEventTime)
+}
+
+// CurrentConnRate returns the messages per second on the current connections
+func (s Snapshot) CurrentConnRate() float64 {
+	return perSecond(s.Stats.CurrentConnMessages, s.CurrentConnDuration())
+}
+
+// OverallRate returns the messages per second over the whole runtime
+func (s Snapshot) OverallRate() float64 {
+	return perSecond(s.Stats.EventsReceived, s.Runtime())
+}
+
+// TypeRate returns the notifications per second of a subscription type over the whole runtime
+func (s Snapshot) TypeRate(subType string) float64 {
+	return perSecond(s.MessagesByType[subType], s.Runtime())
+}
+
+// ConnectionEventRate returns the messages per second of connected time
+func (s Snapshot) ConnectionEventRate() float64 {
+	return perSecond(s.Stats.EventsReceived, s.Stats.TotalUptime)
+}
+
+// CallRate returns the workload calls sent per second of runtime
+func (s Snapshot) CallRate() float64 {
+	return perSecond(s.Calls.Sent, s.Runtime())
+}
+
+// ReceiveRate returns the bytes received per second of runtime
+func (s Snapshot) ReceiveRate() float64 {
+	return perSecond(s.Stats.BytesReceived, s.Runtime())
+}
+
+// SendRate returns the bytes sent per second of runtime
+func (s Snapshot) SendRate() float64 {
+	return perSecond(s.Stats.BytesSent, s.Runtime())
+}
+
+// SuccessRate returns the percentage of received messages that were not errors
+func (s Snapshot) SuccessRate() float64 {
+	if s.Stats.EventsReceived == 0 {
+		return 0
+	}
+	return float64(s.Stats.EventsReceived-s.Stats.ErrorEvents) / float64(s.Stats.EventsReceived) * 100
+}
+
+// Reliability returns the percentage of the runtime spent connected,
+// averaged over the connection slots so it never exceeds 100%
+func (s Snapshot) Reliability() float64 {
+	runtime := s.Runtime()
+	if runtime <= 0 {
+		return 0
+	}
+	slots := max(len(s.Connections), 1)
+	return s.Stats.TotalUptime.Seconds() / (runtime.Seconds() * float64(slots)) * 100
+}
+
+// EventsPerSubscription returns the mean number of events per created subscription
+func (s Snapshot) EventsPerSubscription() float64 {
+	if s.Stats.SubscriptionsCreated == 0 {
+		return 0
+	}
+	return float64(s.Stats.SubscriptionEvents) / float64(s.Stats.SubscriptionsCreated)
+}
+
+// AverageClosedConnection returns the mean duration of connections that have ended
+func (s Snapshot) AverageClosedConnection() time.Duration {
+	if len(s.ConnectionHistory) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, conn := range s.ConnectionHistory {
+		total += conn.Duration
+	}
+	return total / time.Duration(len(s.ConnectionHistory))
+}
+
+// AverageUptimePerConnection returns the total uptime divided by the number of connections
+func (s Snapshot) AverageUptimePerConnection() time.Duration {
+	if s.Stats.TotalConnections == 0 {
+		return 0
+	}
+	return s.Stats.TotalUptime / time.Duration(s.Stats.TotalConnections)
+}
+
+// perSecond returns count divided by the elapsed seconds, or 0 when nothing has elapsed
+func perSecond[N int | int64](count N, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed.Seconds()
+}
@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestSnapshot_DerivedMetrics(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	snap := Snapshot{
+		Time: start.Add(100 * time.Second),
+		Stats: types.Stats{
+			ClientStartTime:      start,
+			CurrentConnStart:     start.Add(80 * time.Second),
+			LastEventTime:        start.Add(95 * time.Second),
+			TotalConnections:     4,
+			TotalUptime:          80 * time.Second,
+			EventsReceived:       400,
+			SubscriptionEvents:   300,
+			ErrorEvents:          40,
+			CurrentConnMessages:  60,
+			SubscriptionsCreated: 6,
+		},
+		ConnectionHistory: []types.ConnectionHistory{
+			{Duration: 10 * time.Second},
+			{Duration: 30 * time.Second},
+		},
+		Stalls: []types.Stall{
+			{SubscriptionType: "newHeads", Duration: 12 * time.Second},
+			{SubscriptionType: "logs", Duration: 30 * time.Second},
+		},
+		ActiveStalls: []types.Stall{
+			{SubscriptionType: "newHeads", StartTime: start.Add(90 * time.Second)},
+		},
+		Connections: []ConnectionSnapshot{{Connected: true}},
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"Runtime", snap.Runtime(), 100 * time.Second},
+		{"CurrentConnDuration", snap.CurrentConnDuration(), 20 * time.Second},
+		{"SinceLastEvent", snap.SinceLastEvent(), 5 * time.Second},
+		{"CurrentConnRate", snap.CurrentConnRate(), 3.0},
+		{"OverallRate", snap.OverallRate(), 4.0},
+		{"ConnectionEventRate", snap.ConnectionEventRate(), 5.0},
+		{"SuccessRate", snap.SuccessRate(), 90.0},
+		{"Reliability", snap.Reliability(), 80.0},
+		{"EventsPerSubscription", snap.EventsPerSubscription(), 50.0},
+		{"AverageClosedConnection", snap.AverageClosedConnection(), 20 * time.Second},
+		{"AverageUptimePerConnection", snap.AverageUptimePerConnection(), 20 * time.Second},
+		{"StallCount", snap.StallCount(), 3},
+		{"StalledTime", snap.StalledTime(), 52 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s() = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshot_Reliability_PerConnection(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	slots := []ConnectionSnapshot{
+		{Stats: types.Stats{TotalUptime: 100 * time.Second}},
+		{Stats: types.Stats{TotalUptime: 60 * time.Second}},
+	}
+	snap := mergeSnapshots(start.Add(100*time.Second), start, slots)
+
+	// Two slots with 100% and 60% uptime are connected 80% of the time, not 160%
+	if got := snap.Reliability(); got != 80.0 {
+		t.Errorf("Reliability() = %v, want 80", got)
+	}
+}
+
+func TestSnapshot_DerivedMetrics_Empty(t *testing.T) {
+	snap := NewManager().Snapshot()
+
+	if snap.CurrentConnDuration() != 0 || snap.SinceLastEvent() != 0 {
+		t.Error("durations should be zero before the first connection")
+	}
+	if snap.CurrentConnRate() != 0 || snap.SuccessRate() != 0 || snap.EventsPerSubscription() != 0 {
+		t.Error("rates should be zero without events")
+	}
+	if snap.AverageClosedConnection() != 0 || snap.AverageUptimePerConnection() != 0 {
+		t.Error("averages should be zero without connections")
+	}
+	if snap.StallCount() != 0 || snap.StalledTime() != 0 || len(snap.StallsByType()) != 0 {
+		t.Error("stall metrics should be zero without stalls")
+	}
+}
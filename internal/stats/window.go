@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+// windowSeconds is the number of per-second buckets a rateWindow keeps:
+// the longest of types.RateWindows plus the second in progress
+const windowSeconds = 300 + 1
+
+// rateWindow counts events in a ring of per-second buckets, so recent rates
+// can be read without keeping every timestamp. Each bucket remembers the
+// second it counts and is reset when the ring comes round to it again.
+type rateWindow struct {
+	counts  [windowSeconds]int
+	seconds [windowSeconds]int64 // unix second counted by each bucket
+}
+
+// add counts an event at now
+func (r *rateWindow) add(now time.Time) {
+	second := now.Unix()
+	i := second % windowSeconds
+	if r.seconds[i] != second {
+		r.seconds[i] = second
+		r.counts[i] = 0
+	}
+	r.counts[i]++
+}
+
+// windowCounts returns the events counted in the complete seconds before now
+// over each of types.RateWindows. The current second is left out until it
+// is over, so a window never covers less time than it claims.
+func (r *rateWindow) windowCounts(now time.Time) []int {
+	second := now.Unix()
+	counts := make([]int, len(types.RateWindows))
+	for i, count := range r.counts {
+		age := second - r.seconds[i]
+		if count == 0 || age < 1 {
+			continue
+		}
+		for w, window := range types.RateWindows {
+			if age <= int64(window/time.Second) {
+				counts[w] += count
+			}
+		}
+	}
+	return counts
+}
+
+// mergeWindowCounts adds the window counts of b to a without modifying either
+func mergeWindowCounts(a, b []int) []int {
+	merged := make([]int, len(types.RateWindows))
+	for i := range merged {
+		if i < len(a) {
+			merged[i] += a[i]
+		}
+		if i < len(b) {
+			merged[i] += b[i]
+		}
+	}
+	return merged
+}
+
+// windowRates converts window counts into events per second. Windows longer
+// than the runtime are averaged over the complete seconds of the runtime.
+func windowRates(counts []int, runtime time.Duration) []float64 {
+	rates := make([]float64, len(types.RateWindows))
+	elapsed := runtime.Truncate(time.Second)
+	for i, window := range types.RateWindows {
+		if i < len(counts) {
+			rates[i] = perSecond(counts[i], min(window, elapsed))
+		}
+	}
+	return rates
+}
+
+// EventRates returns the messages per second over each of types.RateWindows
+func (s Snapshot) EventRates() []float64 {
+	return windowRates(s.EventWindows, s.Runtime())
+}
+
+// TypeRates returns the notifications per second of a subscription type over
+// each of types.RateWindows
+func (s Snapshot) TypeRates(subType string) []float64 {
+	return windowRates(s.TypeWindows[subType], s.Runtime())
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/commoddity/websocket-load-test/internal/types"
+)
+
+func TestRateWindow(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var window rateWindow
+
+	// One event a second for six minutes, then ten in the last complete second
+	for s := 0; s < 360; s++ {
+		window.add(start.Add(time.Duration(s) * time.Second))
+	}
+	for i := 0; i < 9; i++ {
+		window.add(start.Add(359*time.Second + time.Duration(i)*time.Millisecond))
+	}
+	// The current second is not over, so it does not count yet
+	window.add(start.Add(360 * time.Second))
+
+	now := start.Add(360*time.Second + 500*time.Millisecond)
+	if got, want := window.windowCounts(now), []int{19, 69, 309}; !slices.Equal(got, want) {
+		t.Errorf("windowCounts() = %v, want %v", got, want)
+	}
+
+	// A stall empties the short windows first; the last event now counts
+	later := now.Add(30 * time.Second)
+	if got, want := window.windowCounts(later), []int{0, 40, 280}; !slices.Equal(got, want) {
+		t.Errorf("windowCounts() after 30s of silence = %v, want %v", got, want)
+	}
+
+	// Buckets that the ring has come round to are reset, not added to
+	if got := window.windowCounts(now.Add(time.Hour)); !slices.Equal(got, []int{0, 0, 0}) {
+		t.Errorf("windowCounts() an hour later = %v, want none", got)
+	}
+}
+
+func TestWindowRates(t *testing.T) {
+	tests := []struct {
+		name    string
+		counts  []int
+		runtime time.Duration
+		want    []float64
+	}{
+		{"full windows", []int{20, 120, 600}, time.Hour, []float64{2, 2, 2}},
+		// Short runs average over the complete seconds so far
+		{"short run", []int{20, 30, 30}, 15*time.Second + 400*time.Millisecond, []float64{2, 2, 2}},
+		{"nothing elapsed", []int{0, 0, 0}, 0, []float64{0, 0, 0}},
+		{"missing counts", nil, time.Hour, []float64{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowRates(tt.counts, tt.runtime); !slices.Equal(got, tt.want) {
+				t.Errorf("windowRates(%v, %v) = %v, want %v", tt.counts, tt.runtime, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_WindowCounts(t *testing.T) {
+	manager := NewManager()
+	for range 2 {
+		collector := manager.NewCollector()
+		collector.StartNewConnection()
+		collector.SetSubscriptionMapping("0xa", "newHeads")
+		collector.HandleResponse(subscriptionEvent("0xa"))
+		collector.HandleResponse(types.JSONRPCResponse{ID: float64(1), Result: "0xa"})
+	}
+
+	// Events only count once their second is over
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	snap := manager.Snapshot()
+	if !slices.Equal(snap.EventWindows, []int{4, 4, 4}) {
+		t.Errorf("EventWindows = %v, want every message of both collectors", snap.EventWindows)
+	}
+	if got := snap.TypeWindows["newHeads"]; !slices.Equal(got, []int{2, 2, 2}) {
+		t.Errorf("TypeWindows[newHeads] = %v, want the notifications of both collectors", got)
+	}
+	snap.Stats.ClientStartTime = snap.Time.Add(-time.Hour)
+	if got, want := snap.TypeRates("newHeads"), []float64{0.2, 2.0 / 60, 2.0 / 300}; !slices.Equal(got, want) {
+		t.Errorf("TypeRates(newHeads) = %v, want %v", got, want)
+	}
+}
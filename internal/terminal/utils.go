@@ -1,10 +1,14 @@
 package terminal
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"syscall"
 	"unsafe"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 var (
@@ -17,6 +21,22 @@ var (
 	Cyan    = color.New(color.FgCyan, color.Bold)
 )
 
+// IsTerminal reports whether f refers to an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// DisableColor turns off colored output for all printers.
+// Colors are already off when NO_COLOR is set or stdout is not a terminal.
+func DisableColor() {
+	color.NoColor = true
+}
+
+// ColorEnabled reports whether colored output is enabled
+func ColorEnabled() bool {
+	return !color.NoColor
+}
+
 // GetTerminalWidth returns the current terminal width
 func GetTerminalWidth() int {
 	type winsize struct {
@@ -40,8 +60,11 @@ func GetTerminalWidth() int {
 
 // GetSubscriptionEmoji returns the appropriate emoji for each subscription type
 func GetSubscriptionEmoji(subscriptionType string) string {
+	// Modifiers such as "newPendingTransactions:full" share the base type's emoji
+	subscriptionType, _, _ = strings.Cut(subscriptionType, ":")
+
 	switch subscriptionType {
-	case "newHeads":
+	case "newHeads", "allHeads", "NewBlock", "NewBlockHeader", "NewBlockEvents":
 		return "🧊" // Ice cube for blocks
 	case "newPendingTransactions":
 		return "⚡" // Lightning for fast pending transactions
@@ -49,7 +72,33 @@ func GetSubscriptionEmoji(subscriptionType string) string {
 		return "📄" // Document for logs/events
 	case "syncing":
 		return "🔄" // Refresh for syncing
+	case "slot", "root":
+		return "🎰" // Slot machine for Solana slots
+	case "account", "program":
+		return "👛" // Purse for Solana accounts
+	case "signature":
+		return "🔏" // Seal for transaction signatures
+	case "Tx":
+		return "🧾" // Receipt for committed transactions
+	case "finalizedHeads":
+		return "🔒" // Lock for finalized blocks
+	case "storage":
+		return "🗄️" // Cabinet for storage changes
 	default:
 		return "📡" // Generic antenna for unknown types
 	}
 }
+
+// FormatBytes renders a byte count using binary units (B, KB, MB, GB)
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 2; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMG"[exp])
+}
@@ -1,7 +1,10 @@
 package terminal
 
 import (
+	"os"
 	"testing"
+
+	"github.com/fatih/color"
 )
 
 func TestGetSubscriptionEmoji(t *testing.T) {
@@ -30,11 +33,41 @@ func TestGetSubscriptionEmoji(t *testing.T) {
 			subscriptionType: "syncing",
 			wantEmoji:        "🔄",
 		},
+		{
+			name:             "CometBFT block subscription",
+			subscriptionType: "NewBlock",
+			wantEmoji:        "🧊",
+		},
+		{
+			name:             "CometBFT transaction subscription",
+			subscriptionType: "Tx",
+			wantEmoji:        "🧾",
+		},
+		{
+			name:             "Substrate finalized head subscription",
+			subscriptionType: "finalizedHeads",
+			wantEmoji:        "🔒",
+		},
+		{
+			name:             "Solana slot subscription",
+			subscriptionType: "slot",
+			wantEmoji:        "🎰",
+		},
+		{
+			name:             "Solana program subscription",
+			subscriptionType: "program",
+			wantEmoji:        "👛",
+		},
 		{
 			name:             "unknown subscription type",
 			subscriptionType: "unknownType",
 			wantEmoji:        "📡",
 		},
+		{
+			name:             "full transactions modifier",
+			subscriptionType: "newPendingTransactions:full",
+			wantEmoji:        "⚡",
+		},
 		{
 			name:             "empty string",
 			subscriptionType: "",
@@ -62,6 +95,53 @@ func TestGetSubscriptionEmoji(t *testing.T) {
 	}
 }
 
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes int64
+		want  string
+	}{
+		{
+			name:  "zero",
+			bytes: 0,
+			want:  "0 B",
+		},
+		{
+			name:  "below one kilobyte",
+			bytes: 1023,
+			want:  "1023 B",
+		},
+		{
+			name:  "kilobytes",
+			bytes: 1536,
+			want:  "1.5 KB",
+		},
+		{
+			name:  "megabytes",
+			bytes: 5 * 1024 * 1024,
+			want:  "5.0 MB",
+		},
+		{
+			name:  "gigabytes",
+			bytes: 3 * 1024 * 1024 * 1024,
+			want:  "3.0 GB",
+		},
+		{
+			name:  "terabytes stay in gigabytes",
+			bytes: 2 * 1024 * 1024 * 1024 * 1024,
+			want:  "2048.0 GB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatBytes(tt.bytes); got != tt.want {
+				t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetTerminalWidth(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -107,6 +187,36 @@ func TestColorVariables(t *testing.T) {
 	}
 }
 
+func TestIsTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if IsTerminal(f) {
+		t.Error("IsTerminal() = true for a regular file")
+	}
+}
+
+func TestDisableColor(t *testing.T) {
+	previous := color.NoColor
+	defer func() { color.NoColor = previous }()
+
+	color.NoColor = false
+	if !ColorEnabled() {
+		t.Fatal("ColorEnabled() = false before DisableColor()")
+	}
+
+	DisableColor()
+	if ColorEnabled() {
+		t.Error("ColorEnabled() = true after DisableColor()")
+	}
+	if got := Green.Sprint("ok"); got != "ok" {
+		t.Errorf("Green.Sprint() = %q after DisableColor(), want plain text", got)
+	}
+}
+
 func BenchmarkGetSubscriptionEmoji(b *testing.B) {
 	subscriptionTypes := []string{
 		"newHeads",
@@ -1,6 +1,13 @@
 package types
 
-import "time"
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Stats contains all statistics for the WebSocket client
 type Stats struct {
@@ -18,21 +25,385 @@ type Stats struct {
 	CurrentConnMessages int
 	LongestConnection   time.Duration
 	ShortestConnection  time.Duration
+
+	// Subscription bookkeeping
+	SubscriptionRequests int // subscribe requests sent across all connections
+	ActiveSubscriptions  int // confirmed subscriptions on the current connection
+	SubscriptionsCreated int // confirmed subscriptions across all connections
+	Resubscriptions      int // confirmed subscriptions re-created after a reconnect
+
+	// WebSocket traffic above TLS and before decompression, including the
+	// upgrade exchange and control frames
+	BytesReceived int64
+	BytesSent     int64
+}
+
+// PayloadStats tracks the size distribution and byte throughput of a message stream
+type PayloadStats struct {
+	Count         int
+	TotalBytes    int64
+	MinBytes      int
+	MaxBytes      int
+	FirstReceived time.Time
+	LastReceived  time.Time
+	SizeBuckets   []int // counts per PayloadSizeBuckets upper bound, plus one overflow bucket
+}
+
+// TransactionStats tracks a full transaction subscription stream
+type TransactionStats struct {
+	Payload      PayloadStats
+	Decoded      int
+	DecodeErrors int
+	HashOnly     int // notifications that carried only a hash despite requesting full objects
+}
+
+// WireStats tracks the messages of one type received and sent, comparing the
+// bytes received on the wire with the payloads after decompression
+type WireStats struct {
+	Payload      PayloadStats  // received payloads after decompression
+	WireBytes    int64         // received frame headers and payloads, compressed when negotiated
+	ReadTime     time.Duration // spent reading payloads once their first frame arrived, including inflating them
+	SentMessages int           // requests sent, such as subscribe requests
+	SentBytes    int64
+}
+
+// RPCMessageType keys the wire statistics of messages that are not
+// subscription notifications, such as subscribe responses
+const RPCMessageType = "rpc"
+
+// SavingPercent returns the share of payload bytes kept off the wire, which
+// is negative when framing costs more than compression saves
+func (w WireStats) SavingPercent() float64 {
+	if w.Payload.TotalBytes == 0 {
+		return 0
+	}
+	return float64(w.Payload.TotalBytes-w.WireBytes) * 100 / float64(w.Payload.TotalBytes)
+}
+
+// ReadTimePerMessage returns the mean time spent reading a payload
+func (w WireStats) ReadTimePerMessage() time.Duration {
+	if w.Payload.Count == 0 {
+		return 0
+	}
+	return w.ReadTime / time.Duration(w.Payload.Count)
+}
+
+// RateWindows are the sliding windows messages are counted over, shortest first
+var RateWindows = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// PayloadSizeBuckets are the upper bounds (exclusive, in bytes) of the payload size distribution
+var PayloadSizeBuckets = []int{512, 1024, 4096, 16384, 65536}
+
+// Transaction is a full transaction object as streamed by newPendingTransactions with includeTransactions=true
+type Transaction struct {
+	Hash                 string  `json:"hash"`
+	From                 string  `json:"from"`
+	To                   *string `json:"to"`
+	Nonce                string  `json:"nonce"`
+	Value                string  `json:"value"`
+	Gas                  string  `json:"gas"`
+	GasPrice             string  `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string  `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string  `json:"maxPriorityFeePerGas,omitempty"`
+	Input                string  `json:"input"`
+	Type                 string  `json:"type,omitempty"`
+	ChainID              string  `json:"chainId,omitempty"`
 }
 
 // ConnectionHistory tracks individual connection sessions
 type ConnectionHistory struct {
-	ConnectionNum int
+	Slot          int // index of the connection slot
+	ConnectionNum int // counts connections within the slot
 	StartTime     time.Time
 	EndTime       time.Time
 	Duration      time.Duration
 	Messages      int
+	BytesReceived int64
+	BytesSent     int64
+	Reason        string       // why the connection ended
+	Class         FailureClass // empty when the connection ended cleanly
 }
 
+// FailureClass categorises why a dial failed or a connection ended
+type FailureClass string
+
+// Failure classes for dials
+const (
+	FailureDNS         FailureClass = "dns"
+	FailureTCPRefused  FailureClass = "tcp_refused"
+	FailureDialTimeout FailureClass = "dial_timeout"
+	FailureTLS         FailureClass = "tls"
+	FailureHTTP401     FailureClass = "http_401"
+	FailureHTTP403     FailureClass = "http_403"
+	FailureHTTP429     FailureClass = "http_429"
+	FailureHTTP5xx     FailureClass = "http_5xx"
+	FailureHTTPOther   FailureClass = "http_other"
+
+	// Proxy failures are kept apart from failures of the target
+	FailureProxyConnect FailureClass = "proxy_connect" // the proxy could not be reached
+	FailureProxyAuth    FailureClass = "proxy_auth"    // the proxy rejected the credentials
+	FailureProxyTunnel  FailureClass = "proxy_tunnel"  // the proxy could not open a tunnel to the target
+)
+
+// Failure classes for open connections
+const (
+	FailureCloseNormal        FailureClass = "close_1000_normal"
+	FailureCloseGoingAway     FailureClass = "close_1001_going_away"
+	FailureCloseAbnormal      FailureClass = "close_1006_abnormal"
+	FailureCloseInternalError FailureClass = "close_1011_internal_error"
+	FailureCloseApplication   FailureClass = "close_4xxx_application"
+	FailureCloseOther         FailureClass = "close_other"
+	FailureReadTimeout        FailureClass = "read_timeout"
+	FailureJSONDecode         FailureClass = "json_decode"
+	FailureStalled            FailureClass = "stalled"
+	FailureRPCAuth            FailureClass = "jsonrpc_auth"
+)
+
+// Failure classes for either
+const (
+	FailureTCPReset FailureClass = "tcp_reset"
+	FailureNetwork  FailureClass = "network"
+	FailureOther    FailureClass = "other"
+)
+
+// Failure is a classified dial or connection failure
+type Failure struct {
+	Class  FailureClass
+	Reason string
+	Time   time.Time
+}
+
+// PingStats tracks WebSocket ping/pong heartbeats
+type PingStats struct {
+	Sent     int
+	Received int
+	Timeouts int // connections dropped because a pong did not arrive in time
+	MinRTT   time.Duration
+	MaxRTT   time.Duration
+	TotalRTT time.Duration
+	LastRTT  time.Duration
+}
+
+// AverageRTT returns the mean ping round-trip time
+func (p PingStats) AverageRTT() time.Duration {
+	if p.Received == 0 {
+		return 0
+	}
+	return p.TotalRTT / time.Duration(p.Received)
+}
+
+// CallStats counts the JSON-RPC calls sent by the call workload
+type CallStats struct {
+	Sent       int
+	Answered   int // responses carrying a result
+	Errors     int // responses carrying an error
+	Unanswered int // calls still awaiting a response when their connection closed
+	Missed     int // calls that fell due while disconnected and were never sent
+}
+
+// SequenceStats tracks notifications that carry a number expected to grow
+// by one each time, such as slots. Each subscription is followed on its own
+// and numbering starts over with every connection, so gaps are numbers a
+// subscription skipped while connected.
+type SequenceStats struct {
+	Received int
+	Highest  uint64 // highest number received
+	Gaps     int    // jumps past the next number
+	Missed   uint64 // numbers skipped by gaps
+	Repeated int    // numbers no higher than one already received, such as after a fork
+}
+
+// OperationEvent is a message about a GraphQL operation
+type OperationEvent string
+
+// Operation events
+const (
+	OperationResult   OperationEvent = "next"     // a result of the operation
+	OperationError    OperationEvent = "error"    // an error ending the operation, or a result carrying errors
+	OperationComplete OperationEvent = "complete" // the server ended the operation
+)
+
+// OperationStats counts the messages about the GraphQL operations of one
+// subscription type
+type OperationStats struct {
+	Results   int
+	Errors    int
+	Completed int
+}
+
+// FinalityStats tracks how far the finalized head trails the best head on
+// connections that follow both. A sample is taken whenever either head
+// moves, and heads are followed per connection like sequences.
+type FinalityStats struct {
+	Samples   int
+	BestHead  uint64 // highest best head received
+	Finalized uint64 // highest finalized head received
+	LastLag   uint64 // blocks between the heads at the latest sample
+	MaxLag    uint64
+	TotalLag  uint64 // sum of every sampled lag
+}
+
+// MeanLag returns the average number of blocks the finalized head trailed by
+func (f FinalityStats) MeanLag() float64 {
+	if f.Samples == 0 {
+		return 0
+	}
+	return float64(f.TotalLag) / float64(f.Samples)
+}
+
+// LatencySample is a single latency measurement on a connection
+type LatencySample struct {
+	ConnectionNum int
+	Time          time.Time
+	Latency       time.Duration
+}
+
+// HandshakeTiming breaks a connection attempt down into its phases. Phases
+// that did not happen, such as DNS for an IP address or TLS for ws:// URLs,
+// are zero, as is Upgrade when no HTTP response arrived.
+type HandshakeTiming struct {
+	Time    time.Time // when the attempt started
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Upgrade time.Duration // from sending the upgrade request to reading the response
+	Total   time.Duration
+	Failed  bool
+}
+
+// Handshake phases, in the order they happen
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "tcp_connect"
+	PhaseTLS     = "tls"
+	PhaseUpgrade = "upgrade"
+	PhaseTotal   = "total"
+)
+
+// HandshakePhases lists every handshake phase in order
+var HandshakePhases = []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseUpgrade, PhaseTotal}
+
+// Phase returns the duration of the named phase
+func (h HandshakeTiming) Phase(name string) time.Duration {
+	switch name {
+	case PhaseDNS:
+		return h.DNS
+	case PhaseConnect:
+		return h.Connect
+	case PhaseTLS:
+		return h.TLS
+	case PhaseUpgrade:
+		return h.Upgrade
+	case PhaseTotal:
+		return h.Total
+	}
+	return 0
+}
+
+// Latency distributions recorded into histograms over the whole run.
+// Each handshake phase is recorded under HandshakeLatency(phase).
+const (
+	LatencySubscribeConfirm = "subscribe_confirm"  // from sending a subscribe request to its confirmation
+	LatencyPingRTT          = "ping_rtt"           // from sending a ping to its pong
+	LatencyCallRTT          = "call_rtt"           // from actually sending a call to its response
+	LatencyCallRTTCorrected = "call_rtt_corrected" // from when a call was due to be sent to its response
+	LatencyFinalityLag      = "finality_lag"       // from a block arriving as best head to it being finalized
+)
+
+// Latencies lists the latency distributions other than handshake phases in display order
+var Latencies = []string{LatencySubscribeConfirm, LatencyPingRTT, LatencyCallRTT, LatencyCallRTTCorrected, LatencyFinalityLag}
+
+// HandshakeLatency names the latency distribution of a handshake phase.
+// The total phase is the time taken to dial a connection.
+func HandshakeLatency(phase string) string {
+	return "handshake_" + phase
+}
+
+// UpgradeResponse is the HTTP response to a WebSocket upgrade request.
+// Headers maps canonical header names to their values joined by ", " and
+// must not be modified once recorded.
+type UpgradeResponse struct {
+	ConnectionNum int // zero when the upgrade was rejected
+	Time          time.Time
+	Status        int
+	Headers       map[string]string
+	TLSVersion    string // negotiated TLS version, empty for ws:// URLs
+	TLSCipher     string // negotiated cipher suite, empty for ws:// URLs
+}
+
+// TLSSession describes the negotiated TLS version and cipher suite, or
+// returns an empty string for ws:// URLs
+func (r UpgradeResponse) TLSSession() string {
+	if r.TLSVersion == "" {
+		return ""
+	}
+	return r.TLSVersion + " " + r.TLSCipher
+}
+
+// Compressed reports whether the server accepted permessage-deflate
+func (r UpgradeResponse) Compressed() bool {
+	return strings.Contains(r.Headers["Sec-Websocket-Extensions"], "permessage-deflate")
+}
+
+// DefaultCaptureHeaders are the upgrade response headers shown by default:
+// rate limits, request IDs for correlating with server logs, and server or
+// region hints
+var DefaultCaptureHeaders = []string{
+	"X-Ratelimit-Limit",
+	"X-Ratelimit-Remaining",
+	"X-Ratelimit-Reset",
+	"Retry-After",
+	"X-Request-Id",
+	"Server",
+	"Via",
+	"Cf-Ray",
+}
+
+// Outage tracks a period spent disconnected between two connections
+type Outage struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	Attempts  int // connection attempts made while disconnected
+}
+
+// Stall tracks a period in which a subscription type delivered no
+// notifications for longer than its expected cadence
+type Stall struct {
+	ConnectionNum    int
+	SubscriptionType string
+	Threshold        time.Duration // the expected maximum silence
+	StartTime        time.Time     // last notification, or when the subscription was confirmed
+	DetectedAt       time.Time
+	EndTime          time.Time // zero while the stall is ongoing
+	Duration         time.Duration
+	Resolution       string // how the stall ended, e.g. StallResumed
+}
+
+// Stall resolutions
+const (
+	StallResumed          = "resumed"
+	StallConnectionClosed = "connection closed"
+)
+
+// ConnectionState is the state of a connection slot
+type ConnectionState string
+
+// Connection states, from worst to best
+const (
+	ConnectionStalled    ConnectionState = "stalled"    // connected, but a subscription went silent
+	ConnectionClosed     ConnectionState = "closed"     // gave up and will not reconnect
+	ConnectionConnecting ConnectionState = "connecting" // dialing, or connected and waiting for subscriptions
+	ConnectionSubscribed ConnectionState = "subscribed" // connected with confirmed subscriptions
+)
+
+// ConnectionStates lists every connection state, from worst to best
+var ConnectionStates = []ConnectionState{ConnectionStalled, ConnectionClosed, ConnectionConnecting, ConnectionSubscribed}
+
 // JSONRPCRequest represents a JSON-RPC request
 type JSONRPCRequest struct {
 	JSONRPC string `json:"jsonrpc"`
-	ID      int    `json:"id"`
+	ID      any    `json:"id"` // a number for subscribe requests, a string for workload calls
 	Method  string `json:"method"`
 	Params  any    `json:"params,omitempty"`
 }
@@ -47,6 +418,60 @@ type JSONRPCResponse struct {
 	Params  any    `json:"params,omitempty"`
 }
 
+// SubscriptionID returns a subscription ID sent by the server as a string.
+// Most APIs use strings, but some, such as Solana, use numbers.
+func SubscriptionID(id any) (string, bool) {
+	switch id := id.(type) {
+	case string:
+		return id, true
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// PubSub names the JSON-RPC methods of a subscription API and the shape of
+// their params. Method names may contain {type}, which is replaced by the
+// subscription type. Params are JSON templates in which {type} is replaced
+// and a "{id}" string becomes the server subscription ID as it was sent.
+// Empty fields use the Ethereum eth_subscribe API.
+type PubSub struct {
+	SubscribeMethod    string
+	UnsubscribeMethod  string
+	NotificationMethod string
+
+	// SubscribeParams maps subscription types to their params template;
+	// the "*" entry applies to every type without its own
+	SubscribeParams   map[string]json.RawMessage
+	UnsubscribeParams json.RawMessage
+
+	// TypeMethods maps subscription types to methods of their own, for APIs
+	// whose method names do not follow a single template
+	TypeMethods map[string]PubSubMethods
+}
+
+// PubSubMethods are the methods of one subscription type. Empty fields use
+// the methods of the PubSub.
+type PubSubMethods struct {
+	Subscribe    string
+	Unsubscribe  string
+	Notification string
+}
+
+// Subscription describes a single subscription type requested on the command line
+type Subscription struct {
+	Type             string
+	FullTransactions bool
+}
+
+// Key returns the name used to track the subscription in statistics
+func (s Subscription) Key() string {
+	if s.FullTransactions {
+		return s.Type + ":full"
+	}
+	return s.Type
+}
+
 // Config holds the configuration for the WebSocket client
 type Config struct {
 	URL           string
@@ -55,6 +480,52 @@ type Config struct {
 	Subscriptions string
 	SubCount      int
 	EnableLogging bool
+
+	// Connections is the number of connection slots, each dialed and
+	// subscribed by its own client
+	Connections int
+
+	// Reconnection backoff
+	BackoffStrategy string
+	BackoffBase     time.Duration
+	BackoffMax      time.Duration
+	MaxRetries      int
+
+	// Heartbeats; a zero PingInterval disables pings and read deadlines
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+
+	// Stall detection; subscription type keys map to the longest expected
+	// silence between notifications. StallReconnect forces a reconnect on stall.
+	StallThresholds map[string]time.Duration
+	StallReconnect  bool
+
+	// RetryAuthFailures keeps reconnecting after the server rejected the credentials
+	RetryAuthFailures bool
+
+	// CaptureHeaders lists the upgrade response headers shown in reports,
+	// in canonical form
+	CaptureHeaders []string
+
+	// TLS configures wss:// connections; nil keeps Go's defaults
+	TLS *tls.Config
+
+	// Proxy is the HTTP CONNECT or SOCKS5 proxy to connect through; nil connects directly
+	Proxy *url.URL
+
+	// Compression offers permessage-deflate; the server decides whether to use it
+	Compression bool
+
+	// Call workload; every connection sends CallMethod with CallParams at
+	// CallRate calls per second, open loop. A zero CallRate sends no calls.
+	CallRate   float64
+	CallMethod string
+	CallParams json.RawMessage
+
+	// Protocol names the subscription API, such as "solana"; empty is Ethereum.
+	// PubSub configures its methods; the zero value is eth_subscribe.
+	Protocol string
+	PubSub   PubSub
 }
 
 // LatestMessage holds information about the most recent WebSocket message
@@ -245,3 +245,93 @@ func TestJSONRPCResponse_Structure(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscription_Key(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want string
+	}{
+		{
+			name: "plain subscription",
+			sub:  Subscription{Type: "newHeads"},
+			want: "newHeads",
+		},
+		{
+			name: "full transactions",
+			sub:  Subscription{Type: "newPendingTransactions", FullTransactions: true},
+			want: "newPendingTransactions:full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.Key(); got != tt.want {
+				t.Errorf("Subscription.Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPingStats_AverageRTT(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats PingStats
+		want  time.Duration
+	}{
+		{name: "no pongs", stats: PingStats{Sent: 3}, want: 0},
+		{name: "mean of received pongs", stats: PingStats{Received: 4, TotalRTT: 100 * time.Millisecond}, want: 25 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.AverageRTT(); got != tt.want {
+				t.Errorf("AverageRTT() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFinalityStats_MeanLag(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats FinalityStats
+		want  float64
+	}{
+		{name: "no samples", stats: FinalityStats{BestHead: 10}, want: 0},
+		{name: "mean of sampled lags", stats: FinalityStats{Samples: 4, TotalLag: 10}, want: 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.MeanLag(); got != tt.want {
+				t.Errorf("MeanLag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWireStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		stats       WireStats
+		wantSaving  float64
+		wantPerRead time.Duration
+	}{
+		{name: "empty", stats: WireStats{}},
+		{name: "compressed", stats: WireStats{Payload: PayloadStats{Count: 4, TotalBytes: 1000}, WireBytes: 250, ReadTime: 2 * time.Millisecond},
+			wantSaving: 75, wantPerRead: 500 * time.Microsecond},
+		{name: "framing overhead", stats: WireStats{Payload: PayloadStats{Count: 1, TotalBytes: 100}, WireBytes: 102}, wantSaving: -2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.SavingPercent(); got != tt.wantSaving {
+				t.Errorf("SavingPercent() = %v, want %v", got, tt.wantSaving)
+			}
+			if got := tt.stats.ReadTimePerMessage(); got != tt.wantPerRead {
+				t.Errorf("ReadTimePerMessage() = %v, want %v", got, tt.wantPerRead)
+			}
+		})
+	}
+}